@@ -0,0 +1,247 @@
+package arbitrage
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+var (
+	// ErrInvalidOptions max hops must be at least 2 to form a cycle
+	ErrInvalidOptions = fmt.Errorf("invalid options")
+)
+
+const (
+	// DefaultMaxHops default maximum number of pairs in a cycle
+	DefaultMaxHops = 3
+	// DefaultMaxNumResults default maximum number of arbitrages to return
+	DefaultMaxNumResults = 10
+
+	floatPrecision = 256
+)
+
+// Options of the arbitrage search
+type Options struct {
+	// the maximum number of pairs a cycle goes through, at least 2
+	MaxHops int
+	// how many results to return
+	MaxNumResults int
+}
+
+// NewDefaultOptions creates Options with default values
+func NewDefaultOptions() *Options {
+	return &Options{
+		MaxHops:       DefaultMaxHops,
+		MaxNumResults: DefaultMaxNumResults,
+	}
+}
+
+// Arbitrage a profitable cycle, i.e. an exact input trade whose route starts and ends in the same token,
+// with the profit-maximizing input amount
+type Arbitrage struct {
+	*entities.Trade
+	// output amount minus input amount
+	Profit *entities.TokenAmount
+}
+
+/**
+ * Finds the cycles through pairs that start and end in token, and returns the profitable ones with their
+ * profit-maximizing input amounts, ranked by profit in decreasing order.
+ * The input amount is computed in closed form for cycles of ClassicPairs, and searched numerically otherwise.
+ * @param pairs the pairs to consider
+ * @param token the token to start and end in, e.g. WETH
+ * @param options maximum hops and results, nil means default
+ */
+func FindArbitrages(pairs []entities.Pair, token *entities.Token, options *Options) ([]*Arbitrage, error) {
+	if options == nil {
+		options = NewDefaultOptions()
+	}
+	if options.MaxHops < 2 || options.MaxNumResults <= 0 {
+		return nil, ErrInvalidOptions
+	}
+	if len(pairs) == 0 {
+		return nil, entities.ErrInvalidPairs
+	}
+
+	arbitrages := make([]*Arbitrage, 0)
+	graph := entities.NewPairGraph(pairs)
+	for _, cycle := range findCycles(graph, token, options.MaxHops) {
+		route, err := entities.NewRoute(cycle, token, token)
+		if err != nil {
+			return nil, err
+		}
+		arbitrage, err := optimize(route)
+		if err != nil {
+			return nil, err
+		}
+		if arbitrage == nil {
+			continue
+		}
+		arbitrages = sortedInsert(arbitrages, arbitrage, options.MaxNumResults)
+	}
+	return arbitrages, nil
+}
+
+// findCycles returns the pair sequences from token back to token making 2 to maxHops hops, without going through a
+// pool or an intermediate token twice. Pairs of the same pool share its state, so pools are told apart by address.
+func findCycles(graph *entities.PairGraph, token *entities.Token, maxHops int) [][]entities.Pair {
+	cycles := make([][]entities.Pair, 0)
+	path := make([]entities.Pair, 0, maxHops)
+	used := make(map[common.Address]bool, maxHops)
+	visited := make(map[common.Address]bool, maxHops)
+
+	var visit func(current *entities.Token)
+	visit = func(current *entities.Token) {
+		for _, pair := range graph.PairsOf(current) {
+			if used[pair.GetAddress()] || pair.Reserve0().EqualTo(entities.ZeroFraction) || pair.Reserve1().EqualTo(entities.ZeroFraction) {
+				continue
+			}
+			next := pair.Token0()
+			if current.Equals(next) {
+				next = pair.Token1()
+			}
+			if next.Equals(token) {
+				if len(path) > 0 {
+					cycles = append(cycles, append(append([]entities.Pair{}, path...), pair))
+				}
+				continue
+			}
+			if len(path)+2 > maxHops || visited[next.Address] {
+				continue
+			}
+			used[pair.GetAddress()], visited[next.Address] = true, true
+			path = append(path, pair)
+			visit(next)
+			path = path[:len(path)-1]
+			delete(used, pair.GetAddress())
+			delete(visited, next.Address)
+		}
+	}
+	visit(token)
+	return cycles
+}
+
+// optimize returns the arbitrage of route with the profit-maximizing input amount, nil if route is not profitable
+func optimize(route *entities.Route) (*Arbitrage, error) {
+	amountIn, ok := optimalClassicInput(route)
+	if !ok {
+		amountIn = optimalInput(route)
+	}
+	if amountIn == nil || amountIn.Sign() <= 0 {
+		return nil, nil
+	}
+
+	tokenAmountIn, err := entities.NewTokenAmount(route.Input, amountIn)
+	if err != nil {
+		return nil, err
+	}
+	trade, err := entities.NewTrade(route, tokenAmountIn, constants.ExactInput)
+	if err != nil {
+		// the amount is not tradable
+		return nil, nil
+	}
+	if !trade.OutputAmount().GreaterThan(trade.InputAmount().Fraction) {
+		return nil, nil
+	}
+	profit, err := trade.OutputAmount().Subtract(trade.InputAmount())
+	if err != nil {
+		return nil, err
+	}
+	return &Arbitrage{Trade: trade, Profit: profit}, nil
+}
+
+// optimalClassicInput returns the input maximizing out(x) - x of a route of ClassicPairs.
+// Composed into a single pair, out(x) = gamma*x*E1 / (E0 + gamma*x), so out'(x) = 1 gives
+// x = (sqrt(gamma*E0*E1) - E0) / gamma, which is positive only if gamma*E1 > E0.
+func optimalClassicInput(route *entities.Route) (*big.Int, bool) {
+	reserveIn, reserveOut, gamma, ok := entities.VirtualReserves(route)
+	if !ok {
+		return nil, false
+	}
+	root := newFloat().Sqrt(newFloat().Mul(newFloat().Mul(gamma, reserveIn), reserveOut))
+	amountIn := newFloat().Quo(newFloat().Sub(root, reserveIn), gamma)
+	if amountIn.Sign() <= 0 {
+		return nil, true
+	}
+	amount, _ := amountIn.Int(nil)
+	return amount, true
+}
+
+// optimalInput ternary searches the input maximizing out(x) - x, which is concave for the supported pair types.
+// The input is bounded by the reserve of the input token in the first pair.
+func optimalInput(route *entities.Route) *big.Int {
+	reserve, err := route.Pairs[0].ReserveOf(route.Input)
+	if err != nil {
+		return nil
+	}
+	lo, hi := new(big.Int), new(big.Int).Set(reserve.Raw())
+	three := big.NewInt(3)
+	for new(big.Int).Sub(hi, lo).Cmp(three) > 0 {
+		third := new(big.Int).Div(new(big.Int).Sub(hi, lo), three)
+		m1 := new(big.Int).Add(lo, third)
+		m2 := new(big.Int).Sub(hi, third)
+		if profit(route, m1).Cmp(profit(route, m2)) < 0 {
+			lo = m1
+		} else {
+			hi = m2
+		}
+	}
+
+	best, bestProfit := new(big.Int), new(big.Int)
+	for x := new(big.Int).Set(lo); x.Cmp(hi) <= 0; x.Add(x, constants.One) {
+		if p := profit(route, x); p.Cmp(bestProfit) > 0 {
+			best, bestProfit = new(big.Int).Set(x), p
+		}
+	}
+	return best
+}
+
+// profit returns out(amountIn) - amountIn of the route, or -amountIn if the amount can not be traded
+func profit(route *entities.Route, amountIn *big.Int) *big.Int {
+	loss := new(big.Int).Neg(amountIn)
+	if amountIn.Sign() == 0 {
+		return loss
+	}
+	amount, err := entities.NewTokenAmount(route.Input, amountIn)
+	if err != nil {
+		return loss
+	}
+	// a pool met again is traded in its state after the previous hop
+	states := make(map[common.Address]entities.Pair, len(route.Pairs))
+	for _, pair := range route.Pairs {
+		if state, ok := states[pair.GetAddress()]; ok && state.Token0().Equals(pair.Token0()) && state.Token1().Equals(pair.Token1()) {
+			pair = state
+		}
+		var next entities.Pair
+		amount, next, err = pair.GetOutputAmount(amount)
+		if err != nil {
+			return loss
+		}
+		states[pair.GetAddress()] = next
+	}
+	return new(big.Int).Sub(amount.Raw(), amountIn)
+}
+
+// sortedInsert inserts arbitrage by decreasing profit, keeping at most maxSize arbitrages
+func sortedInsert(arbitrages []*Arbitrage, arbitrage *Arbitrage, maxSize int) []*Arbitrage {
+	i := len(arbitrages)
+	for i > 0 && arbitrages[i-1].Profit.Raw().Cmp(arbitrage.Profit.Raw()) < 0 {
+		i--
+	}
+	if i >= maxSize {
+		return arbitrages
+	}
+	arbitrages = append(arbitrages[:i], append([]*Arbitrage{arbitrage}, arbitrages[i:]...)...)
+	if len(arbitrages) > maxSize {
+		arbitrages = arbitrages[:maxSize]
+	}
+	return arbitrages
+}
+
+func newFloat() *big.Float {
+	return new(big.Float).SetPrec(floatPrecision)
+}
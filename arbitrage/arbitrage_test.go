@@ -0,0 +1,191 @@
+package arbitrage
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+// assertOptimal asserts that moving the input amount of arbitrage does not increase the profit
+func assertOptimal(t *testing.T, arbitrage *Arbitrage) {
+	t.Helper()
+	amountIn := arbitrage.InputAmount().Raw()
+	for _, delta := range []int64{1e9, 1e15, 1e18} {
+		for _, amount := range []*big.Int{
+			new(big.Int).Add(amountIn, big.NewInt(delta)),
+			new(big.Int).Sub(amountIn, big.NewInt(delta)),
+		} {
+			if p := profit(arbitrage.Route, amount); p.Cmp(arbitrage.Profit.Raw()) > 0 {
+				t.Errorf("input [%+v] gives more profit [%+v] than [%+v]", amount, p, arbitrage.Profit.Raw())
+			}
+		}
+	}
+}
+
+// nolint funlen
+func TestFindArbitrages(t *testing.T) {
+	weth := entities.WETH[constants.Mainnet]
+	tokenA, _ := entities.NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "A", "")
+	tokenB, _ := entities.NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "B", "")
+
+	tokenAmount_weth_1000, _ := entities.NewTokenAmount(weth, new(big.Int).Mul(big.NewInt(1000), constants.B1e18))
+	tokenAmount_a_1000000, _ := entities.NewTokenAmount(tokenA, new(big.Int).Mul(big.NewInt(1000000), constants.B1e18))
+	tokenAmount_b_900000, _ := entities.NewTokenAmount(tokenB, new(big.Int).Mul(big.NewInt(900000), constants.B1e18))
+	tokenAmount_b_1000000, _ := entities.NewTokenAmount(tokenB, new(big.Int).Mul(big.NewInt(1000000), constants.B1e18))
+	tokenAmount_b_1200000, _ := entities.NewTokenAmount(tokenB, new(big.Int).Mul(big.NewInt(1200000), constants.B1e18))
+
+	// 1 WETH = 1000 A = 1000 B, but B/WETH prices 1 WETH = 900 B
+	wethA, _ := entities.NewPairBuilder().SetTokenAmounts(tokenAmount_weth_1000, tokenAmount_a_1000000).SetFee(30, 10000).Build()
+	ab, _ := entities.NewPairBuilder().SetTokenAmounts(tokenAmount_a_1000000, tokenAmount_b_1000000).SetFee(5, 10000).Build()
+	bWeth, _ := entities.NewPairBuilder().SetTokenAmounts(tokenAmount_b_900000, tokenAmount_weth_1000).SetFee(25, 10000).Build()
+
+	// classic cycle in closed form
+	{
+		arbitrages, err := FindArbitrages([]entities.Pair{wethA, ab, bWeth}, weth, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(arbitrages) != 1 {
+			t.Fatalf("expect[%+v], but got[%+v]", 1, len(arbitrages))
+		}
+		arbitrage := arbitrages[0]
+		// buy back WETH with B where WETH is cheap
+		expect := []entities.Pair{wethA, ab, bWeth}
+		for i := range expect {
+			if arbitrage.Route.Pairs[i] != expect[i] {
+				t.Errorf("expect[%+v], but got[%+v]", expect[i].GetAddress(), arbitrage.Route.Pairs[i].GetAddress())
+			}
+		}
+		if arbitrage.Profit.Raw().Sign() <= 0 {
+			t.Errorf("expect profit, but got[%+v]", arbitrage.Profit.Raw())
+		}
+		assertOptimal(t, arbitrage)
+
+		// numeric search agrees with the closed form
+		numeric := optimalInput(arbitrage.Route)
+		diff := new(big.Int).Sub(profit(arbitrage.Route, numeric), arbitrage.Profit.Raw())
+		if diff.CmpAbs(big.NewInt(1)) > 0 {
+			t.Errorf("expect[%+v], but got[%+v]", arbitrage.Profit.Raw(), profit(arbitrage.Route, numeric))
+		}
+	}
+
+	// cycles through stable pairs are searched numerically, and ranked by profit
+	{
+		stable, _ := entities.NewPairBuilder().SetTokenAmounts(tokenAmount_a_1000000, tokenAmount_b_1200000).SetFee(4, 10000).
+			SetTokenMultiplier(constants.Ten, constants.Ten).Build()
+		arbitrages, err := FindArbitrages([]entities.Pair{wethA, ab, bWeth, stable}, weth, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(arbitrages) < 2 {
+			t.Fatalf("expect at least [%+v], but got[%+v]", 2, len(arbitrages))
+		}
+		usesStable := false
+		for i, arbitrage := range arbitrages {
+			assertOptimal(t, arbitrage)
+			if i > 0 && arbitrage.Profit.GreaterThan(arbitrages[i-1].Profit.Fraction) {
+				t.Error("arbitrages should be sorted by profit")
+			}
+			for _, pair := range arbitrage.Route.Pairs {
+				usesStable = usesStable || pair == stable
+			}
+			if !arbitrage.Route.Input.Equals(weth) || !arbitrage.Route.Output.Equals(weth) {
+				t.Error("arbitrage should start and end in WETH")
+			}
+		}
+		if !usesStable {
+			t.Error("should find the arbitrage through the stable pair")
+		}
+	}
+
+	// consistent prices have no arbitrage
+	{
+		consistent, _ := entities.NewPairBuilder().SetTokenAmounts(tokenAmount_b_1000000, tokenAmount_weth_1000).SetFee(30, 10000).Build()
+		arbitrages, err := FindArbitrages([]entities.Pair{wethA, ab, consistent}, weth, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(arbitrages) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", 0, len(arbitrages))
+		}
+	}
+
+	if _, err := FindArbitrages([]entities.Pair{wethA}, weth, &Options{MaxHops: 1, MaxNumResults: 1}); err != ErrInvalidOptions {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidOptions, err)
+	}
+}
+
+// nolint funlen
+func TestFindCycles(t *testing.T) {
+	weth := entities.WETH[constants.Mainnet]
+	tokenA, _ := entities.NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "A", "")
+	tokenB, _ := entities.NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "B", "")
+	ether := func(amount int64) *big.Int {
+		return new(big.Int).Mul(big.NewInt(amount), constants.B1e18)
+	}
+	newPair := func(tokenA *entities.Token, amountA int64, tokenB *entities.Token, amountB int64, address string) entities.Pair {
+		tokenAmountA, _ := entities.NewTokenAmount(tokenA, ether(amountA))
+		tokenAmountB, _ := entities.NewTokenAmount(tokenB, ether(amountB))
+		pair, err := entities.NewPairBuilder().SetTokenAmounts(tokenAmountA, tokenAmountB).
+			SetPairAddress(common.HexToAddress(address)).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pair
+	}
+
+	// two pairs of one pool are not a cycle
+	{
+		wethA := newPair(weth, 1000, tokenA, 1000000, "0x0a")
+		stale := newPair(weth, 1000, tokenA, 900000, "0x0a")
+		if cycles := findCycles(entities.NewPairGraph([]entities.Pair{wethA, stale}), weth, 3); len(cycles) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", 0, len(cycles))
+		}
+	}
+
+	// a cycle goes through an intermediate token once
+	{
+		pairs := []entities.Pair{
+			newPair(weth, 1000, tokenA, 1000000, "0x0a"),
+			newPair(tokenA, 1000000, tokenB, 1000000, "0x0b"),
+			newPair(tokenA, 1000000, tokenB, 1100000, "0x0c"),
+			newPair(tokenA, 1000000, weth, 1000, "0x0d"),
+		}
+		cycles := findCycles(entities.NewPairGraph(pairs), weth, 4)
+		// WETH -> A -> WETH both ways
+		if len(cycles) != 2 {
+			t.Errorf("expect[%+v], but got[%+v]", 2, len(cycles))
+		}
+		for _, cycle := range cycles {
+			if len(cycle) != 2 {
+				t.Errorf("expect[%+v], but got[%+v]", 2, len(cycle))
+			}
+		}
+	}
+
+	// a pool met again on a route is traded in its state after the previous hop
+	{
+		wethA := newPair(weth, 1000, tokenA, 1000000, "0x0a")
+		route, err := entities.NewRoute([]entities.Pair{wethA, wethA}, weth, weth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		amountIn, _ := entities.NewTokenAmount(weth, ether(10))
+		amountA, next, err := wethA.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		amountOut, _, err := next.GetOutputAmount(amountA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := new(big.Int).Sub(amountOut.Raw(), amountIn.Raw())
+		if got := profit(route, amountIn.Raw()); got.Cmp(expect) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect, got)
+		}
+	}
+}
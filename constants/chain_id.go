@@ -5,9 +5,16 @@ type ChainID int
 
 //go:generate stringer -type=ChainID -linecomment
 const (
-	Mainnet ChainID = 1
-	Ropsten ChainID = 3
-	Rinkeby ChainID = 4
-	Goerli  ChainID = 5
-	Kovan   ChainID = 42
+	Mainnet   ChainID = 1
+	Ropsten   ChainID = 3
+	Rinkeby   ChainID = 4
+	Goerli    ChainID = 5
+	Optimism  ChainID = 10
+	Kovan     ChainID = 42
+	BNBChain  ChainID = 56
+	Polygon   ChainID = 137
+	Base      ChainID = 8453
+	Arbitrum  ChainID = 42161
+	Avalanche ChainID = 43114
+	Sepolia   ChainID = 11155111
 )
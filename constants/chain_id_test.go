@@ -14,6 +14,13 @@ func TestChainIDString(t *testing.T) {
 		{"Rinkeby", Rinkeby},
 		{"Goerli", Goerli},
 		{"Kovan", Kovan},
+		{"Optimism", Optimism},
+		{"BNBChain", BNBChain},
+		{"Polygon", Polygon},
+		{"Base", Base},
+		{"Arbitrum", Arbitrum},
+		{"Avalanche", Avalanche},
+		{"Sepolia", Sepolia},
 		{"ChainID(2)", ChainID(2)},
 	}
 	for i, test := range tests {
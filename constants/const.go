@@ -53,6 +53,17 @@ var (
 	B1999 = big.NewInt(1999)
 	B2000 = big.NewInt(2000)
 	B4000 = big.NewInt(4000)
+
+	// DefaultAmplification amplification coefficient A of stable pairs, Ann = A * 2 = 2000
+	DefaultAmplification = big.NewInt(1000)
+
+	// StableSwapFeeDenominator denominator of the fee and admin fee of StableSwap pools, as FEE_DENOMINATOR of Curve
+	StableSwapFeeDenominator = big.NewInt(1e10)
+	// StableSwapPrecision precision of the rates of StableSwap pools, a coin of 18 decimals has rate 1e18
+	StableSwapPrecision = big.NewInt(1e18)
+
+	// TaxDenominator denominator of the transfer taxes of fee on transfer tokens, i.e. taxes are in basis points
+	TaxDenominator = big.NewInt(10000)
 )
 
 type SolidityType string
@@ -73,3 +84,27 @@ var (
 	FactoryAddress = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
 	InitCodeHash   = common.FromHex("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f")
 )
+
+// uniswap v3
+const (
+	// MinTick the minimum tick that can be used on any pool
+	MinTick = -887272
+	// MaxTick the maximum tick that can be used on any pool
+	MaxTick = -MinTick
+)
+
+var (
+	V3FactoryAddress   = common.HexToAddress("0x1F98431c8aD98523631AE4a59f267346ea31F984")
+	V3PoolInitCodeHash = common.FromHex("0xe34f199b19b2b4f47f68442619d555527d244f78a3297ea89325f843f87b8b54")
+
+	Q32  = new(big.Int).Lsh(One, 32)
+	Q96  = new(big.Int).Lsh(One, 96)
+	Q128 = new(big.Int).Lsh(One, 128)
+	Q192 = new(big.Int).Lsh(One, 192)
+	// MinSqrtRatio the sqrt ratio corresponding to the minimum tick that could be used on any pool
+	MinSqrtRatio = big.NewInt(4295128739)
+	// MaxSqrtRatio the sqrt ratio corresponding to the maximum tick that could be used on any pool
+	MaxSqrtRatio, _ = new(big.Int).SetString("1461446703485210103287273052203988822378723970342", 10)
+	B1e6            = big.NewInt(1000000)
+	B1e18           = big.NewInt(1e18)
+)
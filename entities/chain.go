@@ -0,0 +1,223 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/utils"
+)
+
+var (
+	// ErrChainRegistered a chain of the same id or name is already registered
+	ErrChainRegistered = fmt.Errorf("chain registered")
+	// ErrInvalidChain the chain has no name or its native currency is not of the chain
+	ErrInvalidChain = fmt.Errorf("invalid chain")
+
+	_ChainRegistry = newChainRegistry(defaultChains())
+)
+
+// Chain describes a network: its native currency, wrapped by the token pairs hold, and the known Uniswap V2
+// compatible factories deployed on it.
+type Chain struct {
+	ID   constants.ChainID
+	Name string
+
+	Native *NativeCurrency
+	// the first one is the default factory of the chain
+	Factories []*Factory
+}
+
+// WrappedNative returns the wrapped token of the native currency, e.g. WETH
+func (c *Chain) WrappedNative() *Token {
+	return c.Native.Wrapped()
+}
+
+// Factory returns the known factory at address
+func (c *Chain) Factory(address common.Address) (*Factory, bool) {
+	for _, factory := range c.Factories {
+		if factory.Address == address {
+			return factory, true
+		}
+	}
+	return nil, false
+}
+
+// DefaultFactory returns the default factory of the chain, nil if no factory is known
+func (c *Chain) DefaultFactory() *Factory {
+	if len(c.Factories) == 0 {
+		return nil
+	}
+	return c.Factories[0]
+}
+
+// ChainRegistry warps the registered chains
+type ChainRegistry struct {
+	lk     *sync.RWMutex
+	byID   map[constants.ChainID]*Chain
+	byName map[string]*Chain
+}
+
+func newChainRegistry(chains []*Chain) *ChainRegistry {
+	r := &ChainRegistry{
+		lk:     new(sync.RWMutex),
+		byID:   make(map[constants.ChainID]*Chain, len(chains)),
+		byName: make(map[string]*Chain, 2*len(chains)),
+	}
+	for _, chain := range chains {
+		if err := r.Register(chain); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// chainName normalizes names, so "BNB Chain", "bnb-chain" and "BNBChain" are the same
+func chainName(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(name))
+}
+
+// Register registers chain, it can be looked up by its id, its name and the name of its id, e.g. "Mainnet"
+func (r *ChainRegistry) Register(chain *Chain) error {
+	if chain == nil || chain.Name == "" || chain.Native == nil || chain.Native.ChainID != chain.ID {
+		return ErrInvalidChain
+	}
+	names := []string{chainName(chain.Name), chainName(chain.ID.String())}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	if _, ok := r.byID[chain.ID]; ok {
+		return ErrChainRegistered
+	}
+	for _, name := range names {
+		if _, ok := r.byName[name]; ok {
+			return ErrChainRegistered
+		}
+	}
+	r.byID[chain.ID] = chain
+	for _, name := range names {
+		r.byName[name] = chain
+	}
+	return nil
+}
+
+// Lookup returns the chain of id
+func (r *ChainRegistry) Lookup(id constants.ChainID) (*Chain, bool) {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+	chain, ok := r.byID[id]
+	return chain, ok
+}
+
+// LookupByName returns the chain of name, case insensitive
+func (r *ChainRegistry) LookupByName(name string) (*Chain, bool) {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+	chain, ok := r.byName[chainName(name)]
+	return chain, ok
+}
+
+// Chains returns the registered chains in the order of their ids
+func (r *ChainRegistry) Chains() []*Chain {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+	chains := make([]*Chain, 0, len(r.byID))
+	for _, chain := range r.byID {
+		chains = append(chains, chain)
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].ID < chains[j].ID })
+	return chains
+}
+
+// RegisterChain registers a chain, e.g. a private network or a chain the SDK does not know yet
+func RegisterChain(chain *Chain) error {
+	return _ChainRegistry.Register(chain)
+}
+
+// LookupChain returns the registered chain of id
+func LookupChain(id constants.ChainID) (*Chain, bool) {
+	return _ChainRegistry.Lookup(id)
+}
+
+// LookupChainByName returns the registered chain of name, e.g. "arbitrum" or "BNB Chain"
+func LookupChainByName(name string) (*Chain, bool) {
+	return _ChainRegistry.LookupByName(name)
+}
+
+// RegisteredChains returns the registered chains in the order of their ids
+func RegisteredChains() []*Chain {
+	return _ChainRegistry.Chains()
+}
+
+/**** known chains *****/
+
+// uniswapV2Factory returns the Uniswap V2 factory deployed at address, or a fork deployed with the same bytecode
+func uniswapV2Factory(address string) *Factory {
+	return NewFactory(utils.ValidateAndParseAddress(address), constants.InitCodeHash,
+		constants.Univ2Symbol, constants.Univ2Name, 3, 1000)
+}
+
+// newChain returns a chain with native currency wrapped by the token at wrapped
+func newChain(id constants.ChainID, name string, symbol, currencyName string, wrapped *Token, factories ...*Factory) *Chain {
+	native, err := NewNativeCurrency(id, constants.Decimals18, symbol, currencyName, wrapped)
+	if err != nil {
+		panic(err)
+	}
+	return &Chain{
+		ID:        id,
+		Name:      name,
+		Native:    native,
+		Factories: factories,
+	}
+}
+
+// newETHChain returns a chain with ETH wrapped by WETH
+func newETHChain(id constants.ChainID, name string, factories ...*Factory) *Chain {
+	return &Chain{
+		ID:        id,
+		Name:      name,
+		Native:    &NativeCurrency{Currency: ETHER, ChainID: id, wrapped: WETH[id]},
+		Factories: factories,
+	}
+}
+
+func newWrappedToken(id constants.ChainID, address, symbol, name string) *Token {
+	token, err := NewToken(id, utils.ValidateAndParseAddress(address), constants.Decimals18, symbol, name)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+func defaultChains() []*Chain {
+	return []*Chain{
+		newETHChain(constants.Mainnet, "Ethereum", UniswapV2Factory),
+		newETHChain(constants.Ropsten, "Ropsten", UniswapV2Factory),
+		newETHChain(constants.Rinkeby, "Rinkeby", UniswapV2Factory),
+		newETHChain(constants.Goerli, "Goerli", UniswapV2Factory),
+		newETHChain(constants.Kovan, "Kovan", UniswapV2Factory),
+		newETHChain(constants.Sepolia, "Sepolia", uniswapV2Factory("0xF62c03E08ada871A0bEb309762E260a7a6a880E6")),
+		newETHChain(constants.Optimism, "OP Mainnet", uniswapV2Factory("0x0c3c1c532F1e39EdF36BE9Fe0bE1410313E074Bf")),
+		newETHChain(constants.Base, "Base", uniswapV2Factory("0x8909Dc15e40173Ff4699343b6eB8132c65e18eC6")),
+		newETHChain(constants.Arbitrum, "Arbitrum One", uniswapV2Factory("0xf1D7CC64Fb4452F05c498126312eBE29f30Fbcf9")),
+		newChain(constants.Polygon, "Polygon", "POL", "Polygon Ecosystem Token",
+			newWrappedToken(constants.Polygon, "0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270", "WPOL", "Wrapped Polygon Ecosystem Token"),
+			uniswapV2Factory("0x9e5A52f57b3038F1B8EeE45F28b3C1967e22799C"),
+			// QuickSwap, deployed from the Uniswap V2 bytecode
+			uniswapV2Factory("0x5757371414417b8C6CAad45bAeF941aBc7d3Ab32")),
+		newChain(constants.BNBChain, "BNB Chain", "BNB", "BNB",
+			newWrappedToken(constants.BNBChain, "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c", "WBNB", "Wrapped BNB"),
+			NewFactory(utils.ValidateAndParseAddress("0xcA143Ce32Fe78f1f7019d7d551a6402fC5350c73"),
+				common.FromHex("0x00fb7f630766e6a796048ea87d01acd3068e8ff67d078148a3fa3f4a84f69bd5"), "Cake-LP", "Pancake LPs", 25, 10000),
+			uniswapV2Factory("0x8909Dc15e40173Ff4699343b6eB8132c65e18eC6")),
+		newChain(constants.Avalanche, "Avalanche", "AVAX", "Avalanche",
+			newWrappedToken(constants.Avalanche, "0xB31f66AA3C1e785363F0875A1B74E27b85FD66c7", "WAVAX", "Wrapped AVAX"),
+			uniswapV2Factory("0x9e5A52f57b3038F1B8EeE45F28b3C1967e22799C"),
+			NewFactory(utils.ValidateAndParseAddress("0x9Ad6C38BE94206cA50bb0d90783181662f0Cfa10"),
+				common.FromHex("0x0bbca9af0511ad1a1da383135cf3a8d2ac620e549ef9f6ae3a4c33c2fed0af91"), "JLP", "Joe LP Token", 3, 1000)),
+	}
+}
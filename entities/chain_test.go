@@ -0,0 +1,115 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+// nolint funlen
+func TestChainRegistry(t *testing.T) {
+	for _, test := range []struct {
+		id      constants.ChainID
+		names   []string
+		native  string
+		wrapped string
+	}{
+		{constants.Mainnet, []string{"Ethereum", "mainnet"}, "ETH", "WETH"},
+		{constants.Sepolia, []string{"sepolia"}, "ETH", "WETH"},
+		{constants.Arbitrum, []string{"Arbitrum One", "arbitrum"}, "ETH", "WETH"},
+		{constants.Optimism, []string{"op-mainnet", "Optimism"}, "ETH", "WETH"},
+		{constants.Base, []string{"base"}, "ETH", "WETH"},
+		{constants.Polygon, []string{"POLYGON"}, "POL", "WPOL"},
+		{constants.BNBChain, []string{"BNB Chain", "bnb_chain", "BNBChain"}, "BNB", "WBNB"},
+		{constants.Avalanche, []string{"avalanche"}, "AVAX", "WAVAX"},
+	} {
+		chain, ok := LookupChain(test.id)
+		if !ok {
+			t.Fatalf("chain [%+v] should be registered", test.id)
+		}
+		for _, name := range test.names {
+			if byName, ok := LookupChainByName(name); !ok || byName != chain {
+				t.Errorf("expect[%+v], but got[%+v]", test.id, byName)
+			}
+		}
+		if chain.Native.Symbol != test.native || chain.WrappedNative().Symbol != test.wrapped ||
+			chain.WrappedNative().ChainID != test.id || !chain.Native.Token().IsNative() {
+			t.Errorf("expect[%+v %+v], but got[%+v %+v]", test.native, test.wrapped, chain.Native.Symbol, chain.WrappedNative().Symbol)
+		}
+		if native, _ := Native(test.id); native != chain.Native {
+			t.Errorf("expect[%+v], but got[%+v]", chain.Native, native)
+		}
+		if chain.DefaultFactory() == nil {
+			t.Errorf("chain [%+v] should have a factory", test.id)
+		}
+	}
+	if _, ok := LookupChainByName("unknown"); ok {
+		t.Error("unknown chain should not be registered")
+	}
+
+	// known factories derive the addresses of their pairs
+	for _, test := range []struct {
+		id      constants.ChainID
+		factory common.Address
+		tokenA  common.Address
+		tokenB  common.Address
+		pair    common.Address
+	}{
+		{
+			constants.Mainnet, common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f"),
+			common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"), common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+			common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"),
+		},
+		{
+			constants.Polygon, common.HexToAddress("0x5757371414417b8C6CAad45bAeF941aBc7d3Ab32"),
+			common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270"), common.HexToAddress("0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174"),
+			common.HexToAddress("0x6e7a5FAFcec6BB1e78bAE2A1F0B612012BF14827"),
+		},
+		{
+			constants.Avalanche, common.HexToAddress("0x9Ad6C38BE94206cA50bb0d90783181662f0Cfa10"),
+			common.HexToAddress("0xB31f66AA3C1e785363F0875A1B74E27b85FD66c7"), common.HexToAddress("0xA7D7079b0FEaD91F3e65f86E8915Cb59c1a4C664"),
+			common.HexToAddress("0xA389f9430876455C36478DeEa9769B7Ca4E3DDB1"),
+		},
+		{
+			constants.BNBChain, common.HexToAddress("0xcA143Ce32Fe78f1f7019d7d551a6402fC5350c73"),
+			common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c"), common.HexToAddress("0xe9e7CEA3DedcA5984780Bafc599bD69ADd087D56"),
+			common.HexToAddress("0x58F876857a02D6762E0101bb5C46A8c1ED44Dc16"),
+		},
+	} {
+		chain, _ := LookupChain(test.id)
+		factory, ok := chain.Factory(test.factory)
+		if !ok {
+			t.Fatalf("factory [%+v] should be known", test.factory.String())
+		}
+		tokenA, _ := NewToken(test.id, test.tokenA, 18, "", "")
+		tokenB, _ := NewToken(test.id, test.tokenB, 18, "", "")
+		pair, err := factory.GetPairAddress(tokenA, tokenB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pair != test.pair {
+			t.Errorf("expect[%+v], but got[%+v]", test.pair.String(), pair.String())
+		}
+	}
+
+	// user registered chains
+	{
+		id := constants.ChainID(31337)
+		wrapped, _ := NewToken(id, common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"), 18, "WETH", "Wrapped Ether")
+		native, _ := NewNativeCurrency(id, 18, "ETH", "Ether", wrapped)
+		if err := RegisterChain(&Chain{ID: id, Name: "Hardhat", Native: native}); err != nil {
+			t.Fatal(err)
+		}
+		if chain, ok := LookupChainByName("hardhat"); !ok || chain.WrappedNative() != wrapped || chain.DefaultFactory() != nil {
+			t.Errorf("expect[%+v], but got[%+v]", wrapped, chain)
+		}
+		if err := RegisterChain(&Chain{ID: id, Name: "Anvil", Native: native}); err != ErrChainRegistered {
+			t.Errorf("expect[%+v], but got[%+v]", ErrChainRegistered, err)
+		}
+		if err := RegisterChain(&Chain{ID: constants.ChainID(31338), Name: "Ethereum", Native: native}); err != ErrInvalidChain {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidChain, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package entities
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+// UniswapV2Factory the canonical Uniswap V2 factory, used when a pair is built without a factory
+var UniswapV2Factory = NewFactory(constants.FactoryAddress, constants.InitCodeHash,
+	constants.Univ2Symbol, constants.Univ2Name, 3, 1000)
+
+// Factory describes a Uniswap V2 compatible factory deployment (SushiSwap, PancakeSwap, QuickSwap...),
+// everything needed to derive pair addresses and liquidity tokens of the pairs it creates.
+type Factory struct {
+	Address      common.Address
+	InitCodeHash []byte
+
+	// liquidity token metadata of the created pairs
+	LiquiditySymbol string
+	LiquidityName   string
+
+	// default swap fee of the created pairs, fee/feeBase
+	Fee     uint64
+	FeeBase uint64
+}
+
+// NewFactory creates a Factory
+func NewFactory(address common.Address, initCodeHash []byte, liquiditySymbol, liquidityName string, fee, feeBase uint64) *Factory {
+	return &Factory{
+		Address:         address,
+		InitCodeHash:    initCodeHash,
+		LiquiditySymbol: liquiditySymbol,
+		LiquidityName:   liquidityName,
+		Fee:             fee,
+		FeeBase:         feeBase,
+	}
+}
+
+// GetPairAddress returns the address of the pair created by this factory for the two tokens
+func (f *Factory) GetPairAddress(tokenA, tokenB *Token) (common.Address, error) {
+	ok, err := tokenA.SortsBefore(tokenB)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if !ok {
+		tokenA, tokenB = tokenB, tokenA
+	}
+	return _PairAddressCache.GetFactoryAddress(f, tokenA.Address, tokenB.Address), nil
+}
+
+// NewLiquidityToken creates the liquidity token of a pair created by this factory
+func (f *Factory) NewLiquidityToken(chainID constants.ChainID, pairAddress common.Address) (*Token, error) {
+	return NewToken(chainID, pairAddress, constants.Decimals18, f.LiquiditySymbol, f.LiquidityName)
+}
+
+func factoryOrDefault(factory *Factory) *Factory {
+	if factory == nil {
+		return UniswapV2Factory
+	}
+	return factory
+}
@@ -0,0 +1,88 @@
+package entities
+
+import (
+	"math/big"
+)
+
+var (
+	// DefaultBaseSwapGas gas of a router swap call excluding hops
+	DefaultBaseSwapGas uint64 = 85000
+	// DefaultHopGas gas of a hop through a pair type not listed in DefaultPerHopGas
+	DefaultHopGas uint64 = 50000
+	// DefaultPerHopGas gas of a hop through each pair type
+	DefaultPerHopGas = map[PairType]uint64{
+		Classic: 50000,
+		Stable:  80000,
+		V3:      80000,
+		// Solidly stable pairs iterate to solve the curve
+		SolidlyStable: 90000,
+		// exchange of a Curve-style pool
+		StableSwap: 100000,
+		// swap through the Balancer vault
+		Weighted: 110000,
+	}
+)
+
+// GasModel estimates the gas cost of trades in terms of the output token
+type GasModel struct {
+	// gas of a single swap call, paid once by every route of a SmartTrade
+	BaseSwapGas uint64
+	// gas of a hop through a pair, by pair type
+	PerHopGas map[PairType]uint64
+	// gas of a hop through a pair type missing from PerHopGas
+	DefaultHopGas uint64
+	// gas price in wei
+	GasPrice *big.Int
+	// price of the native token in terms of the output token (input token for exact output routing),
+	// i.e. raw amount per wei
+	NativePrice *Price
+}
+
+// NewDefaultGasModel creates a GasModel with default gas usage, PerHopGas is a copy of DefaultPerHopGas
+func NewDefaultGasModel(gasPrice *big.Int, nativePrice *Price) *GasModel {
+	perHopGas := make(map[PairType]uint64, len(DefaultPerHopGas))
+	for pairType, gas := range DefaultPerHopGas {
+		perHopGas[pairType] = gas
+	}
+	return &GasModel{
+		BaseSwapGas:   DefaultBaseSwapGas,
+		PerHopGas:     perHopGas,
+		DefaultHopGas: DefaultHopGas,
+		GasPrice:      gasPrice,
+		NativePrice:   nativePrice,
+	}
+}
+
+// EstimateGas returns the gas used by executing every trade as a separate swap call
+func (g *GasModel) EstimateGas(trades ...*Trade) uint64 {
+	var gas uint64
+	for _, trade := range trades {
+		gas += g.BaseSwapGas
+		for _, pair := range trade.Route.Pairs {
+			hopGas, ok := g.PerHopGas[pair.PairType()]
+			if !ok {
+				hopGas = g.DefaultHopGas
+			}
+			gas += hopGas
+		}
+	}
+	return gas
+}
+
+// GasCost returns the cost of the gas in terms of token, which must be the quote currency of NativePrice
+func (g *GasModel) GasCost(gas uint64, token *Token) (*TokenAmount, error) {
+	if !g.NativePrice.QuoteCurrency.Equals(token.Currency) {
+		return nil, ErrInvalidCurrency
+	}
+
+	wei := new(big.Int).Mul(new(big.Int).SetUint64(gas), g.GasPrice)
+	return NewTokenAmount(token, g.NativePrice.Raw().Multiply(NewFraction(wei, nil)).Quotient())
+}
+
+// gasAdjustedOutput returns output minus gas cost, floored at zero
+func gasAdjustedOutput(outputAmount, gasCost *TokenAmount) (*TokenAmount, error) {
+	if gasCost.Raw().Cmp(outputAmount.Raw()) >= 0 {
+		return NewTokenAmount(outputAmount.Token, big.NewInt(0))
+	}
+	return outputAmount.Subtract(gasCost)
+}
@@ -0,0 +1,11 @@
+package entities
+
+import "math/big"
+
+func mustTokenAmount(token *Token, amount *big.Int) *TokenAmount {
+	tokenAmount, err := NewTokenAmount(token, amount)
+	if err != nil {
+		panic(err)
+	}
+	return tokenAmount
+}
@@ -0,0 +1,363 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+var (
+	// ErrInvalidJSON the JSON does not match the schema of the entity
+	ErrInvalidJSON = fmt.Errorf("invalid json")
+)
+
+// decimalInt is a big.Int in JSON as a decimal string, so amounts keep their precision in any JSON decoder
+type decimalInt big.Int
+
+func newDecimalInt(i *big.Int) *decimalInt {
+	if i == nil {
+		return nil
+	}
+	return (*decimalInt)(i)
+}
+
+func (d *decimalInt) Int() *big.Int {
+	return (*big.Int)(d)
+}
+
+func (d *decimalInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Int().String())
+}
+
+func (d *decimalInt) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if _, ok := d.Int().SetString(s, 10); !ok {
+		return ErrInvalidJSON
+	}
+	return nil
+}
+
+// checksumAddress is an address in JSON as an EIP-55 checksummed hex string
+type checksumAddress common.Address
+
+func (a checksumAddress) MarshalJSON() ([]byte, error) {
+	return json.Marshal(common.Address(a).Hex())
+}
+
+func (a *checksumAddress) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if !common.IsHexAddress(s) {
+		return ErrInvalidJSON
+	}
+	*a = checksumAddress(common.HexToAddress(s))
+	return nil
+}
+
+// fractionJSON is a price or percent in JSON, the raw fraction and its value for display
+type fractionJSON struct {
+	Numerator   *decimalInt `json:"numerator"`
+	Denominator *decimalInt `json:"denominator"`
+	Value       string      `json:"value"`
+}
+
+func newPriceJSON(price *Price) *fractionJSON {
+	if price == nil {
+		return nil
+	}
+	return &fractionJSON{
+		Numerator:   newDecimalInt(price.Numerator),
+		Denominator: newDecimalInt(price.Denominator),
+		Value:       price.ToSignificant(18),
+	}
+}
+
+func newPercentJSON(percent *Percent) *fractionJSON {
+	if percent == nil {
+		return nil
+	}
+	return &fractionJSON{
+		Numerator:   newDecimalInt(percent.Numerator),
+		Denominator: newDecimalInt(percent.Denominator),
+		Value:       percent.ToFixed(4),
+	}
+}
+
+/**** token *****/
+
+type tokenJSON struct {
+	ChainID  constants.ChainID `json:"chainId"`
+	Address  checksumAddress   `json:"address"`
+	Decimals int               `json:"decimals"`
+	Symbol   string            `json:"symbol,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	// the token stands for the native currency wrapped by the token at address
+	Native bool         `json:"native,omitempty"`
+	Tax    *TransferTax `json:"tax,omitempty"`
+}
+
+// MarshalJSON returns the JSON of the token
+func (t *Token) MarshalJSON() ([]byte, error) {
+	data := tokenJSON{
+		ChainID:  t.ChainID,
+		Address:  checksumAddress(t.Address),
+		Decimals: t.Decimals,
+		Symbol:   t.Symbol,
+		Name:     t.Name,
+		Native:   t.IsNative(),
+	}
+	if t.HasTax() {
+		data.Tax = t.Tax
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON sets the token to the token of the JSON, native tokens are resolved through the chain registry
+func (t *Token) UnmarshalJSON(data []byte) error {
+	var tokenJSON tokenJSON
+	if err := json.Unmarshal(data, &tokenJSON); err != nil {
+		return err
+	}
+	address := common.Address(tokenJSON.Address)
+
+	if tokenJSON.Native {
+		if chain, ok := LookupChain(tokenJSON.ChainID); ok && chain.WrappedNative().Address == address {
+			*t = *chain.Native.Token()
+			return nil
+		}
+		wrapped, err := NewToken(tokenJSON.ChainID, address, tokenJSON.Decimals, "", "")
+		if err != nil {
+			return err
+		}
+		native, err := NewNativeCurrency(tokenJSON.ChainID, tokenJSON.Decimals, tokenJSON.Symbol, tokenJSON.Name, wrapped)
+		if err != nil {
+			return err
+		}
+		*t = *native.Token()
+		return nil
+	}
+
+	token, err := NewToken(tokenJSON.ChainID, address, tokenJSON.Decimals, tokenJSON.Symbol, tokenJSON.Name)
+	if err != nil {
+		return err
+	}
+	if tax := tokenJSON.Tax; tax != nil {
+		if token, err = token.WithTax(tax.Buy, tax.Sell, tax.Transfer); err != nil {
+			return err
+		}
+	}
+	*t = *token
+	return nil
+}
+
+/**** token amount *****/
+
+type tokenAmountJSON struct {
+	Token  *Token      `json:"token"`
+	Amount *decimalInt `json:"amount"`
+}
+
+// MarshalJSON returns the JSON of the amount, the raw amount as a decimal string
+func (t *TokenAmount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tokenAmountJSON{Token: t.Token, Amount: newDecimalInt(t.Raw())})
+}
+
+// UnmarshalJSON sets the amount to the amount of the JSON
+func (t *TokenAmount) UnmarshalJSON(data []byte) error {
+	var amountJSON tokenAmountJSON
+	if err := json.Unmarshal(data, &amountJSON); err != nil {
+		return err
+	}
+	if amountJSON.Token == nil || amountJSON.Amount == nil {
+		return ErrInvalidJSON
+	}
+	tokenAmount, err := NewTokenAmount(amountJSON.Token, amountJSON.Amount.Int())
+	if err != nil {
+		return err
+	}
+	*t = *tokenAmount
+	return nil
+}
+
+/**** route *****/
+
+type routeJSON struct {
+	Input    *Token            `json:"input"`
+	Output   *Token            `json:"output"`
+	Path     []*Token          `json:"path"`
+	Pairs    []json.RawMessage `json:"pairs"`
+	MidPrice *fractionJSON     `json:"midPrice,omitempty"`
+}
+
+// MarshalJSON returns the JSON of the route, pairs are in the JSON of MarshalPair
+func (r *Route) MarshalJSON() ([]byte, error) {
+	data := routeJSON{
+		Input:    r.Input,
+		Output:   r.Output,
+		Path:     r.Path,
+		Pairs:    make([]json.RawMessage, len(r.Pairs)),
+		MidPrice: newPriceJSON(r.MidPrice),
+	}
+	for i, pair := range r.Pairs {
+		pairJSON, err := MarshalPair(pair)
+		if err != nil {
+			return nil, err
+		}
+		data.Pairs[i] = pairJSON
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON sets the route to the route of the pairs and tokens of the JSON, the path and mid price are
+// derived again
+func (r *Route) UnmarshalJSON(data []byte) error {
+	var routeJSON routeJSON
+	if err := json.Unmarshal(data, &routeJSON); err != nil {
+		return err
+	}
+	if routeJSON.Input == nil {
+		return ErrInvalidJSON
+	}
+	pairs := make([]Pair, len(routeJSON.Pairs))
+	for i, pairJSON := range routeJSON.Pairs {
+		pair, err := UnmarshalPair(pairJSON)
+		if err != nil {
+			return err
+		}
+		pairs[i] = pair
+	}
+	route, err := NewRoute(pairs, routeJSON.Input, routeJSON.Output)
+	if err != nil {
+		return err
+	}
+	*r = *route
+	return nil
+}
+
+/**** trade *****/
+
+var tradeTypeNames = map[constants.TradeType]string{
+	constants.ExactInput:  "EXACT_INPUT",
+	constants.ExactOutput: "EXACT_OUTPUT",
+}
+
+type tradeJSON struct {
+	TradeType      string        `json:"tradeType"`
+	Route          *Route        `json:"route"`
+	InputAmount    *TokenAmount  `json:"inputAmount"`
+	OutputAmount   *TokenAmount  `json:"outputAmount"`
+	ExecutionPrice *fractionJSON `json:"executionPrice,omitempty"`
+	NextMidPrice   *fractionJSON `json:"nextMidPrice,omitempty"`
+	PriceImpact    *fractionJSON `json:"priceImpact,omitempty"`
+}
+
+// MarshalJSON returns the JSON of the trade
+func (t *Trade) MarshalJSON() ([]byte, error) {
+	tradeType, ok := tradeTypeNames[t.TradeType]
+	if !ok {
+		return nil, ErrInvalidJSON
+	}
+	return json.Marshal(tradeJSON{
+		TradeType:      tradeType,
+		Route:          t.Route,
+		InputAmount:    t.inputAmount,
+		OutputAmount:   t.outputAmount,
+		ExecutionPrice: newPriceJSON(t.ExecutionPrice),
+		NextMidPrice:   newPriceJSON(t.NextMidPrice),
+		PriceImpact:    newPercentJSON(t.PriceImpact),
+	})
+}
+
+// UnmarshalJSON sets the trade to the trade of the route and the exact amount of the JSON, the other amount and
+// the prices are computed again
+func (t *Trade) UnmarshalJSON(data []byte) error {
+	var tradeJSON tradeJSON
+	if err := json.Unmarshal(data, &tradeJSON); err != nil {
+		return err
+	}
+	if tradeJSON.Route == nil {
+		return ErrInvalidJSON
+	}
+	for tradeType, name := range tradeTypeNames {
+		if name != tradeJSON.TradeType {
+			continue
+		}
+		amount := tradeJSON.InputAmount
+		if tradeType == constants.ExactOutput {
+			amount = tradeJSON.OutputAmount
+		}
+		if amount == nil {
+			return ErrInvalidJSON
+		}
+		trade, err := NewTrade(tradeJSON.Route, amount, tradeType)
+		if err != nil {
+			return err
+		}
+		*t = *trade
+		return nil
+	}
+	return ErrInvalidJSON
+}
+
+/**** smart trade *****/
+
+type smartTradeJSON struct {
+	Percents                []int        `json:"percents"`
+	Trades                  []*Trade     `json:"trades"`
+	InputAmount             *TokenAmount `json:"inputAmount"`
+	OutputAmount            *TokenAmount `json:"outputAmount"`
+	EstimatedGas            uint64       `json:"estimatedGas,omitempty"`
+	GasCost                 *TokenAmount `json:"gasCost,omitempty"`
+	GasAdjustedOutputAmount *TokenAmount `json:"gasAdjustedOutputAmount,omitempty"`
+	GasAdjustedInputAmount  *TokenAmount `json:"gasAdjustedInputAmount,omitempty"`
+}
+
+// MarshalJSON returns the JSON of the smart trade
+func (t *SmartTrade) MarshalJSON() ([]byte, error) {
+	return json.Marshal(smartTradeJSON{
+		Percents:                t.Percents,
+		Trades:                  t.Trades,
+		InputAmount:             t.inputAmount,
+		OutputAmount:            t.outputAmount,
+		EstimatedGas:            t.EstimatedGas,
+		GasCost:                 t.gasCost,
+		GasAdjustedOutputAmount: t.gasAdjustedOutputAmount,
+		GasAdjustedInputAmount:  t.gasAdjustedInputAmount,
+	})
+}
+
+// UnmarshalJSON sets the smart trade to the trades of the JSON, the amounts are summed again and the gas estimate
+// is kept as routed
+func (t *SmartTrade) UnmarshalJSON(data []byte) error {
+	var tradeJSON smartTradeJSON
+	if err := json.Unmarshal(data, &tradeJSON); err != nil {
+		return err
+	}
+	if len(tradeJSON.Trades) == 0 || len(tradeJSON.Percents) != len(tradeJSON.Trades) {
+		return ErrInvalidJSON
+	}
+	for _, trade := range tradeJSON.Trades {
+		if trade == nil {
+			return ErrInvalidJSON
+		}
+	}
+	smartTrade, err := newSmartTrade(tradeJSON.Percents, tradeJSON.Trades, tradeJSON.Trades[0].TradeType, nil)
+	if err != nil {
+		return err
+	}
+	smartTrade.EstimatedGas = tradeJSON.EstimatedGas
+	smartTrade.gasCost = tradeJSON.GasCost
+	smartTrade.gasAdjustedOutputAmount = tradeJSON.GasAdjustedOutputAmount
+	smartTrade.gasAdjustedInputAmount = tradeJSON.GasAdjustedInputAmount
+	*t = *smartTrade
+	return nil
+}
@@ -0,0 +1,200 @@
+package entities
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+// nolint funlen
+func TestTokenJSON(t *testing.T) {
+	usdc, _ := NewToken(constants.Mainnet, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "USD Coin")
+	taxed, _ := usdc.WithTax(100, 200, 0)
+
+	data, err := json.Marshal(taxed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"chainId":1,"address":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48","decimals":6,"symbol":"USDC",` +
+		`"name":"USD Coin","tax":{"buy":100,"sell":200,"transfer":0}}`
+	if string(data) != expect {
+		t.Errorf("expect[%+v], but got[%+v]", expect, string(data))
+	}
+	var token Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		t.Fatal(err)
+	}
+	if !token.Equals(taxed) || token.Name != taxed.Name || *token.Tax != *taxed.Tax || token.IsNative() {
+		t.Errorf("expect[%+v], but got[%+v]", taxed, token)
+	}
+
+	// lower case addresses are accepted, invalid ones are not
+	if err := json.Unmarshal([]byte(`{"chainId":1,"address":"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48","decimals":6}`), &token); err != nil ||
+		token.Address != usdc.Address || token.HasTax() {
+		t.Errorf("expect[%+v], but got[%+v %+v]", usdc, token, err)
+	}
+	if err := json.Unmarshal([]byte(`{"chainId":1,"address":"0x01","decimals":6}`), &token); err != ErrInvalidJSON {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidJSON, err)
+	}
+
+	// native tokens
+	ether, _ := Native(constants.Mainnet)
+	if data, err = json.Marshal(ether.Token()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"native":true`) {
+		t.Errorf("expect native, but got[%+v]", string(data))
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		t.Fatal(err)
+	}
+	if !token.IsNative() || token.Wrapped() != ether.Wrapped() || token.Symbol != ether.Symbol {
+		t.Errorf("expect[%+v], but got[%+v]", ether.Token(), token)
+	}
+	custom := `{"chainId":1,"address":"0x0000000000000000000000000000000000000001","decimals":18,"symbol":"XYZ","native":true}`
+	if err := json.Unmarshal([]byte(custom), &token); err != nil {
+		t.Fatal(err)
+	}
+	if !token.IsNative() || token.Symbol != "XYZ" || token.Wrapped().Address != common.HexToAddress("0x01") {
+		t.Errorf("expect native XYZ, but got[%+v]", token)
+	}
+}
+
+// nolint funlen
+func TestPairJSON(t *testing.T) {
+	usdc, _ := NewToken(constants.Mainnet, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "USD Coin")
+	dai, _ := NewToken(constants.Mainnet, common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"), 18, "DAI", "DAI Stablecoin")
+	reserveUSDC, _ := NewTokenAmount(usdc, mustUnits(1000000, 6))
+	reserveDAI, _ := NewTokenAmount(dai, mustUnits(1000000, 18))
+
+	classic, _ := NewPair(reserveUSDC, reserveDAI)
+	weighted, err := NewWeightedPair(common.HexToAddress("0x5c6Ee304399DBdB9C8Ef030aB642B10820DB8F56"),
+		reserveUSDC, reserveDAI, big.NewInt(8e17), big.NewInt(2e17), big.NewInt(3e15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	liquidity := mustUnits(1, 18)
+	balanceUSDC, _ := NewTokenAmount(usdc, liquidity)
+	balanceDAI, _ := NewTokenAmount(dai, liquidity)
+	v3, err := NewV3Pool(balanceUSDC, balanceDAI, FeeLow, constants.Q96, liquidity, 0, []Tick{
+		{Index: -887270, LiquidityNet: liquidity, LiquidityGross: liquidity},
+		{Index: 887270, LiquidityNet: new(big.Int).Neg(liquidity), LiquidityGross: liquidity},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, tokens := newThreePool(t, 120000000, 100000000, 80000000)
+	stableSwap, _ := pool.Pair(tokens[2], tokens[0])
+
+	for _, pair := range []Pair{classic, weighted, v3, stableSwap} {
+		data, err := MarshalPair(pair)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := UnmarshalPair(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.PairType() != pair.PairType() || got.GetAddress() != pair.GetAddress() ||
+			got.Reserve0().Raw().Cmp(pair.Reserve0().Raw()) != 0 || got.Reserve1().Raw().Cmp(pair.Reserve1().Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", pair, got)
+		}
+		amountIn, _ := NewTokenAmount(pair.Token0(), mustUnits(1, pair.Token0().Decimals-3))
+		expect, _, err := pair.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, _, err := got.GetOutputAmount(amountIn)
+		if err != nil || output.Raw().Cmp(expect.Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), output)
+		}
+	}
+
+	// amounts are decimal strings, the type is tagged
+	data, _ := MarshalPair(classic)
+	for _, expect := range []string{`"type":"classic"`, `"amount":"1000000000000"`, `"address":"0x6B175474E89094C44Da98b954EedeAC495271d0F"`} {
+		if !strings.Contains(string(data), expect) {
+			t.Errorf("expect[%+v] in [%+v]", expect, string(data))
+		}
+	}
+	if _, err := UnmarshalPair([]byte(`{"type":"classic","pair":{"tokenAmounts":[]}}`)); err != ErrInvalidJSON {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidJSON, err)
+	}
+	if _, err := NewPairBuilder().SetTokenAmounts(reserveUSDC, reserveDAI).SetPairType(Weighted).Build(); err != ErrBuildWeightedPair {
+		t.Errorf("expect[%+v], but got[%+v]", ErrBuildWeightedPair, err)
+	}
+}
+
+// nolint funlen
+func TestTradeJSON(t *testing.T) {
+	token0, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "t0", "")
+	token1, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "t1", "")
+	token3, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000004"), 18, "t3", "")
+	pair_0_3 := mustPair(token0, mustEther(1000000), token3, mustEther(1000000))
+	pair_0_1 := mustPair(token0, mustEther(1000000), token1, mustEther(1000000))
+	pair_1_3 := mustPair(token1, mustEther(1000000), token3, mustEther(1000000))
+
+	route, err := NewRoute([]Pair{pair_0_1, pair_1_3}, token0, token3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tradeType := range []constants.TradeType{constants.ExactInput, constants.ExactOutput} {
+		amount, _ := NewTokenAmount(token0, mustEther(100))
+		if tradeType == constants.ExactOutput {
+			amount, _ = NewTokenAmount(token3, mustEther(100))
+		}
+		trade, err := NewTrade(route, amount, tradeType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(trade)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Trade
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.TradeType != tradeType || len(got.Route.Pairs) != 2 || len(got.Route.Path) != 3 || !got.Route.Output.Equals(token3) ||
+			got.InputAmount().Raw().Cmp(trade.InputAmount().Raw()) != 0 || got.OutputAmount().Raw().Cmp(trade.OutputAmount().Raw()) != 0 ||
+			got.ExecutionPrice.ToSignificant(18) != trade.ExecutionPrice.ToSignificant(18) {
+			t.Errorf("expect[%+v], but got[%+v]", trade, got)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"tradeType":"EXACT_SOMETHING","route":{"input":null}}`), &Trade{}); err != ErrInvalidJSON {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidJSON, err)
+	}
+
+	// smart trades keep the gas estimate as routed
+	amountIn, _ := NewTokenAmount(token0, mustEther(100000))
+	smartTrades, err := BestSmartTradeExactIn([]Pair{pair_0_3, pair_0_1, pair_1_3}, amountIn, token3, &BestSmartTradeOptions{
+		BestTradeOptions:        *NewDefaultBestTradeOptions(),
+		MaxSplit:                2,
+		MaxSmartTradeNumResults: 1,
+	})
+	if err != nil || len(smartTrades) == 0 {
+		t.Fatalf("should find smart trades, but got[%+v]", err)
+	}
+	smartTrade := smartTrades[0]
+	smartTrade.EstimatedGas = 250000
+	data, err := json.Marshal(smartTrade)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got SmartTrade
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Trades) != len(smartTrade.Trades) || got.EstimatedGas != 250000 ||
+		got.InputAmount().Raw().Cmp(smartTrade.InputAmount().Raw()) != 0 || got.OutputAmount().Raw().Cmp(smartTrade.OutputAmount().Raw()) != 0 {
+		t.Errorf("expect[%+v], but got[%+v]", smartTrade, got)
+	}
+	if err := json.Unmarshal([]byte(`{"percents":[100],"trades":[]}`), &got); err != ErrInvalidJSON {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidJSON, err)
+	}
+}
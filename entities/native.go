@@ -0,0 +1,96 @@
+package entities
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+var (
+	// ErrNoWrappedNative the chain has no known wrapped token of its native currency
+	ErrNoWrappedNative = fmt.Errorf("no wrapped native token")
+)
+
+// NativeCurrency is the native currency of a chain, e.g. ETH, BNB and MATIC. Pairs hold its wrapped token, e.g. WETH,
+// so trades of the native currency are routed through the pairs of the wrapped token.
+type NativeCurrency struct {
+	*Currency
+
+	ChainID constants.ChainID
+	wrapped *Token
+}
+
+/**
+ * NewNativeCurrency creates the native currency of a chain
+ * @param wrapped the wrapped token of the native currency, e.g. WETH
+ */
+func NewNativeCurrency(chainID constants.ChainID, decimals int, symbol, name string, wrapped *Token) (*NativeCurrency, error) {
+	if wrapped == nil || wrapped.ChainID != chainID {
+		return nil, ErrNoWrappedNative
+	}
+	currency, err := newCurrency(decimals, symbol, name)
+	if err != nil {
+		return nil, err
+	}
+	return &NativeCurrency{
+		Currency: currency,
+		ChainID:  chainID,
+		wrapped:  wrapped,
+	}, nil
+}
+
+// Native returns the native currency of the registered chain, e.g. ETH of Mainnet and BNB of BNB Chain
+func Native(chainID constants.ChainID) (*NativeCurrency, error) {
+	chain, ok := LookupChain(chainID)
+	if !ok {
+		return nil, ErrNoWrappedNative
+	}
+	return chain.Native, nil
+}
+
+// Wrapped returns the wrapped token of the native currency
+func (n *NativeCurrency) Wrapped() *Token {
+	return n.wrapped
+}
+
+// Token returns the token standing for the native currency in routes and trades, it has the address of the wrapped
+// token so it is equal to the wrapped token in pairs, and the currency of the native currency
+func (n *NativeCurrency) Token() *Token {
+	return &Token{
+		Currency: n.Currency,
+		ChainID:  n.ChainID,
+		Address:  n.wrapped.Address,
+		native:   n,
+	}
+}
+
+// NewAmount returns the amount of the native currency, e.g. wei of ETH
+func (n *NativeCurrency) NewAmount(amount *big.Int) (*TokenAmount, error) {
+	return NewTokenAmount(n.Token(), amount)
+}
+
+// IsNative returns true if the token stands for the native currency of its chain rather than an ERC20
+func (t *Token) IsNative() bool {
+	return t.native != nil
+}
+
+// Wrapped returns the wrapped token of the native currency if the token stands for it, or the token itself
+func (t *Token) Wrapped() *Token {
+	if t.native != nil {
+		return t.native.wrapped
+	}
+	if weth, ok := WETH[t.ChainID]; ok && t.Currency.Equals(ETHER) && weth.Address == t.Address {
+		return weth
+	}
+	return t
+}
+
+// nativeAmount returns amount in token if token stands for the native currency, so trades report the native
+// currency instead of the wrapped token of the pairs
+func nativeAmount(token *Token, amount *TokenAmount) (*TokenAmount, error) {
+	if !token.IsNative() || amount.Token.IsNative() {
+		return amount, nil
+	}
+	return NewTokenAmount(token, amount.Raw())
+}
@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+// nolint funlen
+func TestNativeCurrency(t *testing.T) {
+	ether, err := Native(constants.Mainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weth := WETH[constants.Mainnet]
+	eth := ether.Token()
+	if !eth.IsNative() || weth.IsNative() || !eth.Equals(weth) || eth.Wrapped() != weth || weth.Wrapped() != weth {
+		t.Fatal("native token should stand for ETH wrapped by WETH")
+	}
+	if eth.Symbol != "ETH" || ether.Wrapped().Symbol != "WETH" {
+		t.Errorf("expect[%+v], but got[%+v]", "ETH", eth.Symbol)
+	}
+	if _, err := Native(constants.ChainID(999999)); err != ErrNoWrappedNative {
+		t.Errorf("expect[%+v], but got[%+v]", ErrNoWrappedNative, err)
+	}
+
+	usdc, _ := NewToken(constants.Mainnet, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "")
+	reserveWETH, _ := NewTokenAmount(weth, mustUnits(1000, 18))
+	reserveUSDC, _ := NewTokenAmount(usdc, mustUnits(2000000, 6))
+	pair, _ := NewPair(reserveWETH, reserveUSDC)
+
+	// ETH in, the pairs keep WETH
+	{
+		route, err := NewRoute([]Pair{pair}, eth, usdc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		amountIn, _ := ether.NewAmount(mustUnits(1, 18))
+		trade, err := ExactIn(route, amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !trade.InputAmount().Token.IsNative() || trade.ExecutionPrice.BaseCurrency != ETHER {
+			t.Errorf("expect[%+v], but got[%+v]", ETHER, trade.InputAmount().Token.Currency)
+		}
+		reserve, _ := trade.nextPairs[0].ReserveOf(weth)
+		if reserve.Token.IsNative() {
+			t.Error("pair should keep WETH")
+		}
+		expect, _, _ := pair.GetOutputAmount(mustTokenAmount(weth, mustUnits(1, 18)))
+		if trade.OutputAmount().Raw().Cmp(expect.Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), trade.OutputAmount().Raw())
+		}
+	}
+
+	// ETH out, reported in ETH for exact input and output
+	{
+		route, err := NewRoute([]Pair{pair}, usdc, eth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		amountIn, _ := NewTokenAmount(usdc, mustUnits(2000, 6))
+		trade, err := ExactIn(route, amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !trade.OutputAmount().Token.IsNative() {
+			t.Errorf("expect[%+v], but got[%+v]", eth.Currency, trade.OutputAmount().Token.Currency)
+		}
+		amountOut, _ := ether.NewAmount(mustUnits(1, 18))
+		trade, err = ExactOut(route, amountOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trade.InputAmount().Token != usdc || !trade.OutputAmount().Token.IsNative() {
+			t.Errorf("expect[%+v], but got[%+v]", eth.Currency, trade.OutputAmount().Token.Currency)
+		}
+	}
+
+	// the best trade search takes and returns the native currency
+	{
+		amountIn, _ := ether.NewAmount(mustUnits(1, 18))
+		trades, err := BestTradeExactIn([]Pair{pair}, amountIn, usdc, NewDefaultBestTradeOptions(), nil, amountIn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) != 1 || !trades[0].InputAmount().Token.IsNative() {
+			t.Errorf("expect a trade of ETH, but got[%+v]", trades)
+		}
+	}
+
+	// native currencies of other chains
+	{
+		wbnb, _ := NewToken(constants.BNBChain, common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c"), 18, "WBNB", "Wrapped BNB")
+		bnb, err := NewNativeCurrency(constants.BNBChain, 18, "BNB", "BNB", wbnb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bnb.Token().IsNative() || bnb.Token().Wrapped() != wbnb || bnb.Token().Currency.Equals(ETHER) {
+			t.Error("native token should stand for BNB wrapped by WBNB")
+		}
+		if _, err := NewNativeCurrency(constants.Mainnet, 18, "BNB", "BNB", wbnb); err != ErrNoWrappedNative {
+			t.Errorf("expect[%+v], but got[%+v]", ErrNoWrappedNative, err)
+		}
+	}
+
+	// an ERC20 looking like ETH is not native
+	{
+		fake, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000e7e"), 18, "ETH", "Ether")
+		if fake.IsNative() || fake.Wrapped() != fake {
+			t.Errorf("expect[%+v], but got[%+v]", false, fake.IsNative())
+		}
+	}
+
+	if amount, _ := ether.NewAmount(big.NewInt(1)); amount.Token.Address != weth.Address {
+		t.Errorf("expect[%+v], but got[%+v]", weth.Address, amount.Token.Address)
+	}
+}
@@ -16,16 +16,21 @@ type PairType string
 var (
 	_PairAddressCache = &PairAddressCache{
 		lk:      new(sync.RWMutex),
-		address: make(map[common.Address]map[common.Address]common.Address, 16),
+		address: make(map[factoryKey]map[common.Address]map[common.Address]common.Address, 4),
 	}
 
 	// ErrInvalidLiquidity invalid liquidity
 	ErrInvalidLiquidity = fmt.Errorf("invalid liquidity")
 	// ErrInvalidKLast invalid kLast
 	ErrInvalidKLast = fmt.Errorf("invalid kLast")
+	// ErrNotImplemented the pair type does not support the operation
+	ErrNotImplemented = fmt.Errorf("not implemented")
+	// ErrInvalidAmplification amplification coefficient must be positive
+	ErrInvalidAmplification = fmt.Errorf("invalid amplification")
 
 	Classic PairType = "classic"
 	Stable  PairType = "stable"
+	V3      PairType = "v3"
 )
 
 // TokenAmounts warps TokenAmount array
@@ -46,47 +51,58 @@ func NewTokenAmounts(tokenAmountA, tokenAmountB *TokenAmount) (TokenAmounts, err
 	return TokenAmounts{tokenAmountB, tokenAmountA}, nil
 }
 
+// factoryKey identifies the pairs a factory creates, factories at the same address of other chains may deploy other
+// pair contracts
+type factoryKey struct {
+	address      common.Address
+	initCodeHash common.Hash
+}
+
 // PairAddressCache warps pair address cache
 type PairAddressCache struct {
 	lk *sync.RWMutex
-	// token0 address : token1 address : pair address
-	address map[common.Address]map[common.Address]common.Address
+	// factory : token0 address : token1 address : pair address
+	address map[factoryKey]map[common.Address]map[common.Address]common.Address
 }
 
-// GetAddress returns contract address
+// GetAddress returns contract address of the pair created by the Uniswap V2 factory
 // addressA < addressB
 func (p *PairAddressCache) GetAddress(addressA, addressB common.Address) common.Address {
+	return p.GetFactoryAddress(UniswapV2Factory, addressA, addressB)
+}
+
+// GetFactoryAddress returns contract address of the pair created by the factory
+// addressA < addressB
+func (p *PairAddressCache) GetFactoryAddress(factory *Factory, addressA, addressB common.Address) common.Address {
+	key := factoryKey{address: factory.Address, initCodeHash: common.BytesToHash(factory.InitCodeHash)}
 	p.lk.RLock()
-	pairAddresses, ok := p.address[addressA]
-	if !ok {
-		p.lk.RUnlock()
-		p.lk.Lock()
-		defer p.lk.Unlock()
-		addr := getCreate2Address(addressA, addressB)
-		p.address[addressA] = map[common.Address]common.Address{
-			addressB: addr,
-		}
-		return addr
+	pairAddress, ok := p.address[key][addressA][addressB]
+	p.lk.RUnlock()
+	if ok {
+		return pairAddress
 	}
 
-	pairAddress, ok := pairAddresses[addressB]
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	factoryAddresses, ok := p.address[key]
 	if !ok {
-		p.lk.RUnlock()
-		p.lk.Lock()
-		defer p.lk.Unlock()
-		addr := getCreate2Address(addressA, addressB)
-		pairAddresses[addressB] = addr
-		return addr
+		factoryAddresses = make(map[common.Address]map[common.Address]common.Address, 16)
+		p.address[key] = factoryAddresses
 	}
-
-	p.lk.RUnlock()
-	return pairAddress
+	pairAddresses, ok := factoryAddresses[addressA]
+	if !ok {
+		pairAddresses = make(map[common.Address]common.Address, 1)
+		factoryAddresses[addressA] = pairAddresses
+	}
+	addr := getCreate2Address(factory, addressA, addressB)
+	pairAddresses[addressB] = addr
+	return addr
 }
 
-func getCreate2Address(addressA, addressB common.Address) common.Address {
+func getCreate2Address(factory *Factory, addressA, addressB common.Address) common.Address {
 	var salt [32]byte
 	copy(salt[:], crypto.Keccak256(append(addressA.Bytes(), addressB.Bytes()...)))
-	return crypto.CreateAddress2(constants.FactoryAddress, salt, constants.InitCodeHash)
+	return crypto.CreateAddress2(factory.Address, salt, factory.InitCodeHash)
 }
 
 type Pair interface {
@@ -109,3 +125,27 @@ type Pair interface {
 	Equal(p Pair) bool
 	GetLiquidityToken() *Token
 }
+
+// CopyablePair is a pair copied with new reserves by its Copy constructor, e.g. ClassicPair and StablePair
+type CopyablePair interface {
+	Pair
+	Copy(tokenAmountA, tokenAmountB *TokenAmount) (Pair, error)
+}
+
+// CopyWithReserves copies the pair with the reserves of token0 and token1, returns ErrNotImplemented if the pair
+// can not be copied
+func CopyWithReserves(pair Pair, reserve0, reserve1 *big.Int) (Pair, error) {
+	copyable, ok := pair.(CopyablePair)
+	if !ok {
+		return nil, ErrNotImplemented
+	}
+	tokenAmount0, err := NewTokenAmount(pair.Token0(), reserve0)
+	if err != nil {
+		return nil, err
+	}
+	tokenAmount1, err := NewTokenAmount(pair.Token1(), reserve1)
+	if err != nil {
+		return nil, err
+	}
+	return copyable.Copy(tokenAmount0, tokenAmount1)
+}
@@ -14,10 +14,21 @@ type PairBuilder struct {
 	fee          uint64
 	feeBase      uint64
 	pairAddress  common.Address
+	factory      *Factory
 	// if multipliers is all nil or is all 1, pair is classic pair
 	// else, is stable pair
 	multiplierA *big.Int
 	multiplierB *big.Int
+	// amplification coefficient of stable pairs, setting it builds a stable pair
+	amp *big.Int
+	// ramp of the amplification coefficient of stable pairs, setting it builds a stable pair
+	ramp *AmplificationRamp
+	// builds a Solidly stable pair, ignoring multipliers and amplification
+	solidlyStable bool
+	// type of the pair to build, inferred from the settings above if empty
+	pairType PairType
+	// parameters of custom pair types
+	parameters map[string]interface{}
 }
 
 func NewPairBuilder() *PairBuilder {
@@ -51,6 +62,12 @@ func (p *PairBuilder) SetPairAddress(pairAddress common.Address) *PairBuilder {
 	return p
 }
 
+// SetFactory set the factory that created the pair, used to derive pair address, liquidity token and default fee
+func (p *PairBuilder) SetFactory(factory *Factory) *PairBuilder {
+	p.factory = factory
+	return p
+}
+
 // SetTokenMultiplier set pair as table pair
 func (p *PairBuilder) SetTokenMultiplier(multiplierA, multiplierB *big.Int) *PairBuilder {
 	p.multiplierA = multiplierA
@@ -58,56 +75,187 @@ func (p *PairBuilder) SetTokenMultiplier(multiplierA, multiplierB *big.Int) *Pai
 	return p
 }
 
+// SetAmplification set the amplification coefficient A of stable pair, default is 1000
+func (p *PairBuilder) SetAmplification(amp *big.Int) *PairBuilder {
+	p.amp = amp
+	return p
+}
+
+// SetAmplificationRamp set the ramp of the amplification coefficient A of stable pair, the pair and the pairs copied
+// from it are quoted with A of the ramp at the current time
+func (p *PairBuilder) SetAmplificationRamp(ramp *AmplificationRamp) *PairBuilder {
+	p.ramp = ramp
+	return p
+}
+
+// SetSolidlyStable set pair as Solidly stable pair, x^3*y + y^3*x = k, e.g. stable pairs of Velodrome and Aerodrome
+func (p *PairBuilder) SetSolidlyStable() *PairBuilder {
+	p.solidlyStable = true
+	return p
+}
+
+// SetPairType set the type of the pair to build, which must be registered by RegisterPairType.
+// when not set, the type is inferred from the other settings: Solidly stable, stable if the amplification or
+// multipliers are set, classic otherwise
+func (p *PairBuilder) SetPairType(pairType PairType) *PairBuilder {
+	p.pairType = pairType
+	return p
+}
+
+// SetParameter set a parameter of a custom pair type, read by its PairConstructor with Parameter
+func (p *PairBuilder) SetParameter(name string, value interface{}) *PairBuilder {
+	if p.parameters == nil {
+		p.parameters = make(map[string]interface{})
+	}
+	p.parameters[name] = value
+	return p
+}
+
+// PairType returns the type of the pair to build
+func (p *PairBuilder) PairType() PairType {
+	if p.pairType != "" {
+		return p.pairType
+	}
+	if p.solidlyStable {
+		return SolidlyStable
+	}
+	if p.amp == nil && p.ramp == nil && (p.multiplierA == nil || p.multiplierB == nil ||
+		(p.multiplierA.Uint64() <= 1 && p.multiplierB.Uint64() <= 1)) {
+		return Classic
+	}
+	return Stable
+}
+
+// TokenAmounts returns the token amounts in the order they were set
+func (p *PairBuilder) TokenAmounts() (tokenAmountA, tokenAmountB *TokenAmount) {
+	return p.tokenAmountA, p.tokenAmountB
+}
+
+// Fee returns the swap fee, 3/1000 or the factory's if not set
+func (p *PairBuilder) Fee() (fee, feeBase *big.Int) {
+	_fee, _feeBase := p.fee, p.feeBase
+	if _fee == 0 && _feeBase == 0 && p.factory != nil {
+		_fee, _feeBase = p.factory.Fee, p.factory.FeeBase
+	}
+	if _fee == 0 && _feeBase == 0 {
+		return constants.Three, constants.B1000
+	}
+	return new(big.Int).SetUint64(_fee), new(big.Int).SetUint64(_feeBase)
+}
+
+// PairAddress returns the pair address, zero if it is derived from the factory
+func (p *PairBuilder) PairAddress() common.Address {
+	return p.pairAddress
+}
+
+// Factory returns the factory that created the pair, nil means the Uniswap V2 factory
+func (p *PairBuilder) Factory() *Factory {
+	return p.factory
+}
+
+// TokenMultipliers returns the multipliers of stable pairs
+func (p *PairBuilder) TokenMultipliers() (multiplierA, multiplierB *big.Int) {
+	return p.multiplierA, p.multiplierB
+}
+
+// Amplification returns the amplification coefficient of stable pairs, nil if not set
+func (p *PairBuilder) Amplification() *big.Int {
+	return p.amp
+}
+
+// AmplificationRamp returns the ramp of the amplification coefficient of stable pairs, nil if not set
+func (p *PairBuilder) AmplificationRamp() *AmplificationRamp {
+	return p.ramp
+}
+
+// Parameter returns a parameter of a custom pair type
+func (p *PairBuilder) Parameter(name string) (interface{}, bool) {
+	value, ok := p.parameters[name]
+	return value, ok
+}
+
+// Build builds the pair with the PairConstructor of its type
 func (p *PairBuilder) Build() (Pair, error) {
 	if nil == p.tokenAmountA || nil == p.tokenAmountB {
 		return nil, errors.New("token amount not set")
 	}
+	definition, ok := LookupPairType(p.PairType())
+	if !ok {
+		return nil, ErrUnknownPairType
+	}
+	return definition.Build(p)
+}
+
+// newBasePair returns the basePair and the fee of the pairs built by the builder
+func (p *PairBuilder) newBasePair() (basePair, *big.Int, *big.Int, error) {
 	tokenAmounts, err := NewTokenAmounts(p.tokenAmountA, p.tokenAmountB)
+	if err != nil {
+		return basePair{}, nil, nil, err
+	}
+	fee, feeBase := p.Fee()
+	return basePair{
+		TokenAmounts: tokenAmounts,
+		PairAddress:  p.pairAddress,
+		factory:      p.factory,
+	}, fee, feeBase, nil
+}
+
+func buildClassicPair(p *PairBuilder) (Pair, error) {
+	base, fee, feeBase, err := p.newBasePair()
+	if err != nil {
+		return nil, err
+	}
+	pair := &ClassicPair{
+		basePair: base,
+		fee:      fee,
+		feeBase:  feeBase,
+	}
+	pair.LiquidityToken, err = factoryOrDefault(p.factory).NewLiquidityToken(p.tokenAmountA.Token.ChainID, pair.GetAddress())
+	return pair, err
+}
+
+func buildStablePair(p *PairBuilder) (Pair, error) {
+	base, fee, feeBase, err := p.newBasePair()
 	if err != nil {
 		return nil, err
 	}
+	multiplierA, multiplierB := p.multiplierA, p.multiplierB
+	if multiplierA == nil || multiplierB == nil {
+		multiplierA, multiplierB = constants.One, constants.One
+	}
+	amp := p.amp
+	if amp == nil {
+		amp = constants.DefaultAmplification
+	}
+	if amp.Sign() <= 0 {
+		return nil, ErrInvalidAmplification
+	}
+	if r := p.ramp; r != nil && (r.InitialA == nil || r.FutureA == nil || r.InitialA.Sign() <= 0 || r.FutureA.Sign() <= 0) {
+		return nil, ErrInvalidAmplification
+	}
+	pair := &StablePair{
+		basePair:    base,
+		multiplierA: multiplierA,
+		multiplierB: multiplierB,
+		fee:         fee,
+		feeBase:     feeBase,
+		amp:         amp,
+		ramp:        p.ramp,
+	}
+	pair.LiquidityToken, err = factoryOrDefault(p.factory).NewLiquidityToken(p.tokenAmountA.Token.ChainID, pair.GetAddress())
+	return pair, err
+}
 
-	// set default fee 3/1000
-	fee := p.fee
-	feeBase := p.feeBase
-	var (
-		feeBI     *big.Int
-		feeBaseBI *big.Int
-	)
-	if fee == 0 && feeBase == 0 {
-		feeBI = constants.Three
-		feeBaseBI = constants.B1000
-	} else {
-		feeBI = big.NewInt(int64(fee))
-		feeBaseBI = big.NewInt(int64(feeBase))
-	}
-
-	if p.multiplierA == nil || p.multiplierB == nil ||
-		(p.multiplierA.Uint64() <= 1 && p.multiplierB.Uint64() <= 1) {
-		pair := &ClassicPair{
-			basePair: basePair{
-				TokenAmounts: tokenAmounts,
-				PairAddress:  p.pairAddress,
-			},
-			fee:     feeBI,
-			feeBase: feeBaseBI,
-		}
-		pair.LiquidityToken, err = NewToken(p.tokenAmountA.Token.ChainID, pair.GetAddress(),
-			constants.Decimals18, constants.Univ2Symbol, constants.Univ2Name)
-		return pair, err
-	} else {
-		pair := &StablePair{
-			basePair: basePair{
-				TokenAmounts: tokenAmounts,
-				PairAddress:  p.pairAddress,
-			},
-			multiplierA: p.multiplierA,
-			multiplierB: p.multiplierB,
-			fee:         feeBI,
-			feeBase:     feeBaseBI,
-		}
-		pair.LiquidityToken, err = NewToken(p.tokenAmountA.Token.ChainID, pair.GetAddress(),
-			constants.Decimals18, constants.Univ2Symbol, constants.Univ2Name)
-		return pair, err
+func buildSolidlyStablePair(p *PairBuilder) (Pair, error) {
+	base, fee, feeBase, err := p.newBasePair()
+	if err != nil {
+		return nil, err
+	}
+	pair := &SolidlyStablePair{
+		basePair: base,
+		fee:      fee,
+		feeBase:  feeBase,
 	}
+	pair.LiquidityToken, err = factoryOrDefault(p.factory).NewLiquidityToken(p.tokenAmountA.Token.ChainID, pair.GetAddress())
+	return pair, err
 }
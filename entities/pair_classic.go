@@ -13,6 +13,9 @@ type basePair struct {
 	TokenAmounts
 
 	PairAddress common.Address
+
+	// factory that created the pair, nil means the Uniswap V2 factory
+	factory *Factory
 }
 
 // ClassicPair wraps uniswap pair
@@ -42,6 +45,25 @@ func NewPair(tokenAmountA, tokenAmountB *TokenAmount) (Pair, error) {
 	return pair, err
 }
 
+// NewPairWithFactory creates Pair created by the factory, using the factory's default fee
+func NewPairWithFactory(tokenAmountA, tokenAmountB *TokenAmount, factory *Factory) (Pair, error) {
+	tokenAmounts, err := NewTokenAmounts(tokenAmountA, tokenAmountB)
+	if err != nil {
+		return nil, err
+	}
+
+	pair := &ClassicPair{
+		basePair: basePair{
+			TokenAmounts: tokenAmounts,
+			factory:      factory,
+		},
+		fee:     big.NewInt(int64(factory.Fee)),
+		feeBase: big.NewInt(int64(factory.FeeBase)),
+	}
+	pair.LiquidityToken, err = factory.NewLiquidityToken(tokenAmountA.Token.ChainID, pair.GetAddress())
+	return pair, err
+}
+
 // NewPair creates Pair
 func NewPairWithFee(tokenAmountA, tokenAmountB *TokenAmount, fee uint64, feeBase uint64) (Pair, error) {
 	tokenAmounts, err := NewTokenAmounts(tokenAmountA, tokenAmountB)
@@ -63,13 +85,18 @@ func NewPairWithFee(tokenAmountA, tokenAmountB *TokenAmount, fee uint64, feeBase
 
 // GetAddress returns a contract's address for a pair
 func (p *basePair) GetAddress() common.Address {
-	if len(p.PairAddress) == 0 {
-		return _PairAddressCache.GetAddress(p.TokenAmounts[0].Token.Address, p.TokenAmounts[1].Token.Address)
+	if p.PairAddress == (common.Address{}) {
+		return _PairAddressCache.GetFactoryAddress(p.Factory(), p.TokenAmounts[0].Token.Address, p.TokenAmounts[1].Token.Address)
 	} else {
 		return p.PairAddress
 	}
 }
 
+// Factory returns the factory that created the pair
+func (p *basePair) Factory() *Factory {
+	return factoryOrDefault(p.factory)
+}
+
 func (p *basePair) Equal(p1 Pair) bool {
 	return p.Token0().Equals(p1.Token0()) && p.Token1().Equals(p1.Token1())
 }
@@ -161,6 +188,7 @@ func (p *ClassicPair) Copy(tokenAmountA, tokenAmountB *TokenAmount) (Pair, error
 			TokenAmounts:   tokenAmounts,
 			PairAddress:    p.PairAddress,
 			LiquidityToken: p.LiquidityToken,
+			factory:        p.factory,
 		},
 		fee:     p.fee,
 		feeBase: p.feeBase,
@@ -170,6 +198,10 @@ func (p *ClassicPair) Copy(tokenAmountA, tokenAmountB *TokenAmount) (Pair, error
 
 // GetOutputAmount returns OutputAmount and a Pair for the InputAmout
 func (p *ClassicPair) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getOutputAmountAfterTax(p, inputAmount, p.getOutputAmount)
+}
+
+func (p *ClassicPair) getOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
 	if !p.InvolvesToken(inputAmount.Token) {
 		return nil, nil, ErrDiffToken
 	}
@@ -220,6 +252,10 @@ func (p *ClassicPair) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, P
 
 // GetInputAmount returns InputAmout and a Pair for the OutputAmount
 func (p *ClassicPair) GetInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getInputAmountAfterTax(p, outputAmount, p.getInputAmount)
+}
+
+func (p *ClassicPair) getInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
 	if !p.InvolvesToken(outputAmount.Token) {
 		return nil, nil, ErrDiffToken
 	}
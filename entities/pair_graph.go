@@ -0,0 +1,336 @@
+package entities
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+type tokenKey struct {
+	chainID constants.ChainID
+	address common.Address
+}
+
+func newTokenKey(token *Token) tokenKey {
+	return tokenKey{chainID: token.ChainID, address: token.Address}
+}
+
+// PairGraph indexes pairs by token so that route search only visits the pairs adjacent to the current token.
+// Pairs are searched in the order they were added, so the results are the same as searching the pair slice with
+// BestTradeExactIn/BestTradeExactOut.
+// A PairGraph is not safe for concurrent updates, searching concurrently without updating is safe.
+type PairGraph struct {
+	// slots of the pairs in insertion order, nil when removed
+	pairs []Pair
+	// token -> slots of the adjacent pairs, ascending
+	adjacent map[tokenKey][]int
+	// pair address -> slots
+	slots map[common.Address][]int
+	count int
+}
+
+// NewPairGraph creates a PairGraph of pairs
+func NewPairGraph(pairs []Pair) *PairGraph {
+	g := &PairGraph{
+		pairs:    make([]Pair, 0, len(pairs)),
+		adjacent: make(map[tokenKey][]int),
+		slots:    make(map[common.Address][]int, len(pairs)),
+	}
+	for _, pair := range pairs {
+		g.AddPair(pair)
+	}
+	return g
+}
+
+// Len returns the number of pairs in the graph
+func (g *PairGraph) Len() int {
+	return g.count
+}
+
+// Pairs returns the pairs in the graph in insertion order
+func (g *PairGraph) Pairs() []Pair {
+	pairs := make([]Pair, 0, g.count)
+	for _, pair := range g.pairs {
+		if pair != nil {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// PairsOf returns the pairs involving token in insertion order
+func (g *PairGraph) PairsOf(token *Token) []Pair {
+	slots := g.adjacent[newTokenKey(token)]
+	pairs := make([]Pair, len(slots))
+	for i, slot := range slots {
+		pairs[i] = g.pairs[slot]
+	}
+	return pairs
+}
+
+// AddPair adds pair to the graph
+func (g *PairGraph) AddPair(pair Pair) {
+	slot := len(g.pairs)
+	g.pairs = append(g.pairs, pair)
+	g.count++
+	// slots only grow, so adjacent slots stay sorted
+	for _, token := range []*Token{pair.Token0(), pair.Token1()} {
+		key := newTokenKey(token)
+		g.adjacent[key] = append(g.adjacent[key], slot)
+	}
+	address := pair.GetAddress()
+	g.slots[address] = append(g.slots[address], slot)
+}
+
+// UpdatePair replaces the pairs with the same address as pair, e.g. after its reserves changed, keeping their
+// search order. The pairs of the other coins of a StableSwapPool are updated to the pool state of pair.
+// Returns false if there is no such pair.
+func (g *PairGraph) UpdatePair(pair Pair) bool {
+	slots, ok := g.slots[pair.GetAddress()]
+	if !ok {
+		return false
+	}
+	for _, slot := range slots {
+		g.pairs[slot] = pairOfState(g.pairs[slot], pair)
+	}
+	return true
+}
+
+// RemovePair removes the pairs at address, returns false if there is no such pair
+func (g *PairGraph) RemovePair(address common.Address) bool {
+	slots, ok := g.slots[address]
+	if !ok {
+		return false
+	}
+	for _, slot := range slots {
+		pair := g.pairs[slot]
+		for _, token := range []*Token{pair.Token0(), pair.Token1()} {
+			key := newTokenKey(token)
+			g.adjacent[key] = removeSlot(g.adjacent[key], slot)
+			if len(g.adjacent[key]) == 0 {
+				delete(g.adjacent, key)
+			}
+		}
+		g.pairs[slot] = nil
+		g.count--
+	}
+	delete(g.slots, address)
+	return true
+}
+
+func removeSlot(slots []int, slot int) []int {
+	for i := range slots {
+		if slots[i] == slot {
+			return append(slots[:i], slots[i+1:]...)
+		}
+	}
+	return slots
+}
+
+// graphSearch holds the state of a single route search
+type graphSearch struct {
+	graph   *PairGraph
+	options *BestTradeOptions
+	ctx     context.Context
+	// number of paths explored, shared by the searches of the first hop branches
+	explored *int64
+	maxPaths int64
+	// slots of the pairs on the current path
+	used map[int]bool
+	// pairs on the current path, from the input side for exact input and from the output side for exact output
+	path   []Pair
+	trades []*Trade
+}
+
+func (g *PairGraph) newSearch(ctx context.Context, options *BestTradeOptions, budget *SearchBudget) (*graphSearch, error) {
+	if options == nil {
+		options = NewDefaultBestTradeOptions()
+	}
+	if g.count == 0 {
+		return nil, ErrInvalidPairs
+	}
+	if options.MaxHops <= 0 {
+		return nil, ErrInvalidOption
+	}
+	s := &graphSearch{
+		graph:    g,
+		options:  options,
+		ctx:      ctx,
+		explored: new(int64),
+	}
+	if budget != nil {
+		s.maxPaths = budget.MaxPaths
+		if budget.explored != nil {
+			s.explored = budget.explored
+		}
+	}
+	s.reset()
+	return s, nil
+}
+
+// branch returns a search sharing the budget of s, with its own path and results
+func (s *graphSearch) branch() *graphSearch {
+	b := *s
+	b.reset()
+	return &b
+}
+
+func (s *graphSearch) reset() {
+	s.used = make(map[int]bool, s.options.MaxHops)
+	s.path = make([]Pair, 0, s.options.MaxHops)
+	s.trades = nil
+}
+
+// exhausted counts a path to explore, and returns true if the search should stop
+func (s *graphSearch) exhausted() bool {
+	if s.maxPaths > 0 && atomic.AddInt64(s.explored, 1) > s.maxPaths {
+		return true
+	}
+	select {
+	case <-s.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+/**
+ * same as BestTradeExactIn of the pairs in the graph, but only visits the pairs adjacent to each token of the path
+ * @param currencyAmountIn exact amount of input currency to spend
+ * @param currencyOut the desired currency out
+ * @param options maximum number of results and hops
+ */
+func (g *PairGraph) BestTradeExactIn(currencyAmountIn *TokenAmount, currencyOut *Token, options *BestTradeOptions) ([]*Trade, error) {
+	return g.BestTradeExactInContext(context.Background(), currencyAmountIn, currencyOut, options, nil)
+}
+
+/**
+ * same as BestTradeExactOut of the pairs in the graph, but only visits the pairs adjacent to each token of the path
+ * @param currencyIn the currency to spend
+ * @param currencyAmountOut the exact amount of currency out
+ * @param options maximum number of results and hops
+ */
+func (g *PairGraph) BestTradeExactOut(currencyIn *Token, currencyAmountOut *TokenAmount, options *BestTradeOptions) ([]*Trade, error) {
+	return g.BestTradeExactOutContext(context.Background(), currencyIn, currencyAmountOut, options, nil)
+}
+
+func (s *graphSearch) exactIn(originalAmountIn, amountIn *TokenAmount, tokenOut *Token, maxHops int) error {
+	for _, slot := range s.graph.adjacent[newTokenKey(amountIn.Token)] {
+		if s.used[slot] {
+			continue
+		}
+		if s.exhausted() {
+			return nil
+		}
+		if err := s.exactInThrough(slot, originalAmountIn, amountIn, tokenOut, maxHops); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exactInThrough extends the current path with the pair at slot
+func (s *graphSearch) exactInThrough(slot int, originalAmountIn, amountIn *TokenAmount, tokenOut *Token, maxHops int) error {
+	pair := s.graph.pairs[slot]
+	if pair.Reserve0().EqualTo(ZeroFraction) || pair.Reserve1().EqualTo(ZeroFraction) {
+		return nil
+	}
+
+	amountOut, _, err := pair.GetOutputAmount(amountIn)
+	if err != nil {
+		// input too low or not enough liquidity in this pair
+		if err == ErrInsufficientInputAmount || err == ErrInsufficientReserves {
+			return nil
+		}
+		return err
+	}
+
+	// we have arrived at the output token, so this is the final trade of one of the paths
+	if amountOut.Token.Equals(tokenOut) {
+		pairs := append(append(make([]Pair, 0, len(s.path)+1), s.path...), pair)
+		route, err := NewRoute(pairs, originalAmountIn.Token, tokenOut)
+		if err != nil {
+			return err
+		}
+		trade, err := NewTrade(route, originalAmountIn, constants.ExactInput)
+		if err != nil {
+			return err
+		}
+		s.trades, _, err = SortedInsert(s.trades, trade, s.options.MaxNumResults, TradeComparator)
+		return err
+	}
+
+	// otherwise, consider all the other paths that lead from this token as long as we have not exceeded maxHops
+	if maxHops > 1 && s.graph.count-len(s.path) > 1 {
+		s.used[slot] = true
+		s.path = append(s.path, pair)
+		err = s.exactIn(originalAmountIn, amountOut, tokenOut, maxHops-1)
+		s.path = s.path[:len(s.path)-1]
+		delete(s.used, slot)
+	}
+	return err
+}
+
+func (s *graphSearch) exactOut(tokenIn *Token, originalAmountOut, amountOut *TokenAmount, maxHops int) error {
+	for _, slot := range s.graph.adjacent[newTokenKey(amountOut.Token)] {
+		if s.used[slot] {
+			continue
+		}
+		if s.exhausted() {
+			return nil
+		}
+		if err := s.exactOutThrough(slot, tokenIn, originalAmountOut, amountOut, maxHops); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exactOutThrough extends the current path with the pair at slot
+func (s *graphSearch) exactOutThrough(slot int, tokenIn *Token, originalAmountOut, amountOut *TokenAmount, maxHops int) error {
+	pair := s.graph.pairs[slot]
+	if pair.Reserve0().EqualTo(ZeroFraction) || pair.Reserve1().EqualTo(ZeroFraction) {
+		return nil
+	}
+
+	amountIn, _, err := pair.GetInputAmount(amountOut)
+	if err != nil {
+		// not enough liquidity in this pair
+		if err == ErrInsufficientReserves {
+			return nil
+		}
+		return err
+	}
+
+	// we have arrived at the input token, so this is the first trade of one of the paths
+	if amountIn.Token.Equals(tokenIn) {
+		pairs := make([]Pair, 0, len(s.path)+1)
+		pairs = append(pairs, pair)
+		for i := len(s.path) - 1; i >= 0; i-- {
+			pairs = append(pairs, s.path[i])
+		}
+		route, err := NewRoute(pairs, tokenIn, originalAmountOut.Token)
+		if err != nil {
+			return err
+		}
+		trade, err := NewTrade(route, originalAmountOut, constants.ExactOutput)
+		if err != nil {
+			return err
+		}
+		s.trades, _, err = SortedInsert(s.trades, trade, s.options.MaxNumResults, TradeComparator)
+		return err
+	}
+
+	// otherwise, consider all the other paths that arrive at this token as long as we have not exceeded maxHops
+	if maxHops > 1 && s.graph.count-len(s.path) > 1 {
+		s.used[slot] = true
+		s.path = append(s.path, pair)
+		err = s.exactOut(tokenIn, originalAmountOut, amountIn, maxHops-1)
+		s.path = s.path[:len(s.path)-1]
+		delete(s.used, slot)
+	}
+	return err
+}
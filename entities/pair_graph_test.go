@@ -0,0 +1,135 @@
+package entities
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+func assertSameTrades(t *testing.T, expect, got []*Trade) {
+	t.Helper()
+	if len(expect) != len(got) {
+		t.Fatalf("expect[%+v], but got[%+v]", len(expect), len(got))
+	}
+	for i := range expect {
+		if len(expect[i].Route.Pairs) != len(got[i].Route.Pairs) {
+			t.Fatalf("expect[%+v], but got[%+v]", len(expect[i].Route.Pairs), len(got[i].Route.Pairs))
+		}
+		for j := range expect[i].Route.Pairs {
+			if expect[i].Route.Pairs[j] != got[i].Route.Pairs[j] {
+				t.Errorf("expect[%+v], but got[%+v]", expect[i].Route.Pairs[j].GetAddress(), got[i].Route.Pairs[j].GetAddress())
+			}
+		}
+		if !expect[i].InputAmount().Equals(got[i].InputAmount()) || !expect[i].OutputAmount().Equals(got[i].OutputAmount()) {
+			t.Errorf("expect[%+v %+v], but got[%+v %+v]", expect[i].InputAmount().Raw(), expect[i].OutputAmount().Raw(),
+				got[i].InputAmount().Raw(), got[i].OutputAmount().Raw())
+		}
+	}
+}
+
+// randomPairs returns numTokens tokens and numPairs pairs with random reserves between them
+func randomPairs(numTokens, numPairs int) ([]*Token, []Pair) {
+	random := rand.New(rand.NewSource(1))
+	tokens := make([]*Token, numTokens)
+	for i := range tokens {
+		tokens[i], _ = NewToken(constants.Mainnet, common.BigToAddress(big.NewInt(int64(i+1))), 18, "t", "")
+	}
+	pairs := make([]Pair, 0)
+	seen := make(map[[2]int]bool)
+	for len(pairs) < numPairs {
+		a, b := random.Intn(len(tokens)), random.Intn(len(tokens))
+		if a == b || seen[[2]int{a, b}] || seen[[2]int{b, a}] {
+			continue
+		}
+		seen[[2]int{a, b}] = true
+		pairs = append(pairs, mustPair(tokens[a], mustEther(int64(1000+random.Intn(100000))), tokens[b], mustEther(int64(1000+random.Intn(100000)))))
+	}
+	return tokens, pairs
+}
+
+// nolint funlen
+func TestPairGraph(t *testing.T) {
+	tokens, pairs := randomPairs(12, 40)
+	graph := NewPairGraph(pairs)
+	if graph.Len() != len(pairs) {
+		t.Errorf("expect[%+v], but got[%+v]", len(pairs), graph.Len())
+	}
+
+	options := &BestTradeOptions{MaxNumResults: 5, MaxHops: 3}
+	for _, tokenIn := range tokens[:4] {
+		for _, tokenOut := range tokens[8:] {
+			amountIn, _ := NewTokenAmount(tokenIn, mustEther(100))
+			expect, err := BestTradeExactIn(pairs, amountIn, tokenOut, options, nil, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := graph.BestTradeExactIn(amountIn, tokenOut, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSameTrades(t, expect, got)
+
+			amountOut, _ := NewTokenAmount(tokenOut, mustEther(100))
+			expect, err = BestTradeExactOut(pairs, tokenIn, amountOut, options, nil, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err = graph.BestTradeExactOut(tokenIn, amountOut, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSameTrades(t, expect, got)
+		}
+	}
+
+	// incremental updates
+	{
+		pair := pairs[0]
+		if !graph.RemovePair(pair.GetAddress()) {
+			t.Error("should remove pair")
+		}
+		if graph.RemovePair(pair.GetAddress()) {
+			t.Error("should not remove pair twice")
+		}
+		for _, adjacent := range graph.PairsOf(pair.Token0()) {
+			if adjacent == pair {
+				t.Error("removed pair should not be adjacent")
+			}
+		}
+		amountIn, _ := NewTokenAmount(pair.Token0(), mustEther(100))
+		expect, err := BestTradeExactIn(pairs[1:], amountIn, pair.Token1(), options, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := graph.BestTradeExactIn(amountIn, pair.Token1(), options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameTrades(t, expect, got)
+
+		// the pair gives the best route after its reserves are updated
+		graph.AddPair(pair)
+		deeper := mustPair(pair.Token0(), mustEther(100000000), pair.Token1(), mustEther(1000000000000))
+		if !graph.UpdatePair(deeper) {
+			t.Error("should update pair")
+		}
+		got, err = graph.BestTradeExactIn(amountIn, pair.Token1(), options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[0].Route.Pairs[0] != deeper {
+			t.Error("updated pair should give the best trade")
+		}
+		if graph.Len() != len(pairs) {
+			t.Errorf("expect[%+v], but got[%+v]", len(pairs), graph.Len())
+		}
+	}
+
+	if _, err := NewPairGraph(nil).BestTradeExactIn(mustTokenAmount(tokens[0], big.NewInt(100)), tokens[1], nil); err != ErrInvalidPairs {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidPairs, err)
+	}
+}
@@ -0,0 +1,328 @@
+package entities
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// buildWithConstructor is the constructor of the pair types PairBuilder can not build, err names their own
+// constructor, e.g. NewWeightedPair
+func buildWithConstructor(err error) PairConstructor {
+	return func(*PairBuilder) (Pair, error) {
+		return nil, err
+	}
+}
+
+// decodeTokenAmounts returns the two amounts of the JSON
+func decodeTokenAmounts(tokenAmounts []*TokenAmount) (*TokenAmount, *TokenAmount, error) {
+	if len(tokenAmounts) != 2 || tokenAmounts[0] == nil || tokenAmounts[1] == nil {
+		return nil, nil, ErrInvalidJSON
+	}
+	return tokenAmounts[0], tokenAmounts[1], nil
+}
+
+/**** builder pair codec *****/
+
+type factoryJSON struct {
+	Address         checksumAddress `json:"address"`
+	InitCodeHash    hexutil.Bytes   `json:"initCodeHash"`
+	LiquiditySymbol string          `json:"liquiditySymbol,omitempty"`
+	LiquidityName   string          `json:"liquidityName,omitempty"`
+	Fee             uint64          `json:"fee,omitempty"`
+	FeeBase         uint64          `json:"feeBase,omitempty"`
+}
+
+// builderPairJSON is the JSON of the pairs built from the settings of PairBuilder, in the order of token0 and token1
+type builderPairJSON struct {
+	TokenAmounts []*TokenAmount   `json:"tokenAmounts"`
+	Fee          uint64           `json:"fee"`
+	FeeBase      uint64           `json:"feeBase"`
+	PairAddress  *checksumAddress `json:"pairAddress,omitempty"`
+	Factory      *factoryJSON     `json:"factory,omitempty"`
+	Multipliers  []*decimalInt    `json:"multipliers,omitempty"`
+	Amp          *decimalInt      `json:"amp,omitempty"`
+	Ramp         *rampJSON        `json:"ramp,omitempty"`
+}
+
+type rampJSON struct {
+	InitialA    *decimalInt `json:"initialA"`
+	FutureA     *decimalInt `json:"futureA"`
+	InitialTime uint64      `json:"initialTime"`
+	FutureTime  uint64      `json:"futureTime"`
+}
+
+func marshalBuilderPair(pair Pair) ([]byte, error) {
+	var (
+		base              *basePair
+		fee, feeBase, amp *big.Int
+		multipliers       []*big.Int
+		ramp              *AmplificationRamp
+	)
+	switch p := pair.(type) {
+	case *ClassicPair:
+		base, fee, feeBase = &p.basePair, p.fee, p.feeBase
+	case *StablePair:
+		base, fee, feeBase = &p.basePair, p.fee, p.feeBase
+		multipliers, amp, ramp = []*big.Int{p.multiplierA, p.multiplierB}, p.amp, p.ramp
+	case *SolidlyStablePair:
+		base, fee, feeBase = &p.basePair, p.fee, p.feeBase
+	default:
+		return nil, ErrUnknownPairType
+	}
+
+	data := builderPairJSON{
+		TokenAmounts: base.TokenAmounts[:],
+		Fee:          fee.Uint64(),
+		FeeBase:      feeBase.Uint64(),
+		Amp:          newDecimalInt(amp),
+	}
+	if base.PairAddress != (common.Address{}) {
+		address := checksumAddress(base.PairAddress)
+		data.PairAddress = &address
+	}
+	if f := base.factory; f != nil {
+		data.Factory = &factoryJSON{
+			Address:         checksumAddress(f.Address),
+			InitCodeHash:    f.InitCodeHash,
+			LiquiditySymbol: f.LiquiditySymbol,
+			LiquidityName:   f.LiquidityName,
+			Fee:             f.Fee,
+			FeeBase:         f.FeeBase,
+		}
+	}
+	for _, multiplier := range multipliers {
+		data.Multipliers = append(data.Multipliers, newDecimalInt(multiplier))
+	}
+	if ramp != nil {
+		data.Ramp = &rampJSON{
+			InitialA:    newDecimalInt(ramp.InitialA),
+			FutureA:     newDecimalInt(ramp.FutureA),
+			InitialTime: ramp.InitialTime,
+			FutureTime:  ramp.FutureTime,
+		}
+	}
+	return json.Marshal(data)
+}
+
+func unmarshalBuilderPair(pairType PairType, build PairConstructor) func(data []byte) (Pair, error) {
+	return func(data []byte) (Pair, error) {
+		var pairJSON builderPairJSON
+		if err := json.Unmarshal(data, &pairJSON); err != nil {
+			return nil, err
+		}
+		tokenAmountA, tokenAmountB, err := decodeTokenAmounts(pairJSON.TokenAmounts)
+		if err != nil {
+			return nil, err
+		}
+
+		builder := NewPairBuilder().
+			SetPairType(pairType).
+			SetTokenAmounts(tokenAmountA, tokenAmountB).
+			SetFee(pairJSON.Fee, pairJSON.FeeBase)
+		if pairJSON.PairAddress != nil {
+			builder.SetPairAddress(common.Address(*pairJSON.PairAddress))
+		}
+		if f := pairJSON.Factory; f != nil {
+			builder.SetFactory(NewFactory(common.Address(f.Address), f.InitCodeHash, f.LiquiditySymbol, f.LiquidityName, f.Fee, f.FeeBase))
+		}
+		if len(pairJSON.Multipliers) == 2 {
+			builder.SetTokenMultiplier(pairJSON.Multipliers[0].Int(), pairJSON.Multipliers[1].Int())
+		}
+		if pairJSON.Amp != nil {
+			builder.SetAmplification(pairJSON.Amp.Int())
+		}
+		if r := pairJSON.Ramp; r != nil {
+			builder.SetAmplificationRamp(&AmplificationRamp{
+				InitialA:    r.InitialA.Int(),
+				FutureA:     r.FutureA.Int(),
+				InitialTime: r.InitialTime,
+				FutureTime:  r.FutureTime,
+			})
+		}
+		return build(builder)
+	}
+}
+
+/**** weighted pair codec *****/
+
+type weightedPairJSON struct {
+	Address      checksumAddress `json:"address"`
+	TokenAmounts []*TokenAmount  `json:"tokenAmounts"`
+	Weights      []*decimalInt   `json:"weights"`
+	SwapFee      *decimalInt     `json:"swapFee"`
+}
+
+func marshalWeightedPair(pair Pair) ([]byte, error) {
+	p, ok := pair.(*WeightedPair)
+	if !ok {
+		return nil, ErrUnknownPairType
+	}
+	return json.Marshal(weightedPairJSON{
+		Address:      checksumAddress(p.PairAddress),
+		TokenAmounts: p.TokenAmounts[:],
+		Weights:      []*decimalInt{newDecimalInt(p.weight0), newDecimalInt(p.weight1)},
+		SwapFee:      newDecimalInt(p.swapFee),
+	})
+}
+
+func unmarshalWeightedPair(data []byte) (Pair, error) {
+	var pairJSON weightedPairJSON
+	if err := json.Unmarshal(data, &pairJSON); err != nil {
+		return nil, err
+	}
+	tokenAmount0, tokenAmount1, err := decodeTokenAmounts(pairJSON.TokenAmounts)
+	if err != nil {
+		return nil, err
+	}
+	if len(pairJSON.Weights) != 2 || pairJSON.Weights[0] == nil || pairJSON.Weights[1] == nil || pairJSON.SwapFee == nil {
+		return nil, ErrInvalidJSON
+	}
+	return NewWeightedPair(common.Address(pairJSON.Address), tokenAmount0, tokenAmount1,
+		pairJSON.Weights[0].Int(), pairJSON.Weights[1].Int(), pairJSON.SwapFee.Int())
+}
+
+/**** v3 pool codec *****/
+
+type tickJSON struct {
+	Index          int         `json:"index"`
+	LiquidityGross *decimalInt `json:"liquidityGross"`
+	LiquidityNet   *decimalInt `json:"liquidityNet"`
+}
+
+type v3PoolJSON struct {
+	TokenAmounts []*TokenAmount `json:"tokenAmounts"`
+	Fee          FeeAmount      `json:"fee"`
+	TickSpacing  int            `json:"tickSpacing"`
+	SqrtPriceX96 *decimalInt    `json:"sqrtPriceX96"`
+	Liquidity    *decimalInt    `json:"liquidity"`
+	TickCurrent  int            `json:"tickCurrent"`
+	Ticks        []tickJSON     `json:"ticks"`
+}
+
+func marshalV3Pool(pair Pair) ([]byte, error) {
+	p, ok := pair.(*V3Pool)
+	if !ok {
+		return nil, ErrUnknownPairType
+	}
+	data := v3PoolJSON{
+		TokenAmounts: p.TokenAmounts[:],
+		Fee:          p.fee,
+		TickSpacing:  p.tickSpacing,
+		SqrtPriceX96: newDecimalInt(p.sqrtPriceX96),
+		Liquidity:    newDecimalInt(p.liquidity),
+		TickCurrent:  p.tickCurrent,
+		Ticks:        make([]tickJSON, len(p.ticks)),
+	}
+	for i, tick := range p.ticks {
+		data.Ticks[i] = tickJSON{
+			Index:          tick.Index,
+			LiquidityGross: newDecimalInt(tick.LiquidityGross),
+			LiquidityNet:   newDecimalInt(tick.LiquidityNet),
+		}
+	}
+	return json.Marshal(data)
+}
+
+func unmarshalV3Pool(data []byte) (Pair, error) {
+	var poolJSON v3PoolJSON
+	if err := json.Unmarshal(data, &poolJSON); err != nil {
+		return nil, err
+	}
+	tokenAmount0, tokenAmount1, err := decodeTokenAmounts(poolJSON.TokenAmounts)
+	if err != nil {
+		return nil, err
+	}
+	if poolJSON.SqrtPriceX96 == nil || poolJSON.Liquidity == nil {
+		return nil, ErrInvalidJSON
+	}
+	ticks := make([]Tick, len(poolJSON.Ticks))
+	for i, tick := range poolJSON.Ticks {
+		if tick.LiquidityGross == nil || tick.LiquidityNet == nil {
+			return nil, ErrInvalidJSON
+		}
+		ticks[i] = Tick{Index: tick.Index, LiquidityGross: tick.LiquidityGross.Int(), LiquidityNet: tick.LiquidityNet.Int()}
+	}
+	return NewV3PoolWithTickSpacing(tokenAmount0, tokenAmount1, poolJSON.Fee, poolJSON.TickSpacing,
+		poolJSON.SqrtPriceX96.Int(), poolJSON.Liquidity.Int(), poolJSON.TickCurrent, ticks)
+}
+
+/**** stableswap pair codec *****/
+
+type stableSwapPoolJSON struct {
+	Address        checksumAddress `json:"address"`
+	Balances       []*TokenAmount  `json:"balances"`
+	Rates          []*decimalInt   `json:"rates"`
+	Amp            *decimalInt     `json:"amp"`
+	Fee            uint64          `json:"fee"`
+	AdminFee       uint64          `json:"adminFee"`
+	LiquidityToken *Token          `json:"liquidityToken,omitempty"`
+}
+
+// stableSwapPairJSON is the JSON of a pair of two coins of the pool, with the whole pool as exchanges depend on
+// the balances of all the coins
+type stableSwapPairJSON struct {
+	Pool   stableSwapPoolJSON `json:"pool"`
+	Index0 int                `json:"index0"`
+	Index1 int                `json:"index1"`
+}
+
+func marshalStableSwapPair(pair Pair) ([]byte, error) {
+	p, ok := pair.(*StableSwapPair)
+	if !ok {
+		return nil, ErrUnknownPairType
+	}
+	pool := p.pool
+	data := stableSwapPairJSON{
+		Pool: stableSwapPoolJSON{
+			Address:        checksumAddress(pool.address),
+			Balances:       pool.Balances(),
+			Rates:          make([]*decimalInt, len(pool.rates)),
+			Amp:            newDecimalInt(pool.amp),
+			Fee:            pool.fee.Uint64(),
+			AdminFee:       pool.adminFee.Uint64(),
+			LiquidityToken: pool.LiquidityToken,
+		},
+		Index0: p.index0,
+		Index1: p.index1,
+	}
+	for i, rate := range pool.rates {
+		data.Pool.Rates[i] = newDecimalInt(rate)
+	}
+	return json.Marshal(data)
+}
+
+func unmarshalStableSwapPair(data []byte) (Pair, error) {
+	var pairJSON stableSwapPairJSON
+	if err := json.Unmarshal(data, &pairJSON); err != nil {
+		return nil, err
+	}
+	poolJSON := pairJSON.Pool
+	if poolJSON.Amp == nil {
+		return nil, ErrInvalidJSON
+	}
+	rates := make([]*big.Int, len(poolJSON.Rates))
+	for i, rate := range poolJSON.Rates {
+		if rate == nil {
+			return nil, ErrInvalidJSON
+		}
+		rates[i] = rate.Int()
+	}
+	for _, balance := range poolJSON.Balances {
+		if balance == nil {
+			return nil, ErrInvalidJSON
+		}
+	}
+	pool, err := NewStableSwapPoolWithRates(common.Address(poolJSON.Address), poolJSON.Balances, rates, poolJSON.Amp.Int(),
+		poolJSON.Fee, poolJSON.AdminFee)
+	if err != nil {
+		return nil, err
+	}
+	pool.LiquidityToken = poolJSON.LiquidityToken
+	if pairJSON.Index0 < 0 || pairJSON.Index1 < 0 || pairJSON.Index0 >= len(pool.tokens) || pairJSON.Index1 >= len(pool.tokens) {
+		return nil, ErrInvalidJSON
+	}
+	return pool.Pair(pool.tokens[pairJSON.Index0], pool.tokens[pairJSON.Index1])
+}
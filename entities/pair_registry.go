@@ -0,0 +1,166 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrPairTypeRegistered the pair type is already registered
+	ErrPairTypeRegistered = fmt.Errorf("pair type registered")
+	// ErrUnknownPairType the pair type is not registered
+	ErrUnknownPairType = fmt.Errorf("unknown pair type")
+	// ErrInvalidPairTypeDefinition the pair type definition has no constructor
+	ErrInvalidPairTypeDefinition = fmt.Errorf("invalid pair type definition")
+
+	// ErrBuildWeightedPair PairBuilder does not build weighted pairs
+	ErrBuildWeightedPair = fmt.Errorf("weighted pairs are built by NewWeightedPair")
+	// ErrBuildV3Pool PairBuilder does not build v3 pools
+	ErrBuildV3Pool = fmt.Errorf("v3 pools are built by NewV3Pool")
+	// ErrBuildStableSwapPair PairBuilder does not build stableswap pairs
+	ErrBuildStableSwapPair = fmt.Errorf("stableswap pairs are built by StableSwapPool.Pair")
+
+	// the builtin pair types, the pair types with their own constructors are registered for their JSON codecs
+	_PairTypeRegistry = &PairTypeRegistry{
+		lk: new(sync.RWMutex),
+		definitions: map[PairType]PairTypeDefinition{
+			Classic: {
+				Build:         buildClassicPair,
+				MarshalJSON:   marshalBuilderPair,
+				UnmarshalJSON: unmarshalBuilderPair(Classic, buildClassicPair),
+			},
+			Stable: {
+				Build:         buildStablePair,
+				MarshalJSON:   marshalBuilderPair,
+				UnmarshalJSON: unmarshalBuilderPair(Stable, buildStablePair),
+			},
+			SolidlyStable: {
+				Build:         buildSolidlyStablePair,
+				MarshalJSON:   marshalBuilderPair,
+				UnmarshalJSON: unmarshalBuilderPair(SolidlyStable, buildSolidlyStablePair),
+			},
+			Weighted: {
+				Build:         buildWithConstructor(ErrBuildWeightedPair),
+				MarshalJSON:   marshalWeightedPair,
+				UnmarshalJSON: unmarshalWeightedPair,
+			},
+			V3: {
+				Build:         buildWithConstructor(ErrBuildV3Pool),
+				MarshalJSON:   marshalV3Pool,
+				UnmarshalJSON: unmarshalV3Pool,
+			},
+			StableSwap: {
+				Build:         buildWithConstructor(ErrBuildStableSwapPair),
+				MarshalJSON:   marshalStableSwapPair,
+				UnmarshalJSON: unmarshalStableSwapPair,
+			},
+		},
+	}
+)
+
+// PairConstructor builds a pair from the settings of the builder
+type PairConstructor func(builder *PairBuilder) (Pair, error)
+
+// PairTypeDefinition defines how pairs of a type are built and (un)marshalled, the JSON codec is optional
+type PairTypeDefinition struct {
+	Build PairConstructor
+	// MarshalJSON returns the JSON of a pair of the type
+	MarshalJSON func(pair Pair) ([]byte, error)
+	// UnmarshalJSON returns the pair of the JSON returned by MarshalJSON
+	UnmarshalJSON func(data []byte) (Pair, error)
+}
+
+// PairTypeRegistry warps the definitions of pair types
+type PairTypeRegistry struct {
+	lk          *sync.RWMutex
+	definitions map[PairType]PairTypeDefinition
+}
+
+/**
+ * RegisterPairType registers a custom pair type, so its pairs can be built by PairBuilder.SetPairType and
+ * (un)marshalled by MarshalPair and UnmarshalPair. Pairs of the type must return it from PairType.
+ * @param pairType the type to register, which can not be registered twice
+ * @param definition constructor and optional JSON codec of the type
+ */
+func RegisterPairType(pairType PairType, definition PairTypeDefinition) error {
+	return _PairTypeRegistry.Register(pairType, definition)
+}
+
+// LookupPairType returns the definition of the registered pair type
+func LookupPairType(pairType PairType) (PairTypeDefinition, bool) {
+	return _PairTypeRegistry.Lookup(pairType)
+}
+
+// RegisteredPairTypes returns the registered pair types in order
+func RegisteredPairTypes() []PairType {
+	return _PairTypeRegistry.PairTypes()
+}
+
+// Register registers the definition of pairType
+func (r *PairTypeRegistry) Register(pairType PairType, definition PairTypeDefinition) error {
+	if pairType == "" || definition.Build == nil {
+		return ErrInvalidPairTypeDefinition
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	if _, ok := r.definitions[pairType]; ok {
+		return ErrPairTypeRegistered
+	}
+	r.definitions[pairType] = definition
+	return nil
+}
+
+// Lookup returns the definition of pairType
+func (r *PairTypeRegistry) Lookup(pairType PairType) (PairTypeDefinition, bool) {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+	definition, ok := r.definitions[pairType]
+	return definition, ok
+}
+
+// PairTypes returns the registered pair types in order
+func (r *PairTypeRegistry) PairTypes() []PairType {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+	pairTypes := make([]PairType, 0, len(r.definitions))
+	for pairType := range r.definitions {
+		pairTypes = append(pairTypes, pairType)
+	}
+	sort.Slice(pairTypes, func(i, j int) bool { return pairTypes[i] < pairTypes[j] })
+	return pairTypes
+}
+
+// pairEnvelope is the JSON of any pair, the type selects the codec of the pair
+type pairEnvelope struct {
+	Type PairType        `json:"type"`
+	Pair json.RawMessage `json:"pair"`
+}
+
+// MarshalPair returns the JSON of pair, with the codec of its registered type
+func MarshalPair(pair Pair) ([]byte, error) {
+	definition, ok := LookupPairType(pair.PairType())
+	if !ok || definition.MarshalJSON == nil {
+		return nil, ErrUnknownPairType
+	}
+	data, err := definition.MarshalJSON(pair)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(pairEnvelope{Type: pair.PairType(), Pair: data})
+}
+
+// UnmarshalPair returns the pair of the JSON returned by MarshalPair
+func UnmarshalPair(data []byte) (Pair, error) {
+	var envelope pairEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	definition, ok := LookupPairType(envelope.Type)
+	if !ok || definition.UnmarshalJSON == nil {
+		return nil, ErrUnknownPairType
+	}
+	return definition.UnmarshalJSON(envelope.Pair)
+}
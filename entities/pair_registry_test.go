@@ -0,0 +1,158 @@
+package entities
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+const testCustom PairType = "test_custom"
+
+// customPair is a classic pair swapping at most maxIn per trade
+type customPair struct {
+	Pair
+	maxIn *big.Int
+}
+
+func (p *customPair) PairType() PairType {
+	return testCustom
+}
+
+func (p *customPair) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	if inputAmount.Raw().Cmp(p.maxIn) > 0 {
+		return nil, nil, ErrInsufficientReserves
+	}
+	outputAmount, next, err := p.Pair.GetOutputAmount(inputAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outputAmount, &customPair{Pair: next, maxIn: p.maxIn}, nil
+}
+
+type customPairJSON struct {
+	Classic json.RawMessage `json:"classic"`
+	MaxIn   string          `json:"maxIn"`
+}
+
+var customPairDefinition = PairTypeDefinition{
+	Build: func(builder *PairBuilder) (Pair, error) {
+		maxIn, ok := builder.Parameter("maxIn")
+		if !ok {
+			return nil, ErrInvalidPairTypeDefinition
+		}
+		classic, err := buildClassicPair(builder)
+		if err != nil {
+			return nil, err
+		}
+		return &customPair{Pair: classic, maxIn: maxIn.(*big.Int)}, nil
+	},
+	MarshalJSON: func(pair Pair) ([]byte, error) {
+		p := pair.(*customPair)
+		classic, err := MarshalPair(p.Pair)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(customPairJSON{Classic: classic, MaxIn: p.maxIn.String()})
+	},
+	UnmarshalJSON: func(data []byte) (Pair, error) {
+		var pairJSON customPairJSON
+		if err := json.Unmarshal(data, &pairJSON); err != nil {
+			return nil, err
+		}
+		classic, err := UnmarshalPair(pairJSON.Classic)
+		if err != nil {
+			return nil, err
+		}
+		maxIn, _ := new(big.Int).SetString(pairJSON.MaxIn, 10)
+		return &customPair{Pair: classic, maxIn: maxIn}, nil
+	},
+}
+
+// nolint funlen
+func TestPairTypeRegistry(t *testing.T) {
+	usdc, _ := NewToken(constants.Mainnet, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "USD Coin")
+	dai, _ := NewToken(constants.Mainnet, common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"), 18, "DAI", "Dai Stablecoin")
+	reserveUSDC, _ := NewTokenAmount(usdc, mustUnits(1000000, 6))
+	reserveDAI, _ := NewTokenAmount(dai, mustUnits(1000000, 18))
+
+	if err := RegisterPairType(testCustom, customPairDefinition); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterPairType(testCustom, customPairDefinition); err != ErrPairTypeRegistered {
+		t.Errorf("expect[%+v], but got[%+v]", ErrPairTypeRegistered, err)
+	}
+	if err := RegisterPairType("test_invalid", PairTypeDefinition{}); err != ErrInvalidPairTypeDefinition {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidPairTypeDefinition, err)
+	}
+	if _, err := NewPairBuilder().SetTokenAmounts(reserveUSDC, reserveDAI).SetPairType("test_unknown").Build(); err != ErrUnknownPairType {
+		t.Errorf("expect[%+v], but got[%+v]", ErrUnknownPairType, err)
+	}
+	registered := false
+	for _, pairType := range RegisteredPairTypes() {
+		registered = registered || pairType == testCustom
+	}
+	if !registered {
+		t.Errorf("expect[%+v] in [%+v]", testCustom, RegisteredPairTypes())
+	}
+
+	// custom pairs are built and routed like builtin pairs
+	pair, err := NewPairBuilder().SetTokenAmounts(reserveUSDC, reserveDAI).
+		SetPairType(testCustom).SetParameter("maxIn", mustUnits(1000, 6)).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.PairType() != testCustom {
+		t.Fatalf("expect[%+v], but got[%+v]", testCustom, pair.PairType())
+	}
+	{
+		amountIn, _ := NewTokenAmount(usdc, mustUnits(100, 6))
+		trades, err := BestTradeExactIn([]Pair{pair}, amountIn, dai, NewDefaultBestTradeOptions(), nil, amountIn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) != 1 || trades[0].Route.Pairs[0].PairType() != testCustom {
+			t.Errorf("expect a trade through the custom pair, but got[%+v]", trades)
+		}
+		tooMuch, _ := NewTokenAmount(usdc, mustUnits(1001, 6))
+		if _, _, err := pair.GetOutputAmount(tooMuch); err != ErrInsufficientReserves {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInsufficientReserves, err)
+		}
+	}
+
+	// JSON round trip of custom and builtin pairs
+	factory := NewFactory(common.HexToAddress("0xF1046053aa5682b4F9a81b5481394DA16BE5FF5a"),
+		common.FromHex("0xc0629f1c7daa09624e54d4f711ba99922a844907cce02997176399e4cc7e8fcf"), "sAMMV2", "StableV2 AMM", 5, 10000)
+	stable, _ := NewPairBuilder().SetTokenAmounts(reserveUSDC, reserveDAI).
+		SetTokenMultiplier(big.NewInt(1e12), big.NewInt(1)).SetAmplification(big.NewInt(200)).SetFee(4, 10000).Build()
+	classic, _ := NewPairBuilder().SetTokenAmounts(reserveUSDC, reserveDAI).SetPairAddress(common.HexToAddress("0xAE461cA67B15dc8dc81CE7615e0320dA1A9aB8D5")).Build()
+	solidly, _ := NewPairBuilder().SetTokenAmounts(reserveUSDC, reserveDAI).SetFactory(factory).SetSolidlyStable().Build()
+	for _, pair := range []Pair{pair, classic, stable, solidly} {
+		data, err := MarshalPair(pair)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := UnmarshalPair(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.PairType() != pair.PairType() || got.GetAddress() != pair.GetAddress() ||
+			got.Reserve0().Raw().Cmp(pair.Reserve0().Raw()) != 0 || got.Reserve1().Raw().Cmp(pair.Reserve1().Raw()) != 0 ||
+			got.GetLiquidityToken().Symbol != pair.GetLiquidityToken().Symbol || got.Token0().Name != pair.Token0().Name {
+			t.Errorf("expect[%+v], but got[%+v]", pair, got)
+		}
+		amountIn, _ := NewTokenAmount(usdc, mustUnits(1000, 6))
+		expect, _, _ := pair.GetOutputAmount(amountIn)
+		output, _, err := got.GetOutputAmount(amountIn)
+		if err != nil || output.Raw().Cmp(expect.Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), output)
+		}
+	}
+
+	if _, err := UnmarshalPair([]byte(`{"type":"test_unknown","pair":{}}`)); err != ErrUnknownPairType {
+		t.Errorf("expect[%+v], but got[%+v]", ErrUnknownPairType, err)
+	}
+}
@@ -0,0 +1,295 @@
+package entities
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/utils"
+)
+
+var SolidlyStable PairType = "solidly_stable"
+
+// SolidlyStablePair wraps a stable pair of Solidly forks, e.g. Velodrome, Aerodrome and Thena.
+// The reserves normalized to 18 decimals keep x^3*y + y^3*x >= k, liquidity is minted and burned like ClassicPair.
+type SolidlyStablePair struct {
+	basePair
+
+	fee     *big.Int
+	feeBase *big.Int
+}
+
+// NewSolidlyStablePair creates a SolidlyStablePair created by factory, with fee/feeBase swap fee
+func NewSolidlyStablePair(tokenAmountA, tokenAmountB *TokenAmount, factory *Factory, fee, feeBase uint64) (Pair, error) {
+	tokenAmounts, err := NewTokenAmounts(tokenAmountA, tokenAmountB)
+	if err != nil {
+		return nil, err
+	}
+
+	pair := &SolidlyStablePair{
+		basePair: basePair{
+			TokenAmounts: tokenAmounts,
+			factory:      factory,
+		},
+		fee:     big.NewInt(int64(fee)),
+		feeBase: big.NewInt(int64(feeBase)),
+	}
+	pair.LiquidityToken, err = factoryOrDefault(factory).NewLiquidityToken(tokenAmountA.Token.ChainID, pair.GetAddress())
+	return pair, err
+}
+
+/**** solidly stable pair *****/
+
+func (p *SolidlyStablePair) PairType() PairType {
+	return SolidlyStable
+}
+
+// GetAddress returns the address of the stable pair created by the factory, the salt is
+// keccak256(abi.encodePacked(token0, token1, true)) so stable and volatile pairs of two tokens differ
+func (p *SolidlyStablePair) GetAddress() common.Address {
+	if p.PairAddress != (common.Address{}) {
+		return p.PairAddress
+	}
+	factory := p.Factory()
+	var salt [32]byte
+	copy(salt[:], crypto.Keccak256(p.Token0().Address.Bytes(), p.Token1().Address.Bytes(), []byte{1}))
+	return crypto.CreateAddress2(factory.Address, salt, factory.InitCodeHash)
+}
+
+// Equal returns true for the pairs of the same pool, the stable and volatile pairs of the same tokens are different
+// pairs
+func (p *SolidlyStablePair) Equal(p1 Pair) bool {
+	return p.GetAddress() == p1.GetAddress()
+}
+
+func (p *SolidlyStablePair) Copy(tokenAmountA, tokenAmountB *TokenAmount) (Pair, error) {
+	tokenAmounts, err := NewTokenAmounts(tokenAmountA, tokenAmountB)
+	if err != nil {
+		return nil, err
+	}
+
+	pair := &SolidlyStablePair{
+		basePair: basePair{
+			TokenAmounts:   tokenAmounts,
+			PairAddress:    p.PairAddress,
+			LiquidityToken: p.LiquidityToken,
+			factory:        p.factory,
+		},
+		fee:     p.fee,
+		feeBase: p.feeBase,
+	}
+	return pair, err
+}
+
+// decimals returns 10^decimals of token
+func decimals(token *Token) *big.Int {
+	return new(big.Int).Exp(constants.Ten, big.NewInt(int64(token.Decimals)), nil)
+}
+
+// normalize returns the raw amount of token at 18 decimals
+func normalize(amount *big.Int, token *Token) *big.Int {
+	normalized := new(big.Int).Mul(amount, constants.B1e18)
+	return normalized.Div(normalized, decimals(token))
+}
+
+// Token0Price Returns the current mid price of the pair in terms of token0, i.e. the marginal amount of token1 per
+// token0 on the stable curve
+func (p *SolidlyStablePair) Token0Price() *Price {
+	numerator, denominator, ok := p.spotPrice()
+	if !ok {
+		return p.basePair.Token0Price()
+	}
+	return NewPrice(p.Token0().Currency, p.Token1().Currency, denominator, numerator)
+}
+
+// Token1Price Returns the current mid price of the pair in terms of token1, i.e. the marginal amount of token0 per
+// token1 on the stable curve
+func (p *SolidlyStablePair) Token1Price() *Price {
+	numerator, denominator, ok := p.spotPrice()
+	if !ok {
+		return p.basePair.Token1Price()
+	}
+	return NewPrice(p.Token1().Currency, p.Token0().Currency, numerator, denominator)
+}
+
+// PriceOf Returns the price of the given token in terms of the other token in the pair.
+// @param token token to return price of
+func (p *SolidlyStablePair) PriceOf(token *Token) (*Price, error) {
+	if !p.InvolvesToken(token) {
+		return nil, ErrDiffToken
+	}
+
+	if token.Equals(p.Token0()) {
+		return p.Token0Price(), nil
+	}
+	return p.Token1Price(), nil
+}
+
+// spotPrice returns the raw amount of token1 per raw amount of token0 at the margin, as numerator / denominator.
+// the curve price is in normalized units, a raw token0 is 1e18 / 10^decimals0 of them and a raw token1
+// 1e18 / 10^decimals1.
+func (p *SolidlyStablePair) spotPrice() (numerator, denominator *big.Int, ok bool) {
+	_x := normalize(p.Reserve0().Raw(), p.Token0())
+	_y := normalize(p.Reserve1().Raw(), p.Token1())
+	if _x.Sign() == 0 || _y.Sign() == 0 {
+		return nil, nil, false
+	}
+	numerator, denominator = utils.SolidlySpotPrice(_x, _y)
+	return numerator.Mul(numerator, decimals(p.Token1())), denominator.Mul(denominator, decimals(p.Token0())), true
+}
+
+// GetOutputAmount returns OutputAmount and a Pair for the InputAmout, as getAmountOut of the pair contracts
+func (p *SolidlyStablePair) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getOutputAmountAfterTax(p, inputAmount, p.getOutputAmount)
+}
+
+func (p *SolidlyStablePair) getOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	if !p.InvolvesToken(inputAmount.Token) {
+		return nil, nil, ErrDiffToken
+	}
+
+	if p.Reserve0().Raw().Cmp(constants.Zero) == 0 ||
+		p.Reserve1().Raw().Cmp(constants.Zero) == 0 {
+		return nil, nil, ErrInsufficientReserves
+	}
+
+	inputReserve, err := p.ReserveOf(inputAmount.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+	token := p.other(inputAmount.Token)
+	outputReserve, err := p.ReserveOf(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_feeIn := new(big.Int).Div(new(big.Int).Mul(inputAmount.Raw(), p.fee), p.feeBase)
+	_amountIn := normalize(new(big.Int).Sub(inputAmount.Raw(), _feeIn), inputAmount.Token)
+	_reserveA := normalize(inputReserve.Raw(), inputAmount.Token)
+	_reserveB := normalize(outputReserve.Raw(), token)
+	_xy := utils.SolidlyK(_reserveA, _reserveB)
+	_yNew, err := utils.SolidlyGetY(new(big.Int).Add(_amountIn, _reserveA), _xy, _reserveB, decimals(p.Token0()), decimals(p.Token1()))
+	if err != nil {
+		return nil, nil, err
+	}
+	_y := new(big.Int).Sub(_reserveB, _yNew)
+	if _y.Sign() < 0 {
+		_y.SetInt64(0)
+	}
+	outputAmount, err := NewTokenAmount(token, _y.Mul(_y, decimals(token)).Div(_y, constants.B1e18))
+	if err != nil {
+		return nil, nil, err
+	}
+	if outputAmount.Raw().Cmp(constants.Zero) == 0 {
+		return nil, nil, ErrInsufficientInputAmount
+	}
+
+	pair, err := p.next(inputAmount, _feeIn, inputReserve, outputAmount, outputReserve)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outputAmount, pair, nil
+}
+
+// GetInputAmount returns InputAmout and a Pair for the OutputAmount.
+// the curve is symmetric, so the input balance is solved the same way as the output balance of GetOutputAmount
+func (p *SolidlyStablePair) GetInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getInputAmountAfterTax(p, outputAmount, p.getInputAmount)
+}
+
+func (p *SolidlyStablePair) getInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	if !p.InvolvesToken(outputAmount.Token) {
+		return nil, nil, ErrDiffToken
+	}
+
+	outputReserve, err := p.ReserveOf(outputAmount.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.Reserve0().Raw().Cmp(constants.Zero) == 0 ||
+		p.Reserve1().Raw().Cmp(constants.Zero) == 0 ||
+		outputAmount.Raw().Cmp(outputReserve.Raw()) >= 0 {
+		return nil, nil, ErrInsufficientReserves
+	}
+
+	token := p.other(outputAmount.Token)
+	inputReserve, err := p.ReserveOf(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_reserveA := normalize(inputReserve.Raw(), token)
+	_reserveB := normalize(outputReserve.Raw(), outputAmount.Token)
+	_xy := utils.SolidlyK(_reserveA, _reserveB)
+	// the output normalized is rounded up
+	_amountOut := ceilDiv(new(big.Int).Mul(outputAmount.Raw(), constants.B1e18), decimals(outputAmount.Token))
+	_yNew := new(big.Int).Sub(_reserveB, _amountOut)
+	if _yNew.Sign() <= 0 {
+		return nil, nil, ErrInsufficientReserves
+	}
+	_x, err := utils.SolidlyGetY(_yNew, _xy, _reserveA, decimals(p.Token0()), decimals(p.Token1()))
+	if err != nil {
+		return nil, nil, err
+	}
+	_amountIn := new(big.Int).Sub(_x, _reserveA)
+	if _amountIn.Sign() < 0 {
+		_amountIn.SetInt64(0)
+	}
+	// back to raw amount rounded up, with 1 unit for the rounding of the contracts, then before the fee
+	amount := ceilDiv(_amountIn.Mul(_amountIn, decimals(token)), constants.B1e18)
+	amount.Add(amount, constants.One)
+	amount = ceilDiv(amount.Mul(amount, p.feeBase), new(big.Int).Sub(p.feeBase, p.fee))
+	inputAmount, err := NewTokenAmount(token, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_feeIn := new(big.Int).Div(new(big.Int).Mul(inputAmount.Raw(), p.fee), p.feeBase)
+	pair, err := p.next(inputAmount, _feeIn, inputReserve, outputAmount, outputReserve)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inputAmount, pair, nil
+}
+
+// next returns the pair after the swap, the fee of the input is sent to the PoolFees contract of the pair instead of
+// staying in the reserves
+func (p *SolidlyStablePair) next(inputAmount *TokenAmount, feeIn *big.Int, inputReserve, outputAmount,
+	outputReserve *TokenAmount) (Pair, error) {
+	reserve := new(big.Int).Add(inputReserve.Raw(), inputAmount.Raw())
+	tokenAmountA, err := NewTokenAmount(inputAmount.Token, reserve.Sub(reserve, feeIn))
+	if err != nil {
+		return nil, err
+	}
+	tokenAmountB, err := outputReserve.Subtract(outputAmount)
+	if err != nil {
+		return nil, err
+	}
+	return p.Copy(tokenAmountA, tokenAmountB)
+}
+
+func (p *SolidlyStablePair) other(token *Token) *Token {
+	if token.Equals(p.Token0()) {
+		return p.Token1()
+	}
+	return p.Token0()
+}
+
+// classic returns the pair as a ClassicPair, Solidly pairs mint and burn liquidity the same way
+func (p *SolidlyStablePair) classic() *ClassicPair {
+	return &ClassicPair{basePair: p.basePair, fee: p.fee, feeBase: p.feeBase}
+}
+
+// GetLiquidityMinted returns liquidity minted TokenAmount, the same as ClassicPair
+func (p *SolidlyStablePair) GetLiquidityMinted(totalSupply, tokenAmountA, tokenAmountB *TokenAmount) (*TokenAmount, error) {
+	return p.classic().GetLiquidityMinted(totalSupply, tokenAmountA, tokenAmountB)
+}
+
+// GetLiquidityValue returns liquidity value TokenAmount, a proportional share of the reserves.
+// Solidly pairs send the fees to the liquidity providers and gauges instead of minting to feeTo, so feeOn and kLast
+// are ignored
+func (p *SolidlyStablePair) GetLiquidityValue(token *Token, totalSupply, liquidity *TokenAmount, feeOn bool, kLast *big.Int) (*TokenAmount, error) {
+	return p.classic().GetLiquidityValue(token, totalSupply, liquidity, false, kLast)
+}
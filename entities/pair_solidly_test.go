@@ -0,0 +1,171 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/utils"
+)
+
+// nolint funlen
+func TestSolidlyStablePair(t *testing.T) {
+	usdc, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85"), 6, "USDC", "")
+	dai, _ := NewToken(constants.Mainnet, common.HexToAddress("0xDA10009cBd5D07dd0CeCc66161FC93D7c9000da1"), 18, "DAI", "")
+	factory := NewFactory(common.HexToAddress("0xF1046053aa5682b4F9a81b5481394DA16BE5FF5a"),
+		common.FromHex("0xc0629f1c7daa09624e54d4f711ba99922a844907cce02997176399e4cc7e8fcf"), "sAMMV2", "StableV2 AMM", 5, 10000)
+
+	reserveUSDC, _ := NewTokenAmount(usdc, mustUnits(1000000, 6))
+	reserveDAI, _ := NewTokenAmount(dai, mustUnits(1000000, 18))
+	pair, err := NewPairBuilder().SetTokenAmounts(reserveUSDC, reserveDAI).SetFactory(factory).SetSolidlyStable().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.PairType() != SolidlyStable {
+		t.Fatalf("expect[%+v], but got[%+v]", SolidlyStable, pair.PairType())
+	}
+	classic, err := NewPairBuilder().SetTokenAmounts(reserveUSDC, reserveDAI).SetFactory(factory).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// stable and volatile pairs of the same tokens are different contracts
+	if pair.GetAddress() == classic.GetAddress() {
+		t.Error("stable pair should have its own address")
+	}
+	if pair.Equal(classic) || !pair.Equal(pair) {
+		t.Error("stable and volatile pairs should be different pairs")
+	}
+	{
+		amountIn, _ := NewTokenAmount(usdc, mustUnits(10000, 6))
+		smartTrades, err := BestSmartTradeExactIn([]Pair{pair, classic}, amountIn, dai, &BestSmartTradeOptions{
+			BestTradeOptions:        *NewDefaultBestTradeOptions(),
+			MaxSplit:                2,
+			MaxSmartTradeNumResults: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		split := false
+		for _, smartTrade := range smartTrades {
+			split = split || len(smartTrade.Trades) == 2
+		}
+		if !split {
+			t.Error("trades should be split across the stable and volatile pairs")
+		}
+	}
+
+	// the flat curve swaps close to 1:1, minus the 0.05% fee, and much better than the constant product
+	{
+		amountIn, _ := NewTokenAmount(usdc, mustUnits(10000, 6))
+		amountOut, next, err := pair.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amountOut.Raw().Cmp(mustUnits(9994, 18)) <= 0 || amountOut.Raw().Cmp(mustUnits(9995, 18)) > 0 {
+			t.Errorf("expect about [%+v], but got[%+v]", mustUnits(9995, 18), amountOut.Raw())
+		}
+		classicOut, _, err := classic.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amountOut.Raw().Cmp(classicOut.Raw()) <= 0 {
+			t.Errorf("expect more than [%+v], but got[%+v]", classicOut.Raw(), amountOut.Raw())
+		}
+		// the invariant does not decrease
+		k0 := solidlyK(pair)
+		if k1 := solidlyK(next); k1.Cmp(k0) < 0 {
+			t.Errorf("expect k at least [%+v], but got[%+v]", k0, k1)
+		}
+		// the fee leaves the reserves for the PoolFees contract
+		reserve, _ := next.ReserveOf(usdc)
+		expect := new(big.Int).Add(reserveUSDC.Raw(), mustUnits(10000-5, 6))
+		if reserve.Raw().Cmp(expect) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect, reserve.Raw())
+		}
+	}
+
+	// exact output is the least input giving the output, both ways
+	for _, amountOut := range []*TokenAmount{
+		mustTokenAmount(dai, mustUnits(250000, 18)),
+		mustTokenAmount(usdc, mustUnits(250000, 6)),
+		mustTokenAmount(usdc, big.NewInt(1)),
+	} {
+		amountIn, _, err := pair.GetInputAmount(amountOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := pair.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Raw().Cmp(amountOut.Raw()) < 0 {
+			t.Errorf("expect at least [%+v], but got[%+v]", amountOut.Raw(), got.Raw())
+		}
+		slack := new(big.Int).Div(amountIn.Raw(), big.NewInt(1e6))
+		if slack.Sign() == 0 {
+			continue
+		}
+		less, _ := NewTokenAmount(amountIn.Token, new(big.Int).Sub(amountIn.Raw(), slack))
+		if got, _, err := pair.GetOutputAmount(less); err == nil && got.Raw().Cmp(amountOut.Raw()) >= 0 {
+			t.Errorf("input [%+v] is not the least", amountIn.Raw())
+		}
+	}
+
+	// the mid price is the price of a small trade before the fee, on an imbalanced pair
+	{
+		imbalancedDAI, _ := NewTokenAmount(dai, mustUnits(3000000, 18))
+		imbalanced, err := NewSolidlyStablePair(reserveUSDC, imbalancedDAI, factory, 5, 10000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		amountIn, _ := NewTokenAmount(usdc, mustUnits(1, 6))
+		amountOut, _, err := imbalanced.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		price, err := imbalanced.PriceOf(usdc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		quote, err := price.Quote(amountIn.CurrencyAmount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// USDC is scarce, so it is worth more than 1 DAI
+		if quote.Raw().Cmp(mustUnits(1, 18)) <= 0 {
+			t.Errorf("expect more than [%+v], but got[%+v]", mustUnits(1, 18), quote.Raw())
+		}
+		withoutFee := new(big.Int).Mul(amountOut.Raw(), big.NewInt(10000))
+		withoutFee.Div(withoutFee, big.NewInt(9995))
+		if new(big.Int).Sub(quote.Raw(), withoutFee).CmpAbs(big.NewInt(1e12)) > 0 {
+			t.Errorf("expect[%+v], but got[%+v]", withoutFee, quote.Raw())
+		}
+	}
+
+	// liquidity is minted like classic pairs
+	{
+		totalSupply, _ := NewTokenAmount(pair.GetLiquidityToken(), mustUnits(1000, 18))
+		amountA, _ := NewTokenAmount(usdc, mustUnits(1000, 6))
+		amountB, _ := NewTokenAmount(dai, mustUnits(1000, 18))
+		liquidity, err := pair.GetLiquidityMinted(totalSupply, amountA, amountB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if liquidity.Raw().Cmp(big.NewInt(1e18)) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", big.NewInt(1e18), liquidity.Raw())
+		}
+		value, err := pair.GetLiquidityValue(usdc, totalSupply, liquidity, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value.Raw().Cmp(mustUnits(1000, 6)) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", mustUnits(1000, 6), value.Raw())
+		}
+	}
+}
+
+func solidlyK(pair Pair) *big.Int {
+	return utils.SolidlyK(normalize(pair.Reserve0().Raw(), pair.Token0()), normalize(pair.Reserve1().Raw(), pair.Token1()))
+}
@@ -1,8 +1,8 @@
 package entities
 
 import (
-	"errors"
 	"math/big"
+	"time"
 
 	"github.com/xiang-xx/uniswap-sdk-go/constants"
 	"github.com/xiang-xx/uniswap-sdk-go/utils"
@@ -16,6 +16,43 @@ type StablePair struct {
 	multiplierB *big.Int
 	fee         *big.Int
 	feeBase     *big.Int
+	// amplification coefficient A, Ann = A * 2
+	amp *big.Int
+	// ramp of A, evaluated when the pair is quoted instead of amp
+	ramp *AmplificationRamp
+}
+
+// rampTime returns the timestamp the amplification ramps are evaluated at
+var rampTime = func() uint64 {
+	return uint64(time.Now().Unix())
+}
+
+// AmplificationRamp a linear ramp of the amplification coefficient from InitialA at InitialTime to FutureA at
+// FutureTime, as in Curve's ramp_A
+type AmplificationRamp struct {
+	InitialA    *big.Int
+	FutureA     *big.Int
+	InitialTime uint64
+	FutureTime  uint64
+}
+
+// AmplificationAt returns the amplification coefficient at timestamp, rounded towards InitialA like the contracts do
+func (r *AmplificationRamp) AmplificationAt(timestamp uint64) *big.Int {
+	if timestamp >= r.FutureTime || r.FutureTime <= r.InitialTime {
+		return r.FutureA
+	}
+	if timestamp <= r.InitialTime {
+		return r.InitialA
+	}
+
+	elapsed := new(big.Int).SetUint64(timestamp - r.InitialTime)
+	duration := new(big.Int).SetUint64(r.FutureTime - r.InitialTime)
+	if r.FutureA.Cmp(r.InitialA) > 0 {
+		delta := new(big.Int).Sub(r.FutureA, r.InitialA)
+		return new(big.Int).Add(r.InitialA, delta.Mul(delta, elapsed).Div(delta, duration))
+	}
+	delta := new(big.Int).Sub(r.InitialA, r.FutureA)
+	return new(big.Int).Sub(r.InitialA, delta.Mul(delta, elapsed).Div(delta, duration))
 }
 
 func NewStablePair(tokenAmountA, tokenAmountB *TokenAmount, multiplierA, multiplierB *big.Int) (Pair, error) {
@@ -32,6 +69,7 @@ func NewStablePair(tokenAmountA, tokenAmountB *TokenAmount, multiplierA, multipl
 		multiplierB: multiplierB,
 		fee:         constants.Three,
 		feeBase:     constants.B1000,
+		amp:         constants.DefaultAmplification,
 	}
 	pair.LiquidityToken, err = NewToken(tokenAmountA.Token.ChainID, pair.GetAddress(),
 		constants.Decimals18, constants.Univ2Symbol, constants.Univ2Name)
@@ -52,6 +90,7 @@ func NewStablePairWithFee(tokenAmountA, tokenAmountB *TokenAmount, multiplierA,
 		multiplierB: multiplierB,
 		fee:         big.NewInt(int64(fee)),
 		feeBase:     big.NewInt(int64(feeBase)),
+		amp:         constants.DefaultAmplification,
 	}
 	pair.LiquidityToken, err = NewToken(tokenAmountA.Token.ChainID, pair.GetAddress(),
 		constants.Decimals18, constants.Univ2Symbol, constants.Univ2Name)
@@ -64,6 +103,18 @@ func (p *StablePair) PairType() PairType {
 	return Stable
 }
 
+// Amplification returns the amplification coefficient A of the pair, that of its ramp at the current time if it has
+// one
+func (p *StablePair) Amplification() *big.Int {
+	if p.ramp != nil {
+		return p.ramp.AmplificationAt(rampTime())
+	}
+	if p.amp == nil {
+		return constants.DefaultAmplification
+	}
+	return p.amp
+}
+
 func (p *StablePair) Copy(tokenAmountA, tokenAmountB *TokenAmount) (Pair, error) {
 	tokenAmounts, err := NewTokenAmounts(tokenAmountA, tokenAmountB)
 	if err != nil {
@@ -75,17 +126,71 @@ func (p *StablePair) Copy(tokenAmountA, tokenAmountB *TokenAmount) (Pair, error)
 			TokenAmounts:   tokenAmounts,
 			PairAddress:    p.PairAddress,
 			LiquidityToken: p.LiquidityToken,
+			factory:        p.factory,
 		},
 		multiplierA: p.multiplierA,
 		multiplierB: p.multiplierB,
 		fee:         p.fee,
 		feeBase:     p.feeBase,
+		amp:         p.amp,
+		ramp:        p.ramp,
 	}
 	return pair, err
 }
 
+// Token0Price Returns the current mid price of the pair in terms of token0, i.e. the marginal amount of token1 per
+// token0 on the stable curve
+func (p *StablePair) Token0Price() *Price {
+	numerator, denominator, ok := p.spotPrice()
+	if !ok {
+		return p.basePair.Token0Price()
+	}
+	return NewPrice(p.Token0().Currency, p.Token1().Currency, denominator, numerator)
+}
+
+// Token1Price Returns the current mid price of the pair in terms of token1, i.e. the marginal amount of token0 per
+// token1 on the stable curve
+func (p *StablePair) Token1Price() *Price {
+	numerator, denominator, ok := p.spotPrice()
+	if !ok {
+		return p.basePair.Token1Price()
+	}
+	return NewPrice(p.Token1().Currency, p.Token0().Currency, numerator, denominator)
+}
+
+// PriceOf Returns the price of the given token in terms of the other token in the pair.
+// @param token token to return price of
+func (p *StablePair) PriceOf(token *Token) (*Price, error) {
+	if !p.InvolvesToken(token) {
+		return nil, ErrDiffToken
+	}
+
+	if token.Equals(p.Token0()) {
+		return p.Token0Price(), nil
+	}
+	return p.Token1Price(), nil
+}
+
+// spotPrice returns the raw amount of token1 per raw amount of token0 at the margin, as numerator / denominator.
+// the curve price is in adjusted units, a raw token0 is multiplierA adjusted units and a raw token1 multiplierB.
+func (p *StablePair) spotPrice() (numerator, denominator *big.Int, ok bool) {
+	_adjustedReserve0 := new(big.Int).Mul(p.Reserve0().Raw(), p.multiplierA)
+	_adjustedReserve1 := new(big.Int).Mul(p.Reserve1().Raw(), p.multiplierB)
+	if _adjustedReserve0.Sign() == 0 || _adjustedReserve1.Sign() == 0 {
+		return nil, nil, false
+	}
+	amp := p.Amplification()
+	_d := utils.ComputeDFromAdjustedBalancesWithAmp(_adjustedReserve0, _adjustedReserve1, amp)
+	numerator, denominator = utils.GetSpotPriceFromAdjustedBalancesWithAmp(_adjustedReserve0, _adjustedReserve1, _d, amp)
+	return new(big.Int).Mul(numerator, p.multiplierA), new(big.Int).Mul(denominator, p.multiplierB), true
+}
+
 // GetOutputAmount returns OutputAmount and a Pair for the InputAmout
 func (p *StablePair) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getOutputAmountAfterTax(p, inputAmount, p.getOutputAmount)
+}
+
+func (p *StablePair) getOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
 	if !p.InvolvesToken(inputAmount.Token) {
 		return nil, nil, ErrDiffToken
 	}
@@ -121,11 +226,12 @@ func (p *StablePair) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pa
 
 	_feeIn := new(big.Int).Div(new(big.Int).Mul(inputAmount.Raw(), p.fee), p.feeBase)
 	_feeDeductedAmountIn := new(big.Int).Sub(inputAmount.Raw(), _feeIn)
-	_d := utils.ComputeDFromAdjustedBalances(_adjustedReserve0, _adjustedReserve1)
+	amp := p.Amplification()
+	_d := utils.ComputeDFromAdjustedBalancesWithAmp(_adjustedReserve0, _adjustedReserve1, amp)
 	var outputAmount *big.Int
 	if inputAmount.Token.Equals(p.Token0()) {
 		_x := new(big.Int).Add(_adjustedReserve0, new(big.Int).Mul(_feeDeductedAmountIn, p.multiplierA))
-		_y := utils.GetY(_x, _d)
+		_y := utils.GetYWithAmp(_x, _d, amp)
 		outputAmount = new(big.Int).Sub(
 			new(big.Int).Sub(_adjustedReserve1, _y),
 			constants.One,
@@ -133,7 +239,7 @@ func (p *StablePair) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pa
 		outputAmount = new(big.Int).Div(outputAmount, p.multiplierB)
 	} else {
 		_x := new(big.Int).Add(_adjustedReserve1, new(big.Int).Mul(_feeDeductedAmountIn, p.multiplierB))
-		_y := utils.GetY(_x, _d)
+		_y := utils.GetYWithAmp(_x, _d, amp)
 		outputAmount = new(big.Int).Sub(
 			new(big.Int).Sub(_adjustedReserve0, _y),
 			constants.One,
@@ -167,6 +273,10 @@ func (p *StablePair) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pa
 
 // GetInputAmount returns InputAmout and a Pair for the OutputAmount
 func (p *StablePair) GetInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getInputAmountAfterTax(p, outputAmount, p.getInputAmount)
+}
+
+func (p *StablePair) getInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
 	if !p.InvolvesToken(outputAmount.Token) {
 		return nil, nil, ErrDiffToken
 	}
@@ -192,7 +302,8 @@ func (p *StablePair) GetInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pa
 
 	_adjustedReserve0 := new(big.Int).Mul(p.Reserve0().Raw(), p.multiplierA)
 	_adjustedReserve1 := new(big.Int).Mul(p.Reserve1().Raw(), p.multiplierB)
-	_d := utils.ComputeDFromAdjustedBalances(_adjustedReserve0, _adjustedReserve1)
+	amp := p.Amplification()
+	_d := utils.ComputeDFromAdjustedBalancesWithAmp(_adjustedReserve0, _adjustedReserve1, amp)
 
 	var inputAmount *big.Int
 	if outputAmount.Token.Equals(p.Token0()) {
@@ -203,7 +314,7 @@ func (p *StablePair) GetInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pa
 		if _y.Cmp(constants.One) <= 0 {
 			inputAmount = constants.One
 		} else {
-			_x := utils.GetY(_y, _d)
+			_x := utils.GetYWithAmp(_y, _d, amp)
 			inputAmount = new(big.Int).Add(constants.One,
 				new(big.Int).Div(
 					new(big.Int).Mul(p.feeBase, new(big.Int).Sub(_x, _adjustedReserve1)),
@@ -220,7 +331,7 @@ func (p *StablePair) GetInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pa
 		if _y.Cmp(constants.One) <= 0 {
 			inputAmount = constants.One
 		} else {
-			_x := utils.GetY(_y, _d)
+			_x := utils.GetYWithAmp(_y, _d, amp)
 			inputAmount = new(big.Int).Add(constants.One,
 				new(big.Int).Div(
 					new(big.Int).Mul(p.feeBase, new(big.Int).Sub(_x, _adjustedReserve0)),
@@ -251,12 +362,107 @@ func (p *StablePair) GetInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pa
 	return inputTokenAmount, pair, nil
 }
 
-// GetLiquidityMinted returns liquidity minted TokenAmount
+// GetLiquidityMinted returns liquidity minted TokenAmount, in terms of the D invariant of the adjusted reserves.
+// the first mint gets D minus MinimumLiquidity, later mints get totalSupply * (D1 - D0) / D0
 func (p *StablePair) GetLiquidityMinted(totalSupply, tokenAmountA, tokenAmountB *TokenAmount) (*TokenAmount, error) {
-	return nil, errors.New("StablePair not implement totalSupply")
+	if !p.LiquidityToken.Equals(totalSupply.Token) {
+		return nil, ErrDiffToken
+	}
+
+	tokenAmounts, err := NewTokenAmounts(tokenAmountA, tokenAmountB)
+	if err != nil {
+		return nil, err
+	}
+	if !(tokenAmounts[0].Token.Equals(p.Token0()) && tokenAmounts[1].Token.Equals(p.Token1())) {
+		return nil, ErrDiffToken
+	}
+
+	d1 := p.invariant(
+		new(big.Int).Add(p.Reserve0().Raw(), tokenAmounts[0].Raw()),
+		new(big.Int).Add(p.Reserve1().Raw(), tokenAmounts[1].Raw()),
+	)
+	var liquidity *big.Int
+	if totalSupply.Raw().Cmp(constants.Zero) == 0 {
+		liquidity = new(big.Int).Sub(d1, constants.MinimumLiquidity)
+	} else {
+		d0 := p.invariant(p.Reserve0().Raw(), p.Reserve1().Raw())
+		if d0.Cmp(constants.Zero) == 0 {
+			return nil, ErrInsufficientReserves
+		}
+		liquidity = new(big.Int).Mul(totalSupply.Raw(), new(big.Int).Sub(d1, d0))
+		liquidity.Div(liquidity, d0)
+	}
+
+	if liquidity.Cmp(constants.Zero) <= 0 {
+		return nil, ErrInsufficientInputAmount
+	}
+
+	return NewTokenAmount(p.LiquidityToken, liquidity)
 }
 
-// GetLiquidityValue returns liquidity value TokenAmount
+// GetLiquidityValue returns liquidity value TokenAmount, liquidity is burned for a proportional share of the reserves.
+// when feeOn, kLast is the D invariant of the reserves after the last liquidity event
 func (p *StablePair) GetLiquidityValue(token *Token, totalSupply, liquidity *TokenAmount, feeOn bool, kLast *big.Int) (*TokenAmount, error) {
-	return nil, errors.New("StablePair not implement GetLiquidityValue")
+	if !p.InvolvesToken(token) || !p.LiquidityToken.Equals(totalSupply.Token) || !p.LiquidityToken.Equals(liquidity.Token) {
+		return nil, ErrDiffToken
+	}
+	if liquidity.Raw().Cmp(totalSupply.Raw()) > 0 {
+		return nil, ErrInvalidLiquidity
+	}
+
+	totalSupplyAdjusted, err := p.adjustTotalSupply(totalSupply, feeOn, kLast)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenAmount, err := p.ReserveOf(token)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := big.NewInt(0).Mul(liquidity.Raw(), tokenAmount.Raw())
+	amount.Div(amount, totalSupplyAdjusted.Raw())
+	return NewTokenAmount(token, amount)
+}
+
+// adjustTotalSupply adds the protocol fee minted on the growth of D since dLast, i.e. the growth of sqrt(k) for
+// classic pairs
+func (p *StablePair) adjustTotalSupply(totalSupply *TokenAmount, feeOn bool, dLast *big.Int) (*TokenAmount, error) {
+	if !feeOn {
+		return totalSupply, nil
+	}
+
+	if dLast == nil {
+		return nil, ErrInvalidKLast
+	}
+	if dLast.Cmp(constants.Zero) == 0 {
+		return totalSupply, nil
+	}
+
+	d := p.invariant(p.Reserve0().Raw(), p.Reserve1().Raw())
+	if d.Cmp(dLast) <= 0 {
+		return totalSupply, nil
+	}
+
+	numerator := big.NewInt(0).Sub(d, dLast)
+	numerator.Mul(numerator, totalSupply.Raw())
+	denominator := big.NewInt(0).Mul(d, constants.Five)
+	denominator.Add(denominator, dLast)
+	tokenAmount, err := NewTokenAmount(p.LiquidityToken, numerator.Div(numerator, denominator))
+	if err != nil {
+		return nil, err
+	}
+	return totalSupply.Add(tokenAmount)
+}
+
+// invariant returns D of the raw reserves, zero if either reserve is zero
+func (p *StablePair) invariant(reserve0, reserve1 *big.Int) *big.Int {
+	if reserve0.Sign() == 0 || reserve1.Sign() == 0 {
+		return new(big.Int)
+	}
+	return utils.ComputeDFromAdjustedBalancesWithAmp(
+		new(big.Int).Mul(reserve0, p.multiplierA),
+		new(big.Int).Mul(reserve1, p.multiplierB),
+		p.Amplification(),
+	)
 }
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
 )
 
 func assertNil(err error) {
@@ -107,3 +109,236 @@ func TestStablePair_GetOutputAmount(t *testing.T) {
 		})
 	}
 }
+
+func TestStablePair_Price(t *testing.T) {
+	usdc, _ := NewToken(1, common.HexToAddress("0x3355df6d4c9c3035724fd0e3914de96a5a83aaf4"), 6, "USDC", "USDC")
+	usdt, _ := NewToken(1, common.HexToAddress("0x493257fd37edb34451f62edf8d2a0c418852ba4c"), 6, "USDT", "USDT")
+	tokenAmountA, _ := NewTokenAmount(usdc, big.NewInt(1372142240197))
+	tokenAmountB, _ := NewTokenAmount(usdt, big.NewInt(2953156372225))
+	multiplier := big.NewInt(1e12)
+	pair, err := NewStablePairWithFee(tokenAmountA, tokenAmountB, multiplier, multiplier, 0, 1000)
+	assertNil(err)
+
+	for _, token := range []*Token{pair.Token0(), pair.Token1()} {
+		price, err := pair.PriceOf(token)
+		assertNil(err)
+		// near peg despite the reserve ratio of about 2.15
+		if price.Raw().LessThan(NewFraction(big.NewInt(9), big.NewInt(10))) ||
+			price.Raw().GreaterThan(NewFraction(big.NewInt(11), big.NewInt(10))) {
+			t.Errorf("expect price near 1, but got[%+v]", price.ToSignificant(6))
+		}
+
+		// a small trade executes at the mid price
+		inputAmount, _ := NewTokenAmount(token, big.NewInt(1e6))
+		outputAmount, _, err := pair.GetOutputAmount(inputAmount)
+		assertNil(err)
+		quote, err := price.Quote(inputAmount.CurrencyAmount)
+		assertNil(err)
+		diff := new(big.Int).Sub(quote.Raw(), outputAmount.Raw())
+		if diff.CmpAbs(big.NewInt(10)) > 0 {
+			t.Errorf("expect[%+v], but got[%+v]", quote.Raw(), outputAmount.Raw())
+		}
+	}
+
+	if !pair.Token0Price().Invert().Raw().EqualTo(pair.Token1Price().Raw()) {
+		t.Error("token1 price should be the inverse of token0 price")
+	}
+
+	route, err := NewRoute([]Pair{pair}, usdc, nil)
+	assertNil(err)
+	expect, _ := pair.PriceOf(usdc)
+	if !route.MidPrice.Raw().EqualTo(expect.Raw()) {
+		t.Errorf("expect route mid price from the curve, but got[%+v]", route.MidPrice.ToSignificant(6))
+	}
+}
+
+// nolint funlen
+func TestStablePair_Liquidity(t *testing.T) {
+	tokenA, _ := NewToken(1, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "A", "")
+	tokenB, _ := NewToken(1, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "B", "")
+	ether := func(amount int64) *big.Int {
+		return new(big.Int).Mul(big.NewInt(amount), big.NewInt(1e18))
+	}
+	newPair := func(amountA, amountB *big.Int) Pair {
+		tokenAmountA, _ := NewTokenAmount(tokenA, amountA)
+		tokenAmountB, _ := NewTokenAmount(tokenB, amountB)
+		pair, err := NewStablePair(tokenAmountA, tokenAmountB, constants.One, constants.One)
+		assertNil(err)
+		return pair
+	}
+	liquidityAmount := func(pair Pair, amount *big.Int) *TokenAmount {
+		tokenAmount, _ := NewTokenAmount(pair.GetLiquidityToken(), amount)
+		return tokenAmount
+	}
+
+	// first mint is D minus the minimum liquidity
+	{
+		pair := newPair(big.NewInt(0), big.NewInt(0))
+		amountA, _ := NewTokenAmount(tokenA, ether(1000))
+		amountB, _ := NewTokenAmount(tokenB, ether(1000))
+		liquidity, err := pair.GetLiquidityMinted(liquidityAmount(pair, big.NewInt(0)), amountA, amountB)
+		assertNil(err)
+		expect := new(big.Int).Sub(ether(2000), constants.MinimumLiquidity)
+		if liquidity.Raw().Cmp(expect) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect, liquidity.Raw())
+		}
+	}
+
+	pair := newPair(ether(1000), ether(1200))
+	totalSupply := liquidityAmount(pair, ether(2000))
+
+	// proportional mints get a proportional share
+	{
+		amountA, _ := NewTokenAmount(tokenA, ether(100))
+		amountB, _ := NewTokenAmount(tokenB, ether(120))
+		liquidity, err := pair.GetLiquidityMinted(totalSupply, amountA, amountB)
+		assertNil(err)
+		diff := new(big.Int).Sub(liquidity.Raw(), ether(200))
+		if diff.CmpAbs(big.NewInt(1e6)) > 0 {
+			t.Errorf("expect[%+v], but got[%+v]", ether(200), liquidity.Raw())
+		}
+
+		// single sided mints are valued on the curve
+		zero, _ := NewTokenAmount(tokenB, big.NewInt(0))
+		single, err := pair.GetLiquidityMinted(totalSupply, amountA, zero)
+		assertNil(err)
+		if single.Raw().Sign() <= 0 || single.Raw().Cmp(liquidity.Raw()) >= 0 {
+			t.Errorf("expect single sided liquidity less than [%+v], but got[%+v]", liquidity.Raw(), single.Raw())
+		}
+	}
+
+	// burns return a proportional share of the reserves
+	{
+		value, err := pair.GetLiquidityValue(tokenB, totalSupply, liquidityAmount(pair, ether(500)), false, nil)
+		assertNil(err)
+		if value.Raw().Cmp(ether(300)) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", ether(300), value.Raw())
+		}
+	}
+
+	// protocol fee is minted on the growth of D
+	{
+		stablePair := pair.(*StablePair)
+		dLast := stablePair.invariant(ether(900), ether(1200))
+		value, err := pair.GetLiquidityValue(tokenB, totalSupply, liquidityAmount(pair, ether(500)), true, dLast)
+		assertNil(err)
+		if value.Raw().Cmp(ether(300)) >= 0 {
+			t.Errorf("expect less than [%+v], but got[%+v]", ether(300), value.Raw())
+		}
+		noGrowth, err := pair.GetLiquidityValue(tokenB, totalSupply, liquidityAmount(pair, ether(500)), true, stablePair.invariant(ether(1000), ether(1200)))
+		assertNil(err)
+		if noGrowth.Raw().Cmp(ether(300)) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", ether(300), noGrowth.Raw())
+		}
+		if _, err := pair.GetLiquidityValue(tokenB, totalSupply, liquidityAmount(pair, ether(500)), true, nil); err != ErrInvalidKLast {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidKLast, err)
+		}
+	}
+}
+
+// nolint funlen
+func TestStablePair_Amplification(t *testing.T) {
+	ramp := &AmplificationRamp{InitialA: big.NewInt(100), FutureA: big.NewInt(200), InitialTime: 1000, FutureTime: 2000}
+	tests := []struct {
+		timestamp uint64
+		expect    int64
+	}{
+		{0, 100},
+		{1000, 100},
+		{1500, 150},
+		{1999, 199},
+		{3000, 200},
+	}
+	for _, tt := range tests {
+		if got := ramp.AmplificationAt(tt.timestamp); got.Int64() != tt.expect {
+			t.Errorf("expect[%+v], but got[%+v]", tt.expect, got)
+		}
+	}
+	down := &AmplificationRamp{InitialA: big.NewInt(200), FutureA: big.NewInt(100), InitialTime: 1000, FutureTime: 2000}
+	if got := down.AmplificationAt(1999); got.Int64() != 101 {
+		t.Errorf("expect[%+v], but got[%+v]", 101, got)
+	}
+
+	tokenA, _ := NewToken(1, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "A", "")
+	tokenB, _ := NewToken(1, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "B", "")
+	reserve := new(big.Int).Mul(big.NewInt(1000000), big.NewInt(1e18))
+	tokenAmountA, _ := NewTokenAmount(tokenA, reserve)
+	tokenAmountB, _ := NewTokenAmount(tokenB, reserve)
+	inputAmount, _ := NewTokenAmount(tokenA, new(big.Int).Div(reserve, big.NewInt(2)))
+
+	defer func(clock func() uint64) { rampTime = clock }(rampTime)
+	rampTime = func() uint64 { return 1500 }
+
+	var prevOutput *TokenAmount
+	for _, builder := range []*PairBuilder{
+		NewPairBuilder().SetAmplificationRamp(ramp),
+		NewPairBuilder().SetAmplification(constants.DefaultAmplification),
+		NewPairBuilder().SetAmplification(big.NewInt(5000)),
+	} {
+		pair, err := builder.SetTokenAmounts(tokenAmountA, tokenAmountB).Build()
+		assertNil(err)
+		if pair.PairType() != Stable {
+			t.Fatalf("expect[%+v], but got[%+v]", Stable, pair.PairType())
+		}
+		output, nextPair, err := pair.GetOutputAmount(inputAmount)
+		assertNil(err)
+		if nextPair.(*StablePair).Amplification().Cmp(pair.(*StablePair).Amplification()) != 0 {
+			t.Error("next pair should keep the amplification")
+		}
+		// higher amplification gives less slippage
+		if prevOutput != nil && !output.GreaterThan(prevOutput.Fraction) {
+			t.Errorf("expect output more than [%+v], but got[%+v]", prevOutput.Raw(), output.Raw())
+		}
+		prevOutput = output
+	}
+
+	// the default amplification keeps the original curve
+	pair, err := NewStablePair(tokenAmountA, tokenAmountB, constants.One, constants.One)
+	assertNil(err)
+	output, _, err := pair.GetOutputAmount(inputAmount)
+	assertNil(err)
+	withDefault, _ := NewPairBuilder().SetTokenAmounts(tokenAmountA, tokenAmountB).SetAmplification(constants.DefaultAmplification).Build()
+	expect, _, err := withDefault.GetOutputAmount(inputAmount)
+	assertNil(err)
+	if !output.Equals(expect) {
+		t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), output.Raw())
+	}
+
+	if _, err := NewPairBuilder().SetTokenAmounts(tokenAmountA, tokenAmountB).SetAmplification(big.NewInt(0)).Build(); err != ErrInvalidAmplification {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidAmplification, err)
+	}
+	invalidRamp := NewPairBuilder().SetTokenAmounts(tokenAmountA, tokenAmountB).SetAmplificationRamp(&AmplificationRamp{})
+	if _, err := invalidRamp.Build(); err != ErrInvalidAmplification {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidAmplification, err)
+	}
+
+	// the ramp is evaluated when the pair is quoted, also on the pairs copied from it
+	{
+		ramped, err := NewPairBuilder().SetTokenAmounts(tokenAmountA, tokenAmountB).SetAmplificationRamp(ramp).Build()
+		assertNil(err)
+		_, next, err := ramped.GetOutputAmount(inputAmount)
+		assertNil(err)
+		copied, err := CopyWithReserves(ramped, reserve, reserve)
+		assertNil(err)
+		data, err := MarshalPair(ramped)
+		assertNil(err)
+		unmarshalled, err := UnmarshalPair(data)
+		assertNil(err)
+
+		rampTime = func() uint64 { return 2500 }
+		for _, pair := range []Pair{ramped, next, copied, unmarshalled} {
+			if got := pair.(*StablePair).Amplification(); got.Cmp(ramp.FutureA) != 0 {
+				t.Errorf("expect[%+v], but got[%+v]", ramp.FutureA, got)
+			}
+		}
+		expect, err := NewPairBuilder().SetTokenAmounts(tokenAmountA, tokenAmountB).SetAmplification(ramp.FutureA).Build()
+		assertNil(err)
+		expectOutput, _, err := expect.GetOutputAmount(inputAmount)
+		assertNil(err)
+		output, _, err := copied.GetOutputAmount(inputAmount)
+		assertNil(err)
+		if !output.Equals(expectOutput) {
+			t.Errorf("expect[%+v], but got[%+v]", expectOutput.Raw(), output.Raw())
+		}
+	}
+}
@@ -0,0 +1,525 @@
+package entities
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/utils"
+)
+
+const (
+	// StableSwapMinCoins minimum number of coins of a StableSwapPool
+	StableSwapMinCoins = 2
+	// StableSwapMaxCoins maximum number of coins of a StableSwapPool, as MAX_COINS of Curve
+	StableSwapMaxCoins = 8
+
+	// stableSwapMaxDxSteps maximum number of times the solved input is bumped when exchange gives less than the output
+	stableSwapMaxDxSteps = 16
+)
+
+var (
+	// ErrInvalidCoins a StableSwapPool holds 2 to 8 distinct coins of the same chain
+	ErrInvalidCoins = fmt.Errorf("invalid coins")
+	// ErrInvalidRates rates must be positive, one for each coin
+	ErrInvalidRates = fmt.Errorf("invalid rates")
+	// ErrInvalidFee fee must be less than, and admin fee at most, the fee denominator
+	ErrInvalidFee = fmt.Errorf("invalid fee")
+	// ErrInvalidPoolAddress a StableSwapPool is not created by a factory, so its address must be given
+	ErrInvalidPoolAddress = fmt.Errorf("invalid pool address")
+
+	StableSwap PairType = "stableswap"
+)
+
+// StableSwapPool wraps a Curve-style StableSwap pool of 2 to 8 coins, e.g. 3pool.
+// Balances are raw amounts, rates convert them to 18 decimals of precision: xp_i = balance_i * rate_i / 1e18.
+// Fee and admin fee are in units of constants.StableSwapFeeDenominator, i.e. 1e10.
+type StableSwapPool struct {
+	// the LP token of the pool, optional
+	LiquidityToken *Token
+
+	address  common.Address
+	tokens   []*Token
+	balances []*big.Int
+	rates    []*big.Int
+	// amplification coefficient A, Ann = A * n
+	amp      *big.Int
+	fee      *big.Int
+	adminFee *big.Int
+}
+
+// NewStableSwapPool creates a StableSwapPool at address, with the rates derived from the decimals of the coins
+// @param balances the balances of the coins, in the order of the coins in the pool
+// @param amp amplification coefficient A
+// @param fee swap fee in units of 1e-10, e.g. 4000000 is 0.04%
+// @param adminFee share of the swap fee taken out of the pool in units of 1e-10, e.g. 5000000000 is 50%
+func NewStableSwapPool(address common.Address, balances []*TokenAmount, amp *big.Int, fee, adminFee uint64) (*StableSwapPool, error) {
+	rates := make([]*big.Int, len(balances))
+	for i, balance := range balances {
+		// 10^(36 - decimals), i.e. 1e18 * PRECISION_MUL
+		rates[i] = new(big.Int).Exp(constants.Ten, big.NewInt(36-int64(balance.Token.Decimals)), nil)
+	}
+	return NewStableSwapPoolWithRates(address, balances, rates, amp, fee, adminFee)
+}
+
+// NewStableSwapPoolWithRates creates a StableSwapPool with custom rates, e.g. the exchange rates of lending pool
+// coins times their precision multipliers
+func NewStableSwapPoolWithRates(address common.Address, balances []*TokenAmount, rates []*big.Int, amp *big.Int,
+	fee, adminFee uint64) (*StableSwapPool, error) {
+	if address == (common.Address{}) {
+		return nil, ErrInvalidPoolAddress
+	}
+	if len(balances) < StableSwapMinCoins || len(balances) > StableSwapMaxCoins {
+		return nil, ErrInvalidCoins
+	}
+	if len(rates) != len(balances) {
+		return nil, ErrInvalidRates
+	}
+	if amp == nil || amp.Sign() <= 0 {
+		return nil, ErrInvalidAmplification
+	}
+	if fee >= constants.StableSwapFeeDenominator.Uint64() || adminFee > constants.StableSwapFeeDenominator.Uint64() {
+		return nil, ErrInvalidFee
+	}
+
+	pool := &StableSwapPool{
+		address:  address,
+		tokens:   make([]*Token, len(balances)),
+		balances: make([]*big.Int, len(balances)),
+		rates:    make([]*big.Int, len(rates)),
+		amp:      amp,
+		fee:      new(big.Int).SetUint64(fee),
+		adminFee: new(big.Int).SetUint64(adminFee),
+	}
+	for i, balance := range balances {
+		if balance.Token.ChainID != balances[0].Token.ChainID {
+			return nil, ErrDiffChainID
+		}
+		if pool.IndexOf(balance.Token) >= 0 {
+			return nil, ErrInvalidCoins
+		}
+		if rates[i] == nil || rates[i].Sign() <= 0 {
+			return nil, ErrInvalidRates
+		}
+		pool.tokens[i] = balance.Token
+		pool.balances[i] = balance.Raw()
+		pool.rates[i] = rates[i]
+	}
+	return pool, nil
+}
+
+// Address returns the address of the pool contract
+func (p *StableSwapPool) Address() common.Address {
+	return p.address
+}
+
+// ChainID returns the chain ID of the coins
+func (p *StableSwapPool) ChainID() constants.ChainID {
+	return p.tokens[0].ChainID
+}
+
+// Tokens returns the coins of the pool in pool order
+func (p *StableSwapPool) Tokens() []*Token {
+	return p.tokens
+}
+
+// Balances returns the balances of the coins in pool order
+func (p *StableSwapPool) Balances() []*TokenAmount {
+	balances := make([]*TokenAmount, len(p.tokens))
+	for i, token := range p.tokens {
+		balances[i], _ = NewTokenAmount(token, p.balances[i])
+	}
+	return balances
+}
+
+// Rates returns the rates of the coins in pool order
+func (p *StableSwapPool) Rates() []*big.Int {
+	return p.rates
+}
+
+// Amplification returns the amplification coefficient A of the pool
+func (p *StableSwapPool) Amplification() *big.Int {
+	return p.amp
+}
+
+// Fee returns the swap fee in units of 1e-10
+func (p *StableSwapPool) Fee() *big.Int {
+	return p.fee
+}
+
+// AdminFee returns the share of the swap fee taken out of the pool in units of 1e-10
+func (p *StableSwapPool) AdminFee() *big.Int {
+	return p.adminFee
+}
+
+// IndexOf returns the index of token in the pool, -1 if the pool does not hold token
+func (p *StableSwapPool) IndexOf(token *Token) int {
+	for i, t := range p.tokens {
+		if t != nil && t.Equals(token) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Invariant returns D of the balances at 18 decimals of precision
+func (p *StableSwapPool) Invariant() *big.Int {
+	return utils.ComputeDN(p.xp(), p.amp)
+}
+
+// xp returns the balances at 18 decimals of precision
+func (p *StableSwapPool) xp() []*big.Int {
+	xp := make([]*big.Int, len(p.balances))
+	for i, balance := range p.balances {
+		xp[i] = new(big.Int).Mul(balance, p.rates[i])
+		xp[i].Div(xp[i], constants.StableSwapPrecision)
+	}
+	return xp
+}
+
+func (p *StableSwapPool) checkIndexes(i, j int) error {
+	if i == j || i < 0 || j < 0 || i >= len(p.tokens) || j >= len(p.tokens) {
+		return ErrDiffToken
+	}
+	for _, x := range p.xp() {
+		if x.Sign() == 0 {
+			return ErrInsufficientReserves
+		}
+	}
+	return nil
+}
+
+// GetDy returns the amount of coin j received for dx of coin i, as get_dy of Curve pools
+func (p *StableSwapPool) GetDy(i, j int, dx *big.Int) (*big.Int, error) {
+	dy, _, err := p.exchange(i, j, dx)
+	return dy, err
+}
+
+// GetDx returns the amount of coin i needed to receive at least dy of coin j
+func (p *StableSwapPool) GetDx(i, j int, dy *big.Int) (*big.Int, error) {
+	dx, err := p.solveDx(i, j, dy)
+	if err != nil {
+		return nil, err
+	}
+	dx, _, err = p.exchangeFor(i, j, dx, dy)
+	return dx, err
+}
+
+// solveDx returns the input of coin i solved on the curve for dy of coin j, exchange may round it to a bit less than dy
+func (p *StableSwapPool) solveDx(i, j int, dy *big.Int) (*big.Int, error) {
+	if err := p.checkIndexes(i, j); err != nil {
+		return nil, err
+	}
+	if dy.Cmp(p.balances[j]) >= 0 {
+		return nil, ErrInsufficientReserves
+	}
+
+	xp := p.xp()
+	// the output at 18 decimals before the fee is deducted, rounded up
+	dyXp := ceilDiv(new(big.Int).Mul(dy, p.rates[j]), constants.StableSwapPrecision)
+	dyXp = ceilDiv(dyXp.Mul(dyXp, constants.StableSwapFeeDenominator), new(big.Int).Sub(constants.StableSwapFeeDenominator, p.fee))
+	// exchange keeps 1 wei of the output in the pool
+	y := new(big.Int).Sub(xp[j], dyXp)
+	y.Sub(y, constants.One)
+	if y.Sign() <= 0 {
+		return nil, ErrInsufficientReserves
+	}
+	x := utils.GetYN(j, i, y, xp, p.amp)
+	dx := ceilDiv(new(big.Int).Mul(new(big.Int).Sub(x, xp[i]), constants.StableSwapPrecision), p.rates[i])
+	return dx.Add(dx, constants.One), nil
+}
+
+// exchangeFor bumps dx until exchange gives at least dy, and returns it with the balances after the exchange
+func (p *StableSwapPool) exchangeFor(i, j int, dx, dy *big.Int) (*big.Int, []*big.Int, error) {
+	dx = new(big.Int).Set(dx)
+	for step := 0; step < stableSwapMaxDxSteps; step++ {
+		got, balances, err := p.exchange(i, j, dx)
+		switch {
+		case err == ErrInsufficientInputAmount:
+			got = new(big.Int)
+		case err != nil:
+			return nil, nil, err
+		case got.Cmp(dy) >= 0:
+			return dx, balances, nil
+		}
+		// the shortfall in coin i before the fee, plus a unit for the rounding
+		short := new(big.Int).Mul(new(big.Int).Sub(dy, got), p.rates[j])
+		short.Mul(short, constants.StableSwapFeeDenominator)
+		short = ceilDiv(short, new(big.Int).Mul(p.rates[i], new(big.Int).Sub(constants.StableSwapFeeDenominator, p.fee)))
+		dx.Add(dx, short.Add(short, constants.One))
+	}
+	return nil, nil, ErrInsufficientReserves
+}
+
+// exchange returns the amount of coin j received for dx of coin i and the balances after the exchange, as exchange
+// of Curve pools. the admin fee leaves the pool.
+func (p *StableSwapPool) exchange(i, j int, dx *big.Int) (*big.Int, []*big.Int, error) {
+	if err := p.checkIndexes(i, j); err != nil {
+		return nil, nil, err
+	}
+	if dx.Sign() == 0 {
+		return new(big.Int), p.balances, nil
+	}
+
+	xp := p.xp()
+	x := new(big.Int).Mul(dx, p.rates[i])
+	x.Div(x, constants.StableSwapPrecision).Add(x, xp[i])
+	y := utils.GetYN(i, j, x, xp, p.amp)
+
+	// -1 just in case there were some rounding errors
+	dyXp := new(big.Int).Sub(xp[j], y)
+	dyXp.Sub(dyXp, constants.One)
+	if dyXp.Sign() <= 0 {
+		return nil, nil, ErrInsufficientInputAmount
+	}
+	dyFee := new(big.Int).Mul(dyXp, p.fee)
+	dyFee.Div(dyFee, constants.StableSwapFeeDenominator)
+	dyAdminFee := new(big.Int).Mul(dyFee, p.adminFee)
+	dyAdminFee.Div(dyAdminFee, constants.StableSwapFeeDenominator)
+
+	dy := new(big.Int).Sub(dyXp, dyFee)
+	dy.Mul(dy, constants.StableSwapPrecision).Div(dy, p.rates[j])
+	dyAdminFee.Mul(dyAdminFee, constants.StableSwapPrecision).Div(dyAdminFee, p.rates[j])
+	if dy.Sign() == 0 {
+		return nil, nil, ErrInsufficientInputAmount
+	}
+
+	balances := make([]*big.Int, len(p.balances))
+	copy(balances, p.balances)
+	balances[i] = new(big.Int).Add(balances[i], dx)
+	balances[j] = new(big.Int).Sub(balances[j], new(big.Int).Add(dy, dyAdminFee))
+	if balances[j].Sign() <= 0 {
+		return nil, nil, ErrInsufficientReserves
+	}
+	return dy, balances, nil
+}
+
+// GetOutputAmount returns the amount of tokenOut received for inputAmount, and the pool after the exchange
+func (p *StableSwapPool) GetOutputAmount(inputAmount *TokenAmount, tokenOut *Token) (*TokenAmount, *StableSwapPool, error) {
+	dy, balances, err := p.exchange(p.IndexOf(inputAmount.Token), p.IndexOf(tokenOut), inputAmount.Raw())
+	if err != nil {
+		return nil, nil, err
+	}
+	outputAmount, err := NewTokenAmount(tokenOut, dy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outputAmount, p.withBalances(balances), nil
+}
+
+// GetInputAmount returns the amount of tokenIn needed to receive outputAmount, and the pool after the exchange
+func (p *StableSwapPool) GetInputAmount(outputAmount *TokenAmount, tokenIn *Token) (*TokenAmount, *StableSwapPool, error) {
+	i, j := p.IndexOf(tokenIn), p.IndexOf(outputAmount.Token)
+	dx, err := p.solveDx(i, j, outputAmount.Raw())
+	if err != nil {
+		return nil, nil, err
+	}
+	// the pool after the exchange is of the input checked to give the output
+	dx, balances, err := p.exchangeFor(i, j, dx, outputAmount.Raw())
+	if err != nil {
+		return nil, nil, err
+	}
+	inputAmount, err := NewTokenAmount(tokenIn, dx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inputAmount, p.withBalances(balances), nil
+}
+
+func (p *StableSwapPool) withBalances(balances []*big.Int) *StableSwapPool {
+	pool := *p
+	pool.balances = balances
+	return &pool
+}
+
+// Pair returns the Pair of the coins tokenA and tokenB of the pool
+func (p *StableSwapPool) Pair(tokenA, tokenB *Token) (Pair, error) {
+	i, j := p.IndexOf(tokenA), p.IndexOf(tokenB)
+	if i < 0 || j < 0 {
+		return nil, ErrDiffToken
+	}
+	if i == j {
+		return nil, ErrSameAddrss
+	}
+	return p.pair(i, j), nil
+}
+
+// Pairs returns the Pairs of every two coins of the pool, so that route search can go through the pool
+func (p *StableSwapPool) Pairs() []Pair {
+	pairs := make([]Pair, 0, len(p.tokens)*(len(p.tokens)-1)/2)
+	for i := range p.tokens {
+		for j := i + 1; j < len(p.tokens); j++ {
+			pairs = append(pairs, p.pair(i, j))
+		}
+	}
+	return pairs
+}
+
+func (p *StableSwapPool) pair(i, j int) *StableSwapPair {
+	tokenAmountI, _ := NewTokenAmount(p.tokens[i], p.balances[i])
+	tokenAmountJ, _ := NewTokenAmount(p.tokens[j], p.balances[j])
+	// coins of a pool are distinct, so they sort
+	tokenAmounts, _ := NewTokenAmounts(tokenAmountI, tokenAmountJ)
+	if !tokenAmounts[0].Token.Equals(p.tokens[i]) {
+		i, j = j, i
+	}
+	return &StableSwapPair{
+		basePair: basePair{
+			LiquidityToken: p.LiquidityToken,
+			TokenAmounts:   tokenAmounts,
+			PairAddress:    p.address,
+		},
+		pool:   p,
+		index0: i,
+		index1: j,
+	}
+}
+
+// StableSwapPair exposes two coins of a StableSwapPool as a Pair.
+// All the pairs of a pool have the address of the pool, and exchanging through one changes the state of the others.
+type StableSwapPair struct {
+	basePair
+
+	pool *StableSwapPool
+	// indexes of token0 and token1 in the pool
+	index0 int
+	index1 int
+}
+
+/**** stableswap pair *****/
+
+func (p *StableSwapPair) PairType() PairType {
+	return StableSwap
+}
+
+// Pool returns the pool of the pair
+func (p *StableSwapPair) Pool() *StableSwapPool {
+	return p.pool
+}
+
+// Equal returns true for the pairs of the same pool, since they share the pool state, pairs of other pools of the
+// same coins are different pairs
+func (p *StableSwapPair) Equal(p1 Pair) bool {
+	if other, ok := p1.(*StableSwapPair); ok {
+		return p.pool.address == other.pool.address
+	}
+	return p.GetAddress() == p1.GetAddress()
+}
+
+// Token0Price Returns the current mid price of the pair in terms of token0, i.e. the marginal amount of token1 per
+// token0 in the pool, excluding the fee
+func (p *StableSwapPair) Token0Price() *Price {
+	numerator, denominator, ok := p.spotPrice()
+	if !ok {
+		return p.basePair.Token0Price()
+	}
+	return NewPrice(p.Token0().Currency, p.Token1().Currency, denominator, numerator)
+}
+
+// Token1Price Returns the current mid price of the pair in terms of token1, i.e. the marginal amount of token0 per
+// token1 in the pool, excluding the fee
+func (p *StableSwapPair) Token1Price() *Price {
+	numerator, denominator, ok := p.spotPrice()
+	if !ok {
+		return p.basePair.Token1Price()
+	}
+	return NewPrice(p.Token1().Currency, p.Token0().Currency, numerator, denominator)
+}
+
+// PriceOf Returns the price of the given token in terms of the other token in the pair.
+// @param token token to return price of
+func (p *StableSwapPair) PriceOf(token *Token) (*Price, error) {
+	if !p.InvolvesToken(token) {
+		return nil, ErrDiffToken
+	}
+
+	if token.Equals(p.Token0()) {
+		return p.Token0Price(), nil
+	}
+	return p.Token1Price(), nil
+}
+
+// spotPrice returns the raw amount of token1 per raw amount of token0 at the margin, as numerator / denominator.
+// the curve price is at 18 decimals of precision, a raw token0 is rate0 / 1e18 of it and a raw token1 rate1 / 1e18.
+func (p *StableSwapPair) spotPrice() (numerator, denominator *big.Int, ok bool) {
+	xp := p.pool.xp()
+	for _, x := range xp {
+		if x.Sign() == 0 {
+			return nil, nil, false
+		}
+	}
+	d := utils.ComputeDN(xp, p.pool.amp)
+	numerator, denominator = utils.GetSpotPriceN(p.index0, p.index1, xp, d, p.pool.amp)
+	return numerator.Mul(numerator, p.pool.rates[p.index0]), denominator.Mul(denominator, p.pool.rates[p.index1]), true
+}
+
+// GetOutputAmount returns OutputAmount and a Pair for the InputAmout
+func (p *StableSwapPair) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getOutputAmountAfterTax(p, inputAmount, p.getOutputAmount)
+}
+
+func (p *StableSwapPair) getOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	if !p.InvolvesToken(inputAmount.Token) {
+		return nil, nil, ErrDiffToken
+	}
+	outputAmount, pool, err := p.pool.GetOutputAmount(inputAmount, p.other(inputAmount.Token))
+	if err != nil {
+		return nil, nil, err
+	}
+	return outputAmount, pool.pair(p.index0, p.index1), nil
+}
+
+// GetInputAmount returns InputAmout and a Pair for the OutputAmount
+func (p *StableSwapPair) GetInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getInputAmountAfterTax(p, outputAmount, p.getInputAmount)
+}
+
+func (p *StableSwapPair) getInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	if !p.InvolvesToken(outputAmount.Token) {
+		return nil, nil, ErrDiffToken
+	}
+	inputAmount, pool, err := p.pool.GetInputAmount(outputAmount, p.other(outputAmount.Token))
+	if err != nil {
+		return nil, nil, err
+	}
+	return inputAmount, pool.pair(p.index0, p.index1), nil
+}
+
+func (p *StableSwapPair) other(token *Token) *Token {
+	if token.Equals(p.Token0()) {
+		return p.Token1()
+	}
+	return p.Token0()
+}
+
+// GetLiquidityMinted liquidity of a pool is added in all of its coins, not in a pair of them
+func (p *StableSwapPair) GetLiquidityMinted(totalSupply, tokenAmountA, tokenAmountB *TokenAmount) (*TokenAmount, error) {
+	return nil, ErrNotImplemented
+}
+
+// GetLiquidityValue liquidity of a pool is removed in all of its coins, not in a pair of them
+func (p *StableSwapPair) GetLiquidityValue(token *Token, totalSupply, liquidity *TokenAmount, feeOn bool, kLast *big.Int) (*TokenAmount, error) {
+	return nil, ErrNotImplemented
+}
+
+// pairOfState returns state as the pair of the same coins as pair, for the pairs sharing the state of a pool
+func pairOfState(pair, state Pair) Pair {
+	target, ok := pair.(*StableSwapPair)
+	if !ok {
+		return state
+	}
+	if source, ok := state.(*StableSwapPair); ok && source.pool.address == target.pool.address {
+		return source.pool.pair(target.index0, target.index1)
+	}
+	return state
+}
+
+func ceilDiv(x, y *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(x, y, new(big.Int))
+	if remainder.Sign() > 0 {
+		quotient.Add(quotient, constants.One)
+	}
+	return quotient
+}
@@ -0,0 +1,295 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+func mustUnits(amount int64, decimals int) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), new(big.Int).Exp(constants.Ten, big.NewInt(int64(decimals)), nil))
+}
+
+// newThreePool creates a 3pool-style pool of DAI, USDC and USDT
+func newThreePool(t *testing.T, dai, usdc, usdt int64) (*StableSwapPool, []*Token) {
+	t.Helper()
+	tokens := []*Token{
+		{Currency: &Currency{Decimals: 18, Symbol: "DAI"}, ChainID: constants.Mainnet,
+			Address: common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")},
+		{Currency: &Currency{Decimals: 6, Symbol: "USDC"}, ChainID: constants.Mainnet,
+			Address: common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")},
+		{Currency: &Currency{Decimals: 6, Symbol: "USDT"}, ChainID: constants.Mainnet,
+			Address: common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")},
+	}
+	balances := make([]*TokenAmount, len(tokens))
+	for i, amount := range []int64{dai, usdc, usdt} {
+		balances[i], _ = NewTokenAmount(tokens[i], mustUnits(amount, int(tokens[i].Decimals)))
+	}
+	pool, err := NewStableSwapPool(common.HexToAddress("0xbEbc44782C7dB0a1A60Cb6fe97d0b483032FF1C7"), balances,
+		big.NewInt(2000), 4000000, 5000000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool, tokens
+}
+
+// nolint funlen
+func TestStableSwapPool(t *testing.T) {
+	pool, tokens := newThreePool(t, 100000000, 100000000, 100000000)
+	dai, usdc, usdt := tokens[0], tokens[1], tokens[2]
+
+	// a balanced pool swaps close to 1:1, minus the 0.04% fee
+	{
+		dy, err := pool.GetDy(0, 1, mustUnits(1000, 18))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dy.Cmp(mustUnits(999, 6)) <= 0 || dy.Cmp(big.NewInt(999600000)) > 0 {
+			t.Errorf("expect about [%+v], but got[%+v]", 999600000, dy)
+		}
+	}
+
+	// the admin fee leaves the pool, the rest of the fee stays
+	{
+		amountIn, _ := NewTokenAmount(usdc, mustUnits(1000000, 6))
+		amountOut, next, err := pool.GetOutputAmount(amountIn, usdt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		balances := next.Balances()
+		if balances[1].Raw().Cmp(mustUnits(101000000, 6)) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", mustUnits(101000000, 6), balances[1].Raw())
+		}
+		removed := new(big.Int).Sub(mustUnits(100000000, 6), balances[2].Raw())
+		if removed.Cmp(amountOut.Raw()) <= 0 {
+			t.Errorf("expect more than [%+v] removed, but got[%+v]", amountOut.Raw(), removed)
+		}
+		if next.Invariant().Cmp(pool.Invariant()) <= 0 {
+			t.Error("fee should increase the invariant")
+		}
+		// the original pool is untouched
+		if pool.Balances()[1].Raw().Cmp(mustUnits(100000000, 6)) != 0 {
+			t.Error("exchange should not change the pool")
+		}
+	}
+
+	// GetDx is the least input giving the output
+	for _, indexes := range [][2]int{{0, 1}, {1, 0}, {1, 2}, {2, 0}} {
+		i, j := indexes[0], indexes[1]
+		dy := mustUnits(250000, int(tokens[j].Decimals))
+		dx, err := pool.GetDx(i, j, dy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := pool.GetDy(i, j, dx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(dy) < 0 {
+			t.Errorf("%d -> %d: expect at least [%+v], but got[%+v]", i, j, dy, got)
+		}
+		// at most a few units of the input token too much
+		slack := new(big.Int).Exp(constants.Ten, big.NewInt(int64(tokens[i].Decimals)-3), nil)
+		less, _ := pool.GetDy(i, j, new(big.Int).Sub(dx, slack))
+		if less.Cmp(dy) >= 0 {
+			t.Errorf("%d -> %d: input [%+v] is not the least", i, j, dx)
+		}
+	}
+
+	// an input short of the output is bumped until exchange gives the output
+	{
+		dy := mustUnits(250000, 6)
+		dx, err := pool.GetDx(0, 1, dy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bumped, balances, err := pool.exchangeFor(0, 1, new(big.Int).Div(dx, big.NewInt(2)), dy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, expect, _ := pool.exchange(0, 1, bumped)
+		if got.Cmp(dy) < 0 {
+			t.Errorf("expect at least [%+v], but got[%+v]", dy, got)
+		}
+		if balances[1].Cmp(expect[1]) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect[1], balances[1])
+		}
+		slack := mustUnits(1, 16)
+		if bumped.Cmp(dx) < 0 || new(big.Int).Sub(bumped, dx).Cmp(slack) > 0 {
+			t.Errorf("expect about [%+v], but got[%+v]", dx, bumped)
+		}
+	}
+
+	// a two coin pool without fee agrees with StablePair
+	{
+		usdx, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "USDX", "")
+		tokenAmountA, _ := NewTokenAmount(dai, mustEther(1200000))
+		tokenAmountB, _ := NewTokenAmount(usdx, mustEther(900000))
+		twoPool, err := NewStableSwapPool(common.HexToAddress("0x01"), []*TokenAmount{tokenAmountA, tokenAmountB},
+			big.NewInt(100), 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stablePair, err := NewPairBuilder().SetTokenAmounts(tokenAmountA, tokenAmountB).
+			SetFee(0, 10000).SetAmplification(big.NewInt(100)).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		amountIn, _ := NewTokenAmount(dai, mustEther(50000))
+		expect, _, err := stablePair.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := twoPool.GetOutputAmount(amountIn, tokenAmountB.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Raw().Cmp(expect.Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), got.Raw())
+		}
+	}
+
+	// invalid pools
+	{
+		balances := pool.Balances()
+		address := pool.Address()
+		if _, err := NewStableSwapPool(address, balances[:1], big.NewInt(100), 0, 0); err != ErrInvalidCoins {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidCoins, err)
+		}
+		if _, err := NewStableSwapPool(address, []*TokenAmount{balances[0], balances[1], balances[0]}, big.NewInt(100), 0, 0); err != ErrInvalidCoins {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidCoins, err)
+		}
+		if _, err := NewStableSwapPool(address, balances, big.NewInt(0), 0, 0); err != ErrInvalidAmplification {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidAmplification, err)
+		}
+		if _, err := NewStableSwapPool(address, balances, big.NewInt(100), 1e10, 0); err != ErrInvalidFee {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidFee, err)
+		}
+		if _, err := NewStableSwapPool(common.Address{}, balances, big.NewInt(100), 0, 0); err != ErrInvalidPoolAddress {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidPoolAddress, err)
+		}
+		if _, err := pool.Pair(usdc, usdc); err != ErrSameAddrss {
+			t.Errorf("expect[%+v], but got[%+v]", ErrSameAddrss, err)
+		}
+	}
+}
+
+// nolint funlen
+func TestStableSwapPair(t *testing.T) {
+	pool, tokens := newThreePool(t, 120000000, 100000000, 80000000)
+	dai, usdc, usdt := tokens[0], tokens[1], tokens[2]
+
+	pairs := pool.Pairs()
+	if len(pairs) != 3 {
+		t.Fatalf("expect[%+v], but got[%+v]", 3, len(pairs))
+	}
+	for _, pair := range pairs {
+		if pair.GetAddress() != pool.Address() || pair.PairType() != StableSwap {
+			t.Error("pairs should be at the pool address")
+		}
+		if !pair.Equal(pairs[0]) {
+			t.Error("pairs of a pool should be equal")
+		}
+	}
+	classic, _ := NewPair(pairs[0].Reserve0(), pairs[0].Reserve1())
+	if pairs[0].Equal(classic) {
+		t.Error("pairs of the pool and v2 pairs of the same coins should be different pairs")
+	}
+
+	// the mid price is the price of a small trade, before the fee
+	daiUsdt, _ := pool.Pair(usdt, dai)
+	{
+		amountIn, _ := NewTokenAmount(dai, mustUnits(1, 18))
+		amountOut, _, err := daiUsdt.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		price, err := daiUsdt.PriceOf(dai)
+		if err != nil {
+			t.Fatal(err)
+		}
+		quote, err := price.Quote(amountIn.CurrencyAmount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// DAI is the most abundant, so it is worth less than 1 USDT
+		if quote.Raw().Cmp(mustUnits(1, 6)) >= 0 {
+			t.Errorf("expect less than [%+v], but got[%+v]", mustUnits(1, 6), quote.Raw())
+		}
+		withoutFee := new(big.Int).Mul(amountOut.Raw(), big.NewInt(10000))
+		withoutFee.Div(withoutFee, big.NewInt(9996))
+		if new(big.Int).Sub(quote.Raw(), withoutFee).CmpAbs(big.NewInt(2)) > 0 {
+			t.Errorf("expect[%+v], but got[%+v]", withoutFee, quote.Raw())
+		}
+		inverse, _ := daiUsdt.PriceOf(usdt)
+		if !inverse.Raw().EqualTo(price.Invert().Raw()) {
+			t.Error("prices should be inverse")
+		}
+	}
+
+	// exchanging through one pair moves the pool state of the others
+	{
+		amountIn, _ := NewTokenAmount(usdc, mustUnits(5000000, 6))
+		usdcUsdt, _ := pool.Pair(usdc, usdt)
+		_, next, err := usdcUsdt.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		states := pairStates{}
+		states[next.GetAddress()] = next
+		state := pairOfState(daiUsdt, states[daiUsdt.GetAddress()])
+		if !state.Token0().Equals(daiUsdt.Token0()) || !state.Token1().Equals(daiUsdt.Token1()) {
+			t.Error("state should be retargeted to the coins of the pair")
+		}
+		if state.Reserve0().Raw().Cmp(daiUsdt.Reserve0().Raw()) == 0 && state.Reserve1().Raw().Cmp(daiUsdt.Reserve1().Raw()) == 0 {
+			t.Error("state should have the reserves after the exchange")
+		}
+
+		graph := NewPairGraph(pairs)
+		if !graph.UpdatePair(next) {
+			t.Fatal("pool should be in the graph")
+		}
+		for _, pair := range graph.PairsOf(dai) {
+			if pair.(*StableSwapPair).Pool() != next.(*StableSwapPair).Pool() {
+				t.Error("every pair of the pool should be updated")
+			}
+			if !pair.InvolvesToken(dai) {
+				t.Error("pairs should keep their coins")
+			}
+		}
+	}
+
+	// routes go through the pool
+	{
+		weth := WETH[constants.Mainnet]
+		wethDai := mustPair(weth, mustEther(1000), dai, mustEther(2000000))
+		amountIn, _ := NewTokenAmount(weth, mustEther(1))
+		trades, err := BestTradeExactIn(append([]Pair{wethDai}, pairs...), amountIn, usdc, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) == 0 || trades[0].Route.Pairs[1].GetAddress() != pool.Address() {
+			t.Fatal("route should go through the pool")
+		}
+
+		amountOut, _ := NewTokenAmount(usdc, mustUnits(1000, 6))
+		trades, err = BestTradeExactOut(append([]Pair{wethDai}, pairs...), weth, amountOut, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) == 0 || trades[0].Route.Pairs[1].GetAddress() != pool.Address() {
+			t.Fatal("exact output route should go through the pool")
+		}
+		// the input is enough for the output
+		if got := routeOutput(trades[0].Route, trades[0].InputAmount().Raw()); got.Cmp(amountOut.Raw()) < 0 {
+			t.Errorf("expect at least [%+v], but got[%+v]", amountOut.Raw(), got)
+		}
+	}
+
+	if _, err := daiUsdt.GetLiquidityMinted(nil, nil, nil); err != ErrNotImplemented {
+		t.Errorf("expect[%+v], but got[%+v]", ErrNotImplemented, err)
+	}
+}
@@ -74,6 +74,18 @@ func TestPair(t *testing.T) {
 		}
 	}
 
+	// factories at the same address with other init code hashes create other pairs
+	{
+		factory := NewFactory(UniswapV2Factory.Address, common.FromHex("0x01"), "", "", 3, 1000)
+		output := _PairAddressCache.GetFactoryAddress(factory, DAI.Address, USDC.Address)
+		if output == _PairAddressCache.GetAddress(DAI.Address, USDC.Address) {
+			t.Errorf("expect other than[%+v], but got[%+v]", _PairAddressCache.GetAddress(DAI.Address, USDC.Address), output)
+		}
+		if output != getCreate2Address(factory, DAI.Address, USDC.Address) {
+			t.Errorf("expect[%+v], but got[%+v]", getCreate2Address(factory, DAI.Address, USDC.Address), output)
+		}
+	}
+
 	{
 		pairA, _ := NewPair(tokenAmountUSDC, tokenAmountDAI)
 		pairB, _ := NewPair(tokenAmountDAI, tokenAmountUSDC)
@@ -375,3 +387,67 @@ func TestPair(t *testing.T) {
 		}
 	}
 }
+
+func TestFactory(t *testing.T) {
+	pancakeFactory := NewFactory(common.HexToAddress("0xcA143Ce32Fe78f1f7019d7d551a6402fC5350c73"),
+		common.FromHex("0x00fb7f630766e6a796048ea87d01acd3068e8ff67d078148a3fa3f4a84f69bd5"), "Cake-LP", "Pancake LPs", 25, 10000)
+	WBNB, _ := NewToken(56, common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c"), 18, "WBNB", "Wrapped BNB")
+	BUSD, _ := NewToken(56, common.HexToAddress("0xe9e7CEA3DedcA5984780Bafc599bD69ADd087D56"), 18, "BUSD", "BUSD Token")
+	USDC, _ := NewToken(constants.Mainnet, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "USD Coin")
+
+	// returns the correct address for each factory
+	{
+		output, err := pancakeFactory.GetPairAddress(BUSD, WBNB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := "0x58F876857a02D6762E0101bb5C46A8c1ED44Dc16"
+		if output.String() != expect {
+			t.Errorf("expect[%+v], but got[%+v]", expect, output)
+		}
+
+		output, err = UniswapV2Factory.GetPairAddress(WETH[constants.Mainnet], USDC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect = "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+		if output.String() != expect {
+			t.Errorf("expect[%+v], but got[%+v]", expect, output)
+		}
+	}
+
+	// pairs built with a factory use its address, liquidity token and default fee
+	{
+		tokenAmountWBNB, _ := NewTokenAmount(WBNB, big.NewInt(10000))
+		tokenAmountBUSD, _ := NewTokenAmount(BUSD, big.NewInt(10000))
+		pair, err := NewPairBuilder().SetTokenAmounts(tokenAmountWBNB, tokenAmountBUSD).SetFactory(pancakeFactory).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := "0x58F876857a02D6762E0101bb5C46A8c1ED44Dc16"
+		if pair.GetAddress().String() != expect {
+			t.Errorf("expect[%+v], but got[%+v]", expect, pair.GetAddress())
+		}
+		if pair.GetLiquidityToken().Symbol != "Cake-LP" || pair.GetLiquidityToken().Address != pair.GetAddress() {
+			t.Errorf("wrong liquidity token %+v", pair.GetLiquidityToken())
+		}
+
+		inputAmount, _ := NewTokenAmount(WBNB, big.NewInt(100))
+		outputAmount, _, err := pair.GetOutputAmount(inputAmount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 100 * 9975 * 10000 / (10000 * 10000 + 100 * 9975)
+		if outputAmount.Raw().Cmp(big.NewInt(98)) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", 98, outputAmount.Raw())
+		}
+
+		pairFromFactory, err := NewPairWithFactory(tokenAmountBUSD, tokenAmountWBNB, pancakeFactory)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pairFromFactory.GetAddress() != pair.GetAddress() {
+			t.Errorf("expect[%+v], but got[%+v]", pair.GetAddress(), pairFromFactory.GetAddress())
+		}
+	}
+}
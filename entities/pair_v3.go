@@ -0,0 +1,479 @@
+package entities
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/utils"
+)
+
+// FeeAmount the fee tier of a v3 pool, in hundredths of a bip, i.e. 1e-6
+type FeeAmount uint32
+
+const (
+	FeeLowest FeeAmount = 100
+	FeeLow    FeeAmount = 500
+	FeeMedium FeeAmount = 3000
+	FeeHigh   FeeAmount = 10000
+)
+
+var (
+	// TickSpacings the default factory tick spacings by fee amount
+	TickSpacings = map[FeeAmount]int{
+		FeeLowest: 1,
+		FeeLow:    10,
+		FeeMedium: 60,
+		FeeHigh:   200,
+	}
+
+	ErrInvalidTickSpacing = errors.New("invalid tick spacing")
+	ErrInvalidTicks       = errors.New("invalid ticks")
+	ErrInvalidSqrtPrice   = errors.New("invalid sqrt price")
+)
+
+// Tick an initialized tick of a v3 pool
+type Tick struct {
+	Index          int
+	LiquidityGross *big.Int
+	LiquidityNet   *big.Int
+}
+
+// V3Pool wraps uniswap v3 concentrated liquidity pool
+type V3Pool struct {
+	basePair
+
+	fee          FeeAmount
+	tickSpacing  int
+	sqrtPriceX96 *big.Int
+	liquidity    *big.Int
+	tickCurrent  int
+	// sorted by index
+	ticks []Tick
+}
+
+// NewV3Pool creates a v3 pool
+// tokenAmountA and tokenAmountB are the token balances held by the pool, ticks are all the initialized ticks
+func NewV3Pool(tokenAmountA, tokenAmountB *TokenAmount, fee FeeAmount, sqrtPriceX96, liquidity *big.Int, tickCurrent int,
+	ticks []Tick) (Pair, error) {
+	tickSpacing, ok := TickSpacings[fee]
+	if !ok {
+		return nil, ErrInvalidTickSpacing
+	}
+	return NewV3PoolWithTickSpacing(tokenAmountA, tokenAmountB, fee, tickSpacing, sqrtPriceX96, liquidity, tickCurrent, ticks)
+}
+
+// NewV3PoolWithTickSpacing creates a v3 pool with a custom tick spacing
+func NewV3PoolWithTickSpacing(tokenAmountA, tokenAmountB *TokenAmount, fee FeeAmount, tickSpacing int, sqrtPriceX96, liquidity *big.Int,
+	tickCurrent int, ticks []Tick) (Pair, error) {
+	if fee >= 1000000 || tickSpacing <= 0 {
+		return nil, ErrInvalidTickSpacing
+	}
+	tokenAmounts, err := NewTokenAmounts(tokenAmountA, tokenAmountB)
+	if err != nil {
+		return nil, err
+	}
+
+	tickCurrentSqrtRatioX96, err := utils.GetSqrtRatioAtTick(tickCurrent)
+	if err != nil {
+		return nil, err
+	}
+	nextTickSqrtRatioX96, err := utils.GetSqrtRatioAtTick(tickCurrent + 1)
+	if err != nil {
+		return nil, err
+	}
+	if sqrtPriceX96.Cmp(tickCurrentSqrtRatioX96) < 0 || sqrtPriceX96.Cmp(nextTickSqrtRatioX96) > 0 {
+		return nil, ErrInvalidSqrtPrice
+	}
+
+	sortedTicks := make([]Tick, len(ticks))
+	copy(sortedTicks, ticks)
+	sort.Slice(sortedTicks, func(i, j int) bool { return sortedTicks[i].Index < sortedTicks[j].Index })
+	if err := validateTicks(sortedTicks, tickSpacing); err != nil {
+		return nil, err
+	}
+
+	return &V3Pool{
+		basePair: basePair{
+			TokenAmounts: tokenAmounts,
+		},
+		fee:          fee,
+		tickSpacing:  tickSpacing,
+		sqrtPriceX96: sqrtPriceX96,
+		liquidity:    liquidity,
+		tickCurrent:  tickCurrent,
+		ticks:        sortedTicks,
+	}, nil
+}
+
+func validateTicks(ticks []Tick, tickSpacing int) error {
+	sum := new(big.Int)
+	for i, tick := range ticks {
+		if tick.Index%tickSpacing != 0 || tick.Index < constants.MinTick || tick.Index > constants.MaxTick {
+			return ErrInvalidTicks
+		}
+		if i > 0 && ticks[i-1].Index == tick.Index {
+			return ErrInvalidTicks
+		}
+		sum.Add(sum, tick.LiquidityNet)
+	}
+	if sum.Sign() != 0 {
+		return ErrInvalidTicks
+	}
+	return nil
+}
+
+/**** v3 pool *****/
+
+func (p *V3Pool) PairType() PairType {
+	return V3
+}
+
+// Fee returns the fee tier of the pool
+func (p *V3Pool) Fee() FeeAmount {
+	return p.fee
+}
+
+// SqrtPriceX96 returns the current sqrt price as a Q64.96
+func (p *V3Pool) SqrtPriceX96() *big.Int {
+	return p.sqrtPriceX96
+}
+
+// Liquidity returns the current in range liquidity
+func (p *V3Pool) Liquidity() *big.Int {
+	return p.liquidity
+}
+
+// TickCurrent returns the current tick
+func (p *V3Pool) TickCurrent() int {
+	return p.tickCurrent
+}
+
+// Ticks returns the initialized ticks sorted by index
+func (p *V3Pool) Ticks() []Tick {
+	return p.ticks
+}
+
+// GetAddress returns the v3 pool address created by the v3 factory
+func (p *V3Pool) GetAddress() common.Address {
+	if p.PairAddress != (common.Address{}) {
+		return p.PairAddress
+	}
+	return getV3PoolAddress(p.Token0().Address, p.Token1().Address, p.fee)
+}
+
+// Equal returns true for the pairs of the same pool, the pools of the fee tiers of the same tokens are different pairs
+func (p *V3Pool) Equal(p1 Pair) bool {
+	return p.GetAddress() == p1.GetAddress()
+}
+
+var v3PoolSaltArguments = abi.Arguments{
+	{Type: mustNewABIType("address")},
+	{Type: mustNewABIType("address")},
+	{Type: mustNewABIType("uint24")},
+}
+
+func mustNewABIType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+func getV3PoolAddress(token0, token1 common.Address, fee FeeAmount) common.Address {
+	encoded, err := v3PoolSaltArguments.Pack(token0, token1, big.NewInt(int64(fee)))
+	if err != nil {
+		panic(err)
+	}
+	var salt [32]byte
+	copy(salt[:], crypto.Keccak256(encoded))
+	return crypto.CreateAddress2(constants.V3FactoryAddress, salt, constants.V3PoolInitCodeHash)
+}
+
+// Token0Price Returns the current mid price of the pool in terms of token0, i.e. the ratio of token1 over token0
+func (p *V3Pool) Token0Price() *Price {
+	return NewPrice(p.Token0().Currency, p.Token1().Currency, constants.Q192, new(big.Int).Mul(p.sqrtPriceX96, p.sqrtPriceX96))
+}
+
+// Token1Price Returns the current mid price of the pool in terms of token1, i.e. the ratio of token0 over token1
+func (p *V3Pool) Token1Price() *Price {
+	return NewPrice(p.Token1().Currency, p.Token0().Currency, new(big.Int).Mul(p.sqrtPriceX96, p.sqrtPriceX96), constants.Q192)
+}
+
+// PriceOf Returns the price of the given token in terms of the other token in the pool.
+func (p *V3Pool) PriceOf(token *Token) (*Price, error) {
+	if !p.InvolvesToken(token) {
+		return nil, ErrDiffToken
+	}
+
+	if token.Equals(p.Token0()) {
+		return p.Token0Price(), nil
+	}
+	return p.Token1Price(), nil
+}
+
+// GetOutputAmount returns OutputAmount and a Pair for the InputAmout
+func (p *V3Pool) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getOutputAmountAfterTax(p, inputAmount, p.getOutputAmount)
+}
+
+func (p *V3Pool) getOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	if !p.InvolvesToken(inputAmount.Token) {
+		return nil, nil, ErrDiffToken
+	}
+
+	zeroForOne := inputAmount.Token.Equals(p.Token0())
+	result, err := p.swap(zeroForOne, inputAmount.Raw())
+	if err != nil {
+		return nil, nil, err
+	}
+	if result.amountSpecifiedRemaining.Sign() != 0 {
+		return nil, nil, ErrInsufficientReserves
+	}
+
+	token := p.Token0()
+	if zeroForOne {
+		token = p.Token1()
+	}
+	outputAmount, err := NewTokenAmount(token, new(big.Int).Neg(result.amountCalculated))
+	if err != nil {
+		return nil, nil, err
+	}
+	if outputAmount.Raw().Cmp(constants.Zero) == 0 {
+		return nil, nil, ErrInsufficientInputAmount
+	}
+
+	pair, err := p.next(result, inputAmount, outputAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outputAmount, pair, nil
+}
+
+// GetInputAmount returns InputAmout and a Pair for the OutputAmount
+func (p *V3Pool) GetInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getInputAmountAfterTax(p, outputAmount, p.getInputAmount)
+}
+
+func (p *V3Pool) getInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	if !p.InvolvesToken(outputAmount.Token) {
+		return nil, nil, ErrDiffToken
+	}
+
+	zeroForOne := outputAmount.Token.Equals(p.Token1())
+	result, err := p.swap(zeroForOne, new(big.Int).Neg(outputAmount.Raw()))
+	if err != nil {
+		return nil, nil, err
+	}
+	if result.amountSpecifiedRemaining.Sign() != 0 {
+		return nil, nil, ErrInsufficientReserves
+	}
+
+	token := p.Token1()
+	if zeroForOne {
+		token = p.Token0()
+	}
+	inputAmount, err := NewTokenAmount(token, result.amountCalculated)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := p.next(result, inputAmount, outputAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inputAmount, pair, nil
+}
+
+// next returns the pool after the swap
+func (p *V3Pool) next(result *v3SwapResult, inputAmount, outputAmount *TokenAmount) (Pair, error) {
+	inputReserve, err := p.ReserveOf(inputAmount.Token)
+	if err != nil {
+		return nil, err
+	}
+	outputReserve, err := p.ReserveOf(outputAmount.Token)
+	if err != nil {
+		return nil, err
+	}
+	if outputReserve.Raw().Cmp(outputAmount.Raw()) < 0 {
+		return nil, ErrInsufficientReserves
+	}
+	tokenAmountA, err := inputReserve.Add(inputAmount)
+	if err != nil {
+		return nil, err
+	}
+	tokenAmountB, err := outputReserve.Subtract(outputAmount)
+	if err != nil {
+		return nil, err
+	}
+	tokenAmounts, err := NewTokenAmounts(tokenAmountA, tokenAmountB)
+	if err != nil {
+		return nil, err
+	}
+
+	return &V3Pool{
+		basePair: basePair{
+			TokenAmounts:   tokenAmounts,
+			PairAddress:    p.PairAddress,
+			LiquidityToken: p.LiquidityToken,
+			factory:        p.factory,
+		},
+		fee:          p.fee,
+		tickSpacing:  p.tickSpacing,
+		sqrtPriceX96: result.sqrtPriceX96,
+		liquidity:    result.liquidity,
+		tickCurrent:  result.tickCurrent,
+		ticks:        p.ticks,
+	}, nil
+}
+
+type v3SwapResult struct {
+	amountSpecifiedRemaining *big.Int
+	amountCalculated         *big.Int
+	sqrtPriceX96             *big.Int
+	liquidity                *big.Int
+	tickCurrent              int
+}
+
+// swap executes a swap, amountSpecified is positive for exact input and negative for exact output
+func (p *V3Pool) swap(zeroForOne bool, amountSpecified *big.Int) (*v3SwapResult, error) {
+	var sqrtPriceLimitX96 *big.Int
+	if zeroForOne {
+		sqrtPriceLimitX96 = new(big.Int).Add(constants.MinSqrtRatio, constants.One)
+	} else {
+		sqrtPriceLimitX96 = new(big.Int).Sub(constants.MaxSqrtRatio, constants.One)
+	}
+	exactInput := amountSpecified.Sign() >= 0
+
+	state := &v3SwapResult{
+		amountSpecifiedRemaining: new(big.Int).Set(amountSpecified),
+		amountCalculated:         new(big.Int),
+		sqrtPriceX96:             p.sqrtPriceX96,
+		liquidity:                p.liquidity,
+		tickCurrent:              p.tickCurrent,
+	}
+
+	// continue swapping as long as we haven't used the entire input/output and haven't reached the price limit
+	for state.amountSpecifiedRemaining.Sign() != 0 && state.sqrtPriceX96.Cmp(sqrtPriceLimitX96) != 0 {
+		sqrtPriceStartX96 := state.sqrtPriceX96
+		tickNext, initialized := p.nextInitializedTickWithinOneWord(state.tickCurrent, zeroForOne)
+		if tickNext < constants.MinTick {
+			tickNext = constants.MinTick
+		} else if tickNext > constants.MaxTick {
+			tickNext = constants.MaxTick
+		}
+		sqrtPriceNextX96, err := utils.GetSqrtRatioAtTick(tickNext)
+		if err != nil {
+			return nil, err
+		}
+
+		target := sqrtPriceNextX96
+		if (zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceLimitX96) < 0) ||
+			(!zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceLimitX96) > 0) {
+			target = sqrtPriceLimitX96
+		}
+		sqrtPriceX96, amountIn, amountOut, feeAmount, err := utils.ComputeSwapStep(state.sqrtPriceX96, target,
+			state.liquidity, state.amountSpecifiedRemaining, uint32(p.fee))
+		if err != nil {
+			return nil, err
+		}
+		state.sqrtPriceX96 = sqrtPriceX96
+
+		if exactInput {
+			state.amountSpecifiedRemaining.Sub(state.amountSpecifiedRemaining, new(big.Int).Add(amountIn, feeAmount))
+			state.amountCalculated.Sub(state.amountCalculated, amountOut)
+		} else {
+			state.amountSpecifiedRemaining.Add(state.amountSpecifiedRemaining, amountOut)
+			state.amountCalculated.Add(state.amountCalculated, new(big.Int).Add(amountIn, feeAmount))
+		}
+
+		if state.sqrtPriceX96.Cmp(sqrtPriceNextX96) == 0 {
+			// if the tick is initialized, run the tick transition
+			if initialized {
+				liquidityNet := p.tickAt(tickNext).LiquidityNet
+				// if we're moving leftward, we interpret liquidityNet as the opposite sign
+				if zeroForOne {
+					liquidityNet = new(big.Int).Neg(liquidityNet)
+				}
+				state.liquidity, err = utils.AddDelta(state.liquidity, liquidityNet)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if zeroForOne {
+				state.tickCurrent = tickNext - 1
+			} else {
+				state.tickCurrent = tickNext
+			}
+		} else if state.sqrtPriceX96.Cmp(sqrtPriceStartX96) != 0 {
+			// recompute unless we're on a lower tick boundary (i.e. already transitioned ticks), and haven't moved
+			state.tickCurrent, err = utils.GetTickAtSqrtRatio(state.sqrtPriceX96)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return state, nil
+}
+
+func (p *V3Pool) tickAt(index int) Tick {
+	i := sort.Search(len(p.ticks), func(i int) bool { return p.ticks[i].Index >= index })
+	return p.ticks[i]
+}
+
+// nextInitializedTickWithinOneWord returns the next initialized tick contained in the same word (or adjacent word)
+// as the tick that is either to the left (less than or equal to) or right (greater than) of the given tick
+func (p *V3Pool) nextInitializedTickWithinOneWord(tick int, lte bool) (int, bool) {
+	compressed := floorDiv(tick, p.tickSpacing)
+	if lte {
+		wordPos := compressed >> 8
+		minimum := (wordPos << 8) * p.tickSpacing
+		if len(p.ticks) == 0 || tick < p.ticks[0].Index {
+			return minimum, false
+		}
+		// the largest initialized tick <= tick
+		i := sort.Search(len(p.ticks), func(i int) bool { return p.ticks[i].Index > tick }) - 1
+		index := p.ticks[i].Index
+		if minimum > index {
+			return minimum, false
+		}
+		return index, true
+	}
+
+	wordPos := (compressed + 1) >> 8
+	maximum := (((wordPos + 1) << 8) - 1) * p.tickSpacing
+	if len(p.ticks) == 0 || tick >= p.ticks[len(p.ticks)-1].Index {
+		return maximum, false
+	}
+	// the smallest initialized tick > tick
+	i := sort.Search(len(p.ticks), func(i int) bool { return p.ticks[i].Index > tick })
+	index := p.ticks[i].Index
+	if maximum < index {
+		return maximum, false
+	}
+	return index, true
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// GetLiquidityMinted v3 positions are not fungible liquidity tokens
+func (p *V3Pool) GetLiquidityMinted(totalSupply, tokenAmountA, tokenAmountB *TokenAmount) (*TokenAmount, error) {
+	return nil, ErrNotImplemented
+}
+
+// GetLiquidityValue v3 positions are not fungible liquidity tokens
+func (p *V3Pool) GetLiquidityValue(token *Token, totalSupply, liquidity *TokenAmount, feeOn bool, kLast *big.Int) (*TokenAmount, error) {
+	return nil, ErrNotImplemented
+}
@@ -0,0 +1,335 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/utils"
+)
+
+// nolint funlen
+func TestV3Pool(t *testing.T) {
+	USDC, _ := NewToken(constants.Mainnet, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "USD Coin")
+	DAI, _ := NewToken(constants.Mainnet, common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"), 18, "DAI", "DAI Stablecoin")
+	oneEther, _ := new(big.Int).SetString("1000000000000000000", 10)
+	balanceUSDC, _ := NewTokenAmount(USDC, oneEther)
+	balanceDAI, _ := NewTokenAmount(DAI, oneEther)
+	ticks := []Tick{
+		{Index: -887270, LiquidityNet: oneEther, LiquidityGross: oneEther},
+		{Index: 887270, LiquidityNet: new(big.Int).Neg(oneEther), LiquidityGross: oneEther},
+	}
+	pool, err := NewV3Pool(balanceUSDC, balanceDAI, FeeLow, constants.Q96, oneEther, 0, ticks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// returns the correct address
+	{
+		tokenAmountWETH, _ := NewTokenAmount(WETH[constants.Mainnet], oneEther)
+		pool, err := NewV3Pool(balanceUSDC, tokenAmountWETH, FeeLow, constants.Q96, oneEther, 0, ticks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := "0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640"
+		if pool.GetAddress().String() != expect {
+			t.Errorf("expect[%+v], but got[%+v]", expect, pool.GetAddress())
+		}
+	}
+
+	// fails if the sqrt price does not match the current tick
+	{
+		_, err := NewV3Pool(balanceUSDC, balanceDAI, FeeLow, constants.Q96, oneEther, 1, ticks)
+		if err != ErrInvalidSqrtPrice {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidSqrtPrice, err)
+		}
+	}
+
+	// mid price is derived from the sqrt price
+	{
+		output := pool.Token0Price()
+		if !output.Raw().EqualTo(NewFraction(constants.One, nil)) {
+			t.Errorf("expect[%+v], but got[%+v]", 1, output.Raw())
+		}
+	}
+
+	tests := []struct {
+		name       string
+		exactInput bool
+		amount     *TokenAmount
+		expect     *TokenAmount
+	}{
+		{"USDC -> DAI exact in", true, mustTokenAmount(USDC, big.NewInt(100)), mustTokenAmount(DAI, big.NewInt(98))},
+		{"DAI -> USDC exact in", true, mustTokenAmount(DAI, big.NewInt(100)), mustTokenAmount(USDC, big.NewInt(98))},
+		{"USDC -> DAI exact out", false, mustTokenAmount(DAI, big.NewInt(98)), mustTokenAmount(USDC, big.NewInt(100))},
+		{"DAI -> USDC exact out", false, mustTokenAmount(USDC, big.NewInt(98)), mustTokenAmount(DAI, big.NewInt(100))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				output   *TokenAmount
+				nextPair Pair
+				err      error
+			)
+			if tt.exactInput {
+				output, nextPair, err = pool.GetOutputAmount(tt.amount)
+			} else {
+				output, nextPair, err = pool.GetInputAmount(tt.amount)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !output.Equals(tt.expect) {
+				t.Errorf("expect[%+v], but got[%+v]", tt.expect.Raw(), output.Raw())
+			}
+			next := nextPair.(*V3Pool)
+			if next.SqrtPriceX96().Cmp(pool.(*V3Pool).SqrtPriceX96()) == 0 {
+				t.Error("sqrt price should move")
+			}
+		})
+	}
+
+	// crosses initialized ticks
+	{
+		crossingTicks := []Tick{
+			{Index: -887270, LiquidityNet: oneEther, LiquidityGross: oneEther},
+			{Index: -10, LiquidityNet: oneEther, LiquidityGross: oneEther},
+			{Index: 10, LiquidityNet: new(big.Int).Neg(oneEther), LiquidityGross: oneEther},
+			{Index: 887270, LiquidityNet: new(big.Int).Neg(oneEther), LiquidityGross: oneEther},
+		}
+		concentrated, err := NewV3Pool(balanceUSDC, balanceDAI, FeeLow, constants.Q96, new(big.Int).Mul(oneEther, constants.Two), 0,
+			crossingTicks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputAmount := mustTokenAmount(DAI, big.NewInt(1e16))
+		output, nextPair, err := concentrated.GetOutputAmount(inputAmount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next := nextPair.(*V3Pool)
+		// DAI is token0, so the price moves down
+		if next.TickCurrent() >= -10 {
+			t.Errorf("expect tick crossed -10, but got[%+v]", next.TickCurrent())
+		}
+		if next.Liquidity().Cmp(oneEther) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", oneEther, next.Liquidity())
+		}
+
+		// swapping back the output approximately restores the input
+		back, _, err := next.GetOutputAmount(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back.Raw().Cmp(inputAmount.Raw()) >= 0 {
+			t.Errorf("round trip should lose fees, but got[%+v]", back.Raw())
+		}
+	}
+
+	// the pools of the fee tiers are different pairs, so trades are split across them
+	{
+		medium, err := NewV3Pool(balanceUSDC, balanceDAI, FeeMedium, constants.Q96, oneEther, 0, []Tick{
+			{Index: -887220, LiquidityNet: oneEther, LiquidityGross: oneEther},
+			{Index: 887220, LiquidityNet: new(big.Int).Neg(oneEther), LiquidityGross: oneEther},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pool.Equal(medium) || medium.Equal(pool) || !pool.Equal(pool) {
+			t.Error("pools of the fee tiers should be different pairs")
+		}
+		tokenAmountUSDC, _ := NewTokenAmount(USDC, oneEther)
+		tokenAmountDAI, _ := NewTokenAmount(DAI, oneEther)
+		classic, _ := NewPair(tokenAmountUSDC, tokenAmountDAI)
+		if pool.Equal(classic) {
+			t.Error("v2 and v3 pools of the same tokens should be different pairs")
+		}
+		// v2 pairs still compare by their tokens
+		other, _ := NewPair(balanceUSDC, balanceDAI)
+		if !classic.Equal(other) {
+			t.Error("v2 pairs of the same tokens should be equal")
+		}
+		mixed, err := BestSmartTradeExactIn([]Pair{classic, pool}, mustTokenAmount(USDC, big.NewInt(1e16)), DAI, &BestSmartTradeOptions{
+			BestTradeOptions:        *NewDefaultBestTradeOptions(),
+			MaxSplit:                2,
+			MaxSmartTradeNumResults: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mixed[0].Trades) != 2 {
+			t.Errorf("expect split across v2 and v3, but got[%+v]", mixed[0].Percents)
+		}
+
+		smartTrades, err := BestSmartTradeExactIn([]Pair{pool, medium}, mustTokenAmount(USDC, big.NewInt(1e16)), DAI, &BestSmartTradeOptions{
+			BestTradeOptions:        *NewDefaultBestTradeOptions(),
+			MaxSplit:                2,
+			MaxSmartTradeNumResults: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(smartTrades[0].Trades) != 2 {
+			t.Errorf("expect split across the fee tiers, but got[%+v]", smartTrades[0].Percents)
+		}
+	}
+
+	// usable by best trade
+	{
+		tokenAmountUSDC, _ := NewTokenAmount(USDC, big.NewInt(1000))
+		tokenAmountDAI, _ := NewTokenAmount(DAI, big.NewInt(1000))
+		classic, _ := NewPair(tokenAmountUSDC, tokenAmountDAI)
+		trades, err := BestTradeExactIn([]Pair{classic, pool}, mustTokenAmount(USDC, big.NewInt(100)), DAI, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) != 2 {
+			t.Fatalf("expect[%+v], but got[%+v]", 2, len(trades))
+		}
+		if trades[0].Route.Pairs[0] != pool {
+			t.Error("v3 pool should give the best trade")
+		}
+		if trades[0].OutputAmount().Raw().Cmp(big.NewInt(98)) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", 98, trades[0].OutputAmount().Raw())
+		}
+	}
+}
+
+// v2StylePool returns a pool with the full range liquidity of a v2 pair of the reserves, like v2StylePool of the
+// trade tests of v3-sdk
+func v2StylePool(reserve0, reserve1 *TokenAmount, fee FeeAmount) Pair {
+	ratioX192 := new(big.Int).Div(new(big.Int).Lsh(reserve1.Raw(), 192), reserve0.Raw())
+	sqrtRatioX96 := new(big.Int).Sqrt(ratioX192)
+	liquidity := new(big.Int).Sqrt(new(big.Int).Mul(reserve0.Raw(), reserve1.Raw()))
+	tickCurrent, err := utils.GetTickAtSqrtRatio(sqrtRatioX96)
+	if err != nil {
+		panic(err)
+	}
+	tickSpacing := TickSpacings[fee]
+	maxTick := constants.MaxTick / tickSpacing * tickSpacing
+	pool, err := NewV3Pool(reserve0, reserve1, fee, sqrtRatioX96, liquidity, tickCurrent, []Tick{
+		{Index: -maxTick, LiquidityNet: liquidity, LiquidityGross: liquidity},
+		{Index: maxTick, LiquidityNet: new(big.Int).Neg(liquidity), LiquidityGross: liquidity},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return pool
+}
+
+// the vectors of the trade tests of v3-sdk
+// nolint funlen
+func TestV3PoolBestTrade(t *testing.T) {
+	token0, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "t0", "")
+	token1, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "t1", "")
+	token2, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000003"), 18, "t2", "")
+
+	pool_0_1 := v2StylePool(mustTokenAmount(token0, big.NewInt(100000)), mustTokenAmount(token1, big.NewInt(100000)), FeeMedium)
+	pool_0_2 := v2StylePool(mustTokenAmount(token0, big.NewInt(100000)), mustTokenAmount(token2, big.NewInt(110000)), FeeMedium)
+	pool_1_2 := v2StylePool(mustTokenAmount(token1, big.NewInt(120000)), mustTokenAmount(token2, big.NewInt(100000)), FeeMedium)
+	pairs := []Pair{pool_0_1, pool_0_2, pool_1_2}
+
+	// exact in, 0 -> 2 at 10:11 and 0 -> 1 -> 2 at 12:12:10
+	{
+		trades, err := BestTradeExactIn(pairs, mustTokenAmount(token0, big.NewInt(10000)), token2, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) != 2 {
+			t.Fatalf("expect[%+v], but got[%+v]", 2, len(trades))
+		}
+		for i, test := range []struct {
+			pairs  []Pair
+			output int64
+		}{
+			{[]Pair{pool_0_2}, 9971},
+			{[]Pair{pool_0_1, pool_1_2}, 7004},
+		} {
+			trade := trades[i]
+			if len(trade.Route.Pairs) != len(test.pairs) || trade.Route.Pairs[0] != test.pairs[0] {
+				t.Errorf("expect[%+v], but got[%+v]", len(test.pairs), len(trade.Route.Pairs))
+			}
+			if trade.InputAmount().Raw().Int64() != 10000 || trade.OutputAmount().Raw().Int64() != test.output {
+				t.Errorf("expect[%+v %+v], but got[%+v %+v]", 10000, test.output, trade.InputAmount().Raw(), trade.OutputAmount().Raw())
+			}
+		}
+	}
+
+	// exact out
+	{
+		trades, err := BestTradeExactOut(pairs, token0, mustTokenAmount(token2, big.NewInt(10000)), nil, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) != 2 {
+			t.Fatalf("expect[%+v], but got[%+v]", 2, len(trades))
+		}
+		for i, test := range []struct {
+			pairs []Pair
+			input int64
+		}{
+			{[]Pair{pool_0_2}, 10032},
+			{[]Pair{pool_0_1, pool_1_2}, 15488},
+		} {
+			trade := trades[i]
+			if len(trade.Route.Pairs) != len(test.pairs) || trade.Route.Pairs[0] != test.pairs[0] {
+				t.Errorf("expect[%+v], but got[%+v]", len(test.pairs), len(trade.Route.Pairs))
+			}
+			if trade.InputAmount().Raw().Int64() != test.input || trade.OutputAmount().Raw().Int64() != 10000 {
+				t.Errorf("expect[%+v %+v], but got[%+v %+v]", test.input, 10000, trade.InputAmount().Raw(), trade.OutputAmount().Raw())
+			}
+		}
+	}
+}
+
+// the vectors of swapping across gaps of the pool tests of v3-core
+// nolint funlen
+func TestV3PoolCrossTicks(t *testing.T) {
+	token0, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "t0", "")
+	token1, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "t1", "")
+	oneEther, _ := new(big.Int).SetString("1000000000000000000", 10)
+	liquidity := new(big.Int).Div(oneEther, constants.Four)
+
+	tests := []struct {
+		name        string
+		tickLower   int
+		tickUpper   int
+		input       *Token
+		tick        int
+		burnAmount0 string
+		burnAmount1 string
+	}{
+		{"one for zero", 120000, 121200, token1, 120196, "30027458295511", "996999999999999999"},
+		{"zero for one", -121200, -120000, token0, -120197, "996999999999999999", "30027458295511"},
+	}
+	for _, test := range tests {
+		// the only position is out of the current price, so the swap crosses the gap first
+		pool, err := NewV3Pool(mustTokenAmount(token0, oneEther), mustTokenAmount(token1, oneEther), FeeMedium, constants.Q96, constants.Zero, 0,
+			[]Tick{
+				{Index: test.tickLower, LiquidityNet: liquidity, LiquidityGross: liquidity},
+				{Index: test.tickUpper, LiquidityNet: new(big.Int).Neg(liquidity), LiquidityGross: liquidity},
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, nextPair, err := pool.GetOutputAmount(mustTokenAmount(test.input, oneEther))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		next := nextPair.(*V3Pool)
+		if next.TickCurrent() != test.tick || next.Liquidity().Cmp(liquidity) != 0 {
+			t.Errorf("%s: expect[%+v %+v], but got[%+v %+v]", test.name, test.tick, liquidity, next.TickCurrent(), next.Liquidity())
+		}
+
+		// the amounts of the position burnt after the swap
+		sqrtRatioLower, _ := utils.GetSqrtRatioAtTick(test.tickLower)
+		sqrtRatioUpper, _ := utils.GetSqrtRatioAtTick(test.tickUpper)
+		amount0 := utils.GetAmount0Delta(next.SqrtPriceX96(), sqrtRatioUpper, liquidity, false)
+		amount1 := utils.GetAmount1Delta(sqrtRatioLower, next.SqrtPriceX96(), liquidity, false)
+		if amount0.String() != test.burnAmount0 || amount1.String() != test.burnAmount1 {
+			t.Errorf("%s: expect[%+v %+v], but got[%+v %+v]", test.name, test.burnAmount0, test.burnAmount1, amount0, amount1)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package entities
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/utils"
+)
+
+var (
+	// ErrInvalidWeights weights must be positive and at most 1e18
+	ErrInvalidWeights = fmt.Errorf("invalid weights")
+
+	Weighted PairType = "weighted"
+)
+
+// WeightedPair wraps a Balancer-style weighted pool of two tokens, e.g. 80/20 pools.
+// Weights and the swap fee are fixed point numbers with 18 decimals, e.g. 8e17 is 80%. The weights of two tokens
+// of a pool with more tokens can be used as they are, only their ratio matters for swaps.
+type WeightedPair struct {
+	basePair
+
+	weight0 *big.Int
+	weight1 *big.Int
+	swapFee *big.Int
+}
+
+/**
+ * NewWeightedPair creates a WeightedPair
+ * @param address the pool address, which is also the address of the pool token
+ * @param weightA weight of the token of tokenAmountA
+ * @param weightB weight of the token of tokenAmountB
+ * @param swapFee swap fee percentage, e.g. 3e15 is 0.3%
+ */
+func NewWeightedPair(address common.Address, tokenAmountA, tokenAmountB *TokenAmount, weightA, weightB, swapFee *big.Int) (Pair, error) {
+	if address == (common.Address{}) {
+		return nil, ErrInvalidPoolAddress
+	}
+	for _, weight := range []*big.Int{weightA, weightB} {
+		if weight == nil || weight.Sign() <= 0 || weight.Cmp(constants.B1e18) > 0 {
+			return nil, ErrInvalidWeights
+		}
+	}
+	if swapFee == nil || swapFee.Sign() < 0 || swapFee.Cmp(constants.B1e18) >= 0 {
+		return nil, ErrInvalidFee
+	}
+	tokenAmounts, err := NewTokenAmounts(tokenAmountA, tokenAmountB)
+	if err != nil {
+		return nil, err
+	}
+	if !tokenAmounts[0].Token.Equals(tokenAmountA.Token) {
+		weightA, weightB = weightB, weightA
+	}
+
+	pair := &WeightedPair{
+		basePair: basePair{
+			TokenAmounts: tokenAmounts,
+			PairAddress:  address,
+		},
+		weight0: weightA,
+		weight1: weightB,
+		swapFee: swapFee,
+	}
+	pair.LiquidityToken, err = NewToken(tokenAmountA.Token.ChainID, address, constants.Decimals18, "BPT", "Balancer Pool Token")
+	return pair, err
+}
+
+/**** weighted pair *****/
+
+func (p *WeightedPair) PairType() PairType {
+	return Weighted
+}
+
+// Weights returns the weights of token0 and token1
+func (p *WeightedPair) Weights() (weight0, weight1 *big.Int) {
+	return p.weight0, p.weight1
+}
+
+// SwapFee returns the swap fee percentage with 18 decimals
+func (p *WeightedPair) SwapFee() *big.Int {
+	return p.swapFee
+}
+
+// Equal returns true for the pairs of the same pool, pools of the same tokens with other weights are different pairs
+func (p *WeightedPair) Equal(p1 Pair) bool {
+	return p.GetAddress() == p1.GetAddress()
+}
+
+func (p *WeightedPair) Copy(tokenAmountA, tokenAmountB *TokenAmount) (Pair, error) {
+	tokenAmounts, err := NewTokenAmounts(tokenAmountA, tokenAmountB)
+	if err != nil {
+		return nil, err
+	}
+
+	pair := &WeightedPair{
+		basePair: basePair{
+			TokenAmounts:   tokenAmounts,
+			PairAddress:    p.PairAddress,
+			LiquidityToken: p.LiquidityToken,
+			factory:        p.factory,
+		},
+		weight0: p.weight0,
+		weight1: p.weight1,
+		swapFee: p.swapFee,
+	}
+	return pair, err
+}
+
+func (p *WeightedPair) weightOf(token *Token) *big.Int {
+	if token.Equals(p.Token0()) {
+		return p.weight0
+	}
+	return p.weight1
+}
+
+// Token0Price Returns the current mid price of the pair in terms of token0, i.e.
+// (reserve1 / weight1) / (reserve0 / weight0), excluding the fee
+func (p *WeightedPair) Token0Price() *Price {
+	return NewPrice(p.Token0().Currency, p.Token1().Currency,
+		new(big.Int).Mul(p.Reserve0().Raw(), p.weight1), new(big.Int).Mul(p.Reserve1().Raw(), p.weight0))
+}
+
+// Token1Price Returns the current mid price of the pair in terms of token1, i.e.
+// (reserve0 / weight0) / (reserve1 / weight1), excluding the fee
+func (p *WeightedPair) Token1Price() *Price {
+	return NewPrice(p.Token1().Currency, p.Token0().Currency,
+		new(big.Int).Mul(p.Reserve1().Raw(), p.weight0), new(big.Int).Mul(p.Reserve0().Raw(), p.weight1))
+}
+
+// PriceOf Returns the price of the given token in terms of the other token in the pair.
+// @param token token to return price of
+func (p *WeightedPair) PriceOf(token *Token) (*Price, error) {
+	if !p.InvolvesToken(token) {
+		return nil, ErrDiffToken
+	}
+
+	if token.Equals(p.Token0()) {
+		return p.Token0Price(), nil
+	}
+	return p.Token1Price(), nil
+}
+
+// scalingFactor returns the factor upscaling raw amounts of token to 18 decimals, with 18 decimals
+func scalingFactor(token *Token) *big.Int {
+	return new(big.Int).Exp(constants.Ten, big.NewInt(int64(36-token.Decimals)), nil)
+}
+
+// GetOutputAmount returns OutputAmount and a Pair for the InputAmout, as onSwap given in of the pool.
+// the input can be at most 30% of the input reserve.
+func (p *WeightedPair) GetOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getOutputAmountAfterTax(p, inputAmount, p.getOutputAmount)
+}
+
+func (p *WeightedPair) getOutputAmount(inputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	if !p.InvolvesToken(inputAmount.Token) {
+		return nil, nil, ErrDiffToken
+	}
+
+	if p.Reserve0().Raw().Cmp(constants.Zero) == 0 ||
+		p.Reserve1().Raw().Cmp(constants.Zero) == 0 {
+		return nil, nil, ErrInsufficientReserves
+	}
+
+	inputReserve, err := p.ReserveOf(inputAmount.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+	token := p.Token0()
+	if inputAmount.Token.Equals(p.Token0()) {
+		token = p.Token1()
+	}
+	outputReserve, err := p.ReserveOf(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the fee is deducted before upscaling
+	_amountIn := new(big.Int).Sub(inputAmount.Raw(), utils.MulUp(inputAmount.Raw(), p.swapFee))
+	_scalingIn, _scalingOut := scalingFactor(inputAmount.Token), scalingFactor(token)
+	_amountOut, err := utils.CalcOutGivenIn(
+		utils.MulDown(inputReserve.Raw(), _scalingIn), p.weightOf(inputAmount.Token),
+		utils.MulDown(outputReserve.Raw(), _scalingOut), p.weightOf(token),
+		utils.MulDown(_amountIn, _scalingIn),
+	)
+	if err != nil {
+		if err == utils.ErrMaxInRatio {
+			return nil, nil, ErrInsufficientReserves
+		}
+		return nil, nil, err
+	}
+	outputAmount, err := NewTokenAmount(token, utils.DivDown(_amountOut, _scalingOut))
+	if err != nil {
+		return nil, nil, err
+	}
+	if outputAmount.Raw().Cmp(constants.Zero) == 0 {
+		return nil, nil, ErrInsufficientInputAmount
+	}
+
+	tokenAmountA, err := inputAmount.Add(inputReserve)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokenAmountB, err := outputReserve.Subtract(outputAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+	pair, err := p.Copy(tokenAmountA, tokenAmountB)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outputAmount, pair, nil
+}
+
+// GetInputAmount returns InputAmout and a Pair for the OutputAmount, as onSwap given out of the pool.
+// the output can be at most 30% of the output reserve.
+func (p *WeightedPair) GetInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	return getInputAmountAfterTax(p, outputAmount, p.getInputAmount)
+}
+
+func (p *WeightedPair) getInputAmount(outputAmount *TokenAmount) (*TokenAmount, Pair, error) {
+	if !p.InvolvesToken(outputAmount.Token) {
+		return nil, nil, ErrDiffToken
+	}
+
+	outputReserve, err := p.ReserveOf(outputAmount.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.Reserve0().Raw().Cmp(constants.Zero) == 0 ||
+		p.Reserve1().Raw().Cmp(constants.Zero) == 0 ||
+		outputAmount.Raw().Cmp(outputReserve.Raw()) >= 0 {
+		return nil, nil, ErrInsufficientReserves
+	}
+
+	token := p.Token0()
+	if outputAmount.Token.Equals(p.Token0()) {
+		token = p.Token1()
+	}
+	inputReserve, err := p.ReserveOf(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_scalingIn, _scalingOut := scalingFactor(token), scalingFactor(outputAmount.Token)
+	_amountIn, err := utils.CalcInGivenOut(
+		utils.MulDown(inputReserve.Raw(), _scalingIn), p.weightOf(token),
+		utils.MulDown(outputReserve.Raw(), _scalingOut), p.weightOf(outputAmount.Token),
+		utils.MulDown(outputAmount.Raw(), _scalingOut),
+	)
+	if err != nil {
+		if err == utils.ErrMaxOutRatio {
+			return nil, nil, ErrInsufficientReserves
+		}
+		return nil, nil, err
+	}
+	// downscaled rounding up, then the fee is added
+	amount := utils.DivUp(utils.DivUp(_amountIn, _scalingIn), utils.Complement(p.swapFee))
+	inputAmount, err := NewTokenAmount(token, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokenAmountA, err := inputAmount.Add(inputReserve)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokenAmountB, err := outputReserve.Subtract(outputAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+	pair, err := p.Copy(tokenAmountA, tokenAmountB)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inputAmount, pair, nil
+}
+
+// GetLiquidityMinted pool tokens of weighted pools are minted by joining the pool in all of its tokens
+func (p *WeightedPair) GetLiquidityMinted(totalSupply, tokenAmountA, tokenAmountB *TokenAmount) (*TokenAmount, error) {
+	return nil, ErrNotImplemented
+}
+
+// GetLiquidityValue pool tokens of weighted pools are burned by exiting the pool in all of its tokens
+func (p *WeightedPair) GetLiquidityValue(token *Token, totalSupply, liquidity *TokenAmount, feeOn bool, kLast *big.Int) (*TokenAmount, error) {
+	return nil, ErrNotImplemented
+}
@@ -0,0 +1,155 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+// nolint funlen
+func TestWeightedPair(t *testing.T) {
+	bal, _ := NewToken(constants.Mainnet, common.HexToAddress("0xba100000625a3754423978a60c9317c58a424e3D"), 18, "BAL", "")
+	usdc, _ := NewToken(constants.Mainnet, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "")
+	address := common.HexToAddress("0x5c6Ee304399DBdB9C8Ef030aB642B10820DB8F56")
+
+	// 80 BAL / 20 USDC, 1 BAL = 5 USDC
+	reserveBAL, _ := NewTokenAmount(bal, mustUnits(800000, 18))
+	reserveUSDC, _ := NewTokenAmount(usdc, mustUnits(1000000, 6))
+	pair, err := NewWeightedPair(address, reserveBAL, reserveUSDC, big.NewInt(8e17), big.NewInt(2e17), big.NewInt(3e15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.PairType() != Weighted || pair.GetAddress() != address || pair.GetLiquidityToken().Address != address {
+		t.Error("pair should be at the pool address")
+	}
+
+	// pools of the same tokens, e.g. 80/20 and 50/50, are different pairs
+	{
+		// 1 BAL = 5 USDC as well
+		balancedBAL, _ := NewTokenAmount(bal, mustUnits(200000, 18))
+		balanced, err := NewWeightedPair(common.HexToAddress("0x0297e37f1873D2DAb4487Aa67cD56B58E2F27875"),
+			balancedBAL, reserveUSDC, big.NewInt(5e17), big.NewInt(5e17), big.NewInt(3e15))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pair.Equal(balanced) || balanced.Equal(pair) || !pair.Equal(pair) {
+			t.Error("weighted pools of the same tokens should be different pairs")
+		}
+		classic, _ := NewPair(reserveBAL, reserveUSDC)
+		if pair.Equal(classic) {
+			t.Error("weighted pool and v2 pair of the same tokens should be different pairs")
+		}
+		amountIn, _ := NewTokenAmount(bal, mustUnits(100000, 18))
+		smartTrades, err := BestSmartTradeExactIn([]Pair{pair, balanced}, amountIn, usdc, &BestSmartTradeOptions{
+			BestTradeOptions:        *NewDefaultBestTradeOptions(),
+			MaxSplit:                2,
+			MaxSmartTradeNumResults: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(smartTrades[0].Trades) != 2 {
+			t.Errorf("expect split across the pools, but got[%+v]", smartTrades[0].Percents)
+		}
+	}
+
+	// mid price
+	{
+		price, err := pair.PriceOf(bal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if price.ToSignificant(6) != "5" {
+			t.Errorf("expect[%+v], but got[%+v]", "5", price.ToSignificant(6))
+		}
+		price, err = pair.PriceOf(usdc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if price.ToSignificant(6) != "0.2" {
+			t.Errorf("expect[%+v], but got[%+v]", "0.2", price.ToSignificant(6))
+		}
+	}
+
+	// exact input, a small trade is at the mid price minus the 0.3% fee
+	{
+		amountIn, _ := NewTokenAmount(bal, mustUnits(1, 18))
+		amountOut, next, err := pair.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amountOut.Raw().Cmp(big.NewInt(4985000)) > 0 || amountOut.Raw().Cmp(big.NewInt(4984900)) < 0 {
+			t.Errorf("expect about [%+v], but got[%+v]", 4985000, amountOut.Raw())
+		}
+		if next.Reserve0().Raw().Cmp(pair.Reserve0().Raw()) == 0 {
+			t.Error("next pair should have the reserves after the swap")
+		}
+	}
+
+	// exact output is the least input giving the output
+	for _, amountOut := range []*TokenAmount{
+		mustTokenAmount(usdc, mustUnits(100000, 6)),
+		mustTokenAmount(bal, mustUnits(20000, 18)),
+	} {
+		amountIn, _, err := pair.GetInputAmount(amountOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := pair.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Raw().Cmp(amountOut.Raw()) < 0 {
+			t.Errorf("expect at least [%+v], but got[%+v]", amountOut.Raw(), got.Raw())
+		}
+		less, _ := NewTokenAmount(amountIn.Token, new(big.Int).Sub(amountIn.Raw(), new(big.Int).Div(amountIn.Raw(), big.NewInt(1e9))))
+		if got, _, _ := pair.GetOutputAmount(less); got.Raw().Cmp(amountOut.Raw()) >= 0 {
+			t.Errorf("input [%+v] is not the least", amountIn.Raw())
+		}
+	}
+
+	// 50/50 without fee is the constant product
+	{
+		tokenAmountA, _ := NewTokenAmount(bal, mustUnits(1000, 18))
+		tokenAmountB, _ := NewTokenAmount(usdc, mustUnits(5000, 6))
+		weighted, err := NewWeightedPair(address, tokenAmountA, tokenAmountB, big.NewInt(5e17), big.NewInt(5e17), big.NewInt(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		classic, err := NewPairWithFee(tokenAmountA, tokenAmountB, 0, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		amountIn, _ := NewTokenAmount(bal, mustUnits(100, 18))
+		expect, _, _ := classic.GetOutputAmount(amountIn)
+		got, _, err := weighted.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if new(big.Int).Sub(got.Raw(), expect.Raw()).CmpAbs(constants.One) > 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), got.Raw())
+		}
+	}
+
+	// trades are limited to 30% of the reserves
+	{
+		amountIn, _ := NewTokenAmount(bal, mustUnits(241000, 18))
+		if _, _, err := pair.GetOutputAmount(amountIn); err != ErrInsufficientReserves {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInsufficientReserves, err)
+		}
+		amountOut, _ := NewTokenAmount(usdc, mustUnits(300001, 6))
+		if _, _, err := pair.GetInputAmount(amountOut); err != ErrInsufficientReserves {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInsufficientReserves, err)
+		}
+	}
+
+	if _, err := NewWeightedPair(address, reserveBAL, reserveUSDC, big.NewInt(0), big.NewInt(2e17), big.NewInt(3e15)); err != ErrInvalidWeights {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidWeights, err)
+	}
+	if _, err := NewWeightedPair(address, reserveBAL, reserveUSDC, big.NewInt(8e17), big.NewInt(2e17), big.NewInt(1e18)); err != ErrInvalidFee {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidFee, err)
+	}
+}
@@ -21,15 +21,12 @@ func NewPriceFromRoute(route *Route) (*Price, error) {
 	// NOTE: check route Pairs len?
 	prices := make([]*Price, length)
 	for i := range route.Pairs {
-		if route.Path[i].Equals(route.Pairs[i].Token0()) {
-			prices[i] = NewPrice(route.Pairs[i].Reserve0().Currency, route.Pairs[i].Reserve1().Currency,
-				route.Pairs[i].Reserve0().Raw(), route.Pairs[i].Reserve1().Raw())
-		} else {
-			prices[i] = NewPrice(route.Pairs[i].Reserve1().Currency, route.Pairs[i].Reserve0().Currency,
-				route.Pairs[i].Reserve1().Raw(), route.Pairs[i].Reserve0().Raw())
+		var err error
+		prices[i], err = route.Pairs[i].PriceOf(route.Path[i])
+		if err != nil {
+			return nil, err
 		}
 	}
-
 	price := prices[0]
 	var err error
 	for i := 1; i < length; i++ {
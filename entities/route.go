@@ -69,6 +69,17 @@ func NewRoute(pairs []Pair, input, output *Token) (*Route, error) {
 	return route, err
 }
 
+// HasTransferTax returns true if any token of the route is a fee on transfer token, so the trade must be executed
+// with the SupportingFeeOnTransferTokens methods of the router
+func (r *Route) HasTransferTax() bool {
+	for i, pair := range r.Pairs {
+		if pairToken(pair, r.Path[i]).HasTax() || pairToken(pair, r.Path[i+1]).HasTax() {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Route) ChainID() constants.ChainID {
 	return r.Pairs[0].ChainID()
 }
@@ -43,6 +43,26 @@ var (
 			ChainID:  constants.Kovan,
 			Address:  utils.ValidateAndParseAddress("0xd0A1E359811322d97991E03f863a0C30C2cF029C"),
 		},
+		constants.Sepolia: {
+			Currency: _WETHCurrency,
+			ChainID:  constants.Sepolia,
+			Address:  utils.ValidateAndParseAddress("0xfFf9976782d46CC05630D1f6eBAb18b2324d6B14"),
+		},
+		constants.Optimism: {
+			Currency: _WETHCurrency,
+			ChainID:  constants.Optimism,
+			Address:  utils.ValidateAndParseAddress("0x4200000000000000000000000000000000000006"),
+		},
+		constants.Base: {
+			Currency: _WETHCurrency,
+			ChainID:  constants.Base,
+			Address:  utils.ValidateAndParseAddress("0x4200000000000000000000000000000000000006"),
+		},
+		constants.Arbitrum: {
+			Currency: _WETHCurrency,
+			ChainID:  constants.Arbitrum,
+			Address:  utils.ValidateAndParseAddress("0x82aF49447D8a07e3bd95BD0d56f35241523fBab1"),
+		},
 	}
 )
 
@@ -54,6 +74,12 @@ type Token struct {
 
 	constants.ChainID
 	common.Address
+
+	// Tax taxes of fee on transfer tokens, nil if transfers are not taxed
+	Tax *TransferTax
+
+	// native currency the token stands for, nil for ERC20 tokens
+	native *NativeCurrency
 }
 
 func NewToken(chainID constants.ChainID, address common.Address, decimals int, symbol, name string) (*Token, error) {
@@ -98,11 +124,20 @@ func (t *Token) SortsBefore(other *Token) (bool, error) {
 	return strings.ToLower(t.Address.String()) < strings.ToLower(other.Address.String()), nil
 }
 
-// NewETHRToken creates a token that currency is ETH
+// NewETHRToken creates a token that currency is ETH, i.e. the native currency wrapped by the token at address.
+// Native(chainID).Token() returns the token of ETH wrapped by WETH.
 func NewETHRToken(chainID constants.ChainID, address common.Address) *Token {
-	return &Token{
+	wrapped, ok := WETH[chainID]
+	if !ok || wrapped.Address != address {
+		wrapped = &Token{
+			Currency: _WETHCurrency,
+			ChainID:  chainID,
+			Address:  address,
+		}
+	}
+	return (&NativeCurrency{
 		Currency: ETHER,
 		ChainID:  chainID,
-		Address:  address,
-	}
+		wrapped:  wrapped,
+	}).Token()
 }
@@ -5,6 +5,9 @@ import "math/big"
 type TokenAmount struct {
 	*CurrencyAmount
 	Token *Token
+	// hop is the amount sent by one pair to the next pair of a route for a taxed token, which is taxed once on the
+	// way, see getOutputAmountAfterTax
+	hop *big.Int
 }
 
 // amount _must_ be raw, i.e. in the native representation
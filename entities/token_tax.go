@@ -0,0 +1,195 @@
+package entities
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+var (
+	// ErrInvalidTax taxes must be less than 100%
+	ErrInvalidTax = fmt.Errorf("invalid tax")
+)
+
+// TransferTax taxes of a fee on transfer token, in basis points of the transferred amount.
+// The tax is taken from the amount received, so a pair receives less than the input of a swap and the recipient
+// less than the output of the pair. On a route the token moves from one pair to the next in a single transfer,
+// which takes the sell tax only.
+type TransferTax struct {
+	// Buy tax of transfers out of a pair, i.e. the output of swaps
+	Buy uint64 `json:"buy"`
+	// Sell tax of transfers into a pair, i.e. the input of swaps
+	Sell uint64 `json:"sell"`
+	// Transfer tax of transfers between wallets
+	Transfer uint64 `json:"transfer"`
+}
+
+/**
+ * WithTax returns a copy of the token taking the taxes on transfers
+ * @param buy tax of transfers out of a pair, in basis points
+ * @param sell tax of transfers into a pair, in basis points
+ * @param transfer tax of other transfers, in basis points
+ */
+func (t *Token) WithTax(buy, sell, transfer uint64) (*Token, error) {
+	denominator := constants.TaxDenominator.Uint64()
+	if buy >= denominator || sell >= denominator || transfer >= denominator {
+		return nil, ErrInvalidTax
+	}
+
+	token := *t
+	token.Tax = &TransferTax{Buy: buy, Sell: sell, Transfer: transfer}
+	if *token.Tax == (TransferTax{}) {
+		token.Tax = nil
+	}
+	return &token, nil
+}
+
+// HasTax returns true if transfers of the token are taxed
+func (t *Token) HasTax() bool {
+	return t.Tax != nil && *t.Tax != (TransferTax{})
+}
+
+// taxed returns amount minus the tax, the tax is rounded down like the tokens do
+func taxed(amount *big.Int, tax uint64) *big.Int {
+	if tax == 0 {
+		return amount
+	}
+	_tax := new(big.Int).Mul(amount, new(big.Int).SetUint64(tax))
+	_tax.Div(_tax, constants.TaxDenominator)
+	return _tax.Sub(amount, _tax)
+}
+
+// untaxed returns the least amount that is at least amount after the tax.
+// the amount after the tax is ceil(x * (1 - tax)), so x is the least one with x * (1 - tax) > amount - 1
+func untaxed(amount *big.Int, tax uint64) *big.Int {
+	if tax == 0 || amount.Sign() == 0 {
+		return amount
+	}
+	_x := new(big.Int).Mul(new(big.Int).Sub(amount, constants.One), constants.TaxDenominator)
+	_x.Div(_x, new(big.Int).Sub(constants.TaxDenominator, new(big.Int).SetUint64(tax)))
+	return _x.Add(_x, constants.One)
+}
+
+// AfterSellTax returns the amount a pair receives when the amount is sent to it
+func (t *TokenAmount) AfterSellTax() (*TokenAmount, error) {
+	if !t.Token.HasTax() {
+		return t, nil
+	}
+	return NewTokenAmount(t.Token, taxed(t.Raw(), t.Token.Tax.Sell))
+}
+
+// AfterBuyTax returns the amount the recipient receives when a pair sends the amount
+func (t *TokenAmount) AfterBuyTax() (*TokenAmount, error) {
+	if !t.Token.HasTax() {
+		return t, nil
+	}
+	return NewTokenAmount(t.Token, taxed(t.Raw(), t.Token.Tax.Buy))
+}
+
+// AfterTransferTax returns the amount the recipient receives when the amount is sent between wallets
+func (t *TokenAmount) AfterTransferTax() (*TokenAmount, error) {
+	if !t.Token.HasTax() {
+		return t, nil
+	}
+	return NewTokenAmount(t.Token, taxed(t.Raw(), t.Token.Tax.Transfer))
+}
+
+// detached returns the amount without the amount sent between pairs, for the amounts a route starts or ends with
+func (t *TokenAmount) detached() *TokenAmount {
+	if t.hop == nil {
+		return t
+	}
+	amount := *t
+	amount.hop = nil
+	return &amount
+}
+
+// pairToken returns the token of the pair equal to token if it is taxed or token stands for the native currency,
+// so taxes are taken from the pair and the pair after the swap keeps its tokens, or token otherwise
+func pairToken(pair Pair, token *Token) *Token {
+	for _, t := range []*Token{pair.Token0(), pair.Token1()} {
+		if t.Equals(token) && (t.HasTax() || token.IsNative()) {
+			return t
+		}
+	}
+	return token
+}
+
+// getOutputAmountAfterTax wraps the swap math of pair, the pair swaps the input minus the sell tax and the
+// output is what the recipient receives after the buy tax.
+// An output passed on to the next pair of a route keeps the amount the pair sends, so the next pair takes the sell
+// tax from it instead of both taxes.
+func getOutputAmountAfterTax(pair Pair, inputAmount *TokenAmount,
+	getOutputAmount func(inputAmount *TokenAmount) (*TokenAmount, Pair, error)) (*TokenAmount, Pair, error) {
+	if !pair.InvolvesToken(inputAmount.Token) {
+		return nil, nil, ErrDiffToken
+	}
+	received := inputAmount
+	if token := pairToken(pair, inputAmount.Token); token != inputAmount.Token || inputAmount.hop != nil {
+		amount := inputAmount.Raw()
+		if inputAmount.hop != nil {
+			amount = inputAmount.hop
+		}
+		var err error
+		if received, err = NewTokenAmount(token, amount); err != nil {
+			return nil, nil, err
+		}
+	}
+	if received.Token.HasTax() {
+		var err error
+		if received, err = received.AfterSellTax(); err != nil {
+			return nil, nil, err
+		}
+		if received.Raw().Sign() == 0 {
+			return nil, nil, ErrInsufficientInputAmount
+		}
+	}
+	sent, next, err := getOutputAmount(received)
+	if err != nil {
+		return nil, nil, err
+	}
+	outputAmount, err := sent.AfterBuyTax()
+	if err != nil {
+		return nil, nil, err
+	}
+	if outputAmount.Raw().Sign() == 0 {
+		return nil, nil, ErrInsufficientInputAmount
+	}
+	if sent.Token.HasTax() {
+		outputAmount.hop = sent.Raw()
+	}
+	return outputAmount, next, nil
+}
+
+// getInputAmountAfterTax wraps the swap math of pair, the pair sends enough for the recipient to receive the
+// output after the buy tax, and the input is enough for the pair to receive what it needs after the sell tax.
+// An input of the next pair of a route already covers the sell tax, so the pair sends it as it is.
+func getInputAmountAfterTax(pair Pair, outputAmount *TokenAmount,
+	getInputAmount func(outputAmount *TokenAmount) (*TokenAmount, Pair, error)) (*TokenAmount, Pair, error) {
+	if !pair.InvolvesToken(outputAmount.Token) {
+		return nil, nil, ErrDiffToken
+	}
+	sent := outputAmount
+	if token := pairToken(pair, outputAmount.Token); token.HasTax() {
+		amount := outputAmount.hop
+		if amount == nil {
+			amount = untaxed(outputAmount.Raw(), token.Tax.Buy)
+		}
+		var err error
+		if sent, err = NewTokenAmount(token, amount); err != nil {
+			return nil, nil, err
+		}
+	}
+	inputAmount, next, err := getInputAmount(sent)
+	if err != nil {
+		return nil, nil, err
+	}
+	if inputAmount.Token.HasTax() {
+		if inputAmount, err = NewTokenAmount(inputAmount.Token, untaxed(inputAmount.Raw(), inputAmount.Token.Tax.Sell)); err != nil {
+			return nil, nil, err
+		}
+		inputAmount.hop = inputAmount.Raw()
+	}
+	return inputAmount, next, nil
+}
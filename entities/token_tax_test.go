@@ -0,0 +1,199 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+// nolint funlen
+func TestTransferTax(t *testing.T) {
+	weth := WETH[constants.Mainnet]
+	plain, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "TAX", "")
+	// 5% buy, 10% sell
+	tax, err := plain.WithTax(500, 1000, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tax.HasTax() || plain.HasTax() || !tax.Equals(plain) {
+		t.Fatal("only the copy should be taxed")
+	}
+	if _, err := plain.WithTax(10000, 0, 0); err != ErrInvalidTax {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidTax, err)
+	}
+
+	reserveTax, _ := NewTokenAmount(tax, mustUnits(1000000, 18))
+	reserveWETH, _ := NewTokenAmount(weth, mustUnits(1000, 18))
+	pair, _ := NewPair(reserveTax, reserveWETH)
+	untaxedReserve, _ := NewTokenAmount(plain, mustUnits(1000000, 18))
+	untaxedPair, _ := NewPair(untaxedReserve, reserveWETH)
+
+	// selling, the pair receives the input minus 10%
+	{
+		amountIn, _ := NewTokenAmount(plain, mustUnits(10000, 18))
+		received, _ := NewTokenAmount(plain, mustUnits(9000, 18))
+		expect, expectNext, _ := untaxedPair.GetOutputAmount(received)
+		got, next, err := pair.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Raw().Cmp(expect.Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), got.Raw())
+		}
+		reserve, _ := next.ReserveOf(tax)
+		expectReserve, _ := expectNext.ReserveOf(plain)
+		if reserve.Raw().Cmp(expectReserve.Raw()) != 0 || !reserve.Token.HasTax() {
+			t.Errorf("expect[%+v], but got[%+v]", expectReserve.Raw(), reserve.Raw())
+		}
+	}
+
+	// buying, the recipient receives the output minus 5%
+	{
+		amountIn, _ := NewTokenAmount(weth, mustUnits(1, 18))
+		expect, _, _ := untaxedPair.GetOutputAmount(amountIn)
+		got, _, err := pair.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := taxed(expect.Raw(), 500); got.Raw().Cmp(want) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", want, got.Raw())
+		}
+	}
+
+	// exact output is enough after the taxes
+	for _, amountOut := range []*TokenAmount{
+		mustTokenAmount(tax, mustUnits(5000, 18)),
+		mustTokenAmount(weth, mustUnits(3, 18)),
+	} {
+		amountIn, _, err := pair.GetInputAmount(amountOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := pair.GetOutputAmount(amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Raw().Cmp(amountOut.Raw()) < 0 {
+			t.Errorf("expect at least [%+v], but got[%+v]", amountOut.Raw(), got.Raw())
+		}
+	}
+
+	// trades and the best trade search report what the recipient receives
+	{
+		route, err := NewRoute([]Pair{pair}, weth, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !route.HasTransferTax() {
+			t.Error("route should have transfer tax")
+		}
+		amountIn, _ := NewTokenAmount(weth, mustUnits(1, 18))
+		trade, err := ExactIn(route, amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect, _, _ := pair.GetOutputAmount(amountIn)
+		if trade.OutputAmount().Raw().Cmp(expect.Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), trade.OutputAmount().Raw())
+		}
+
+		// a pair exempt from the taxes, on another factory, gives more
+		factory := NewFactory(common.HexToAddress("0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac"),
+			common.FromHex("0xe18a34eb0e04b04f7a0ac29a6e80748dca96319b42c520ef5f5ed8b14ec8d79d"), "SLP", "SushiSwap LP Token", 3, 1000)
+		other, _ := NewPairWithFactory(mustTokenAmount(plain, mustUnits(990000, 18)), reserveWETH, factory)
+		trades, err := BestTradeExactIn([]Pair{pair, other}, amountIn, plain, NewDefaultBestTradeOptions(), nil, amountIn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) != 2 || trades[0].Route.Pairs[0] != other {
+			t.Errorf("expect the untaxed pair first, but got[%+v]", trades)
+		}
+	}
+}
+
+// nolint funlen
+func TestTransferTaxRoute(t *testing.T) {
+	weth := WETH[constants.Mainnet]
+	plain, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "TAX", "")
+	usdc, _ := NewToken(constants.Mainnet, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "USD Coin")
+	// 5% buy, 10% sell
+	tax, _ := plain.WithTax(500, 1000, 100)
+
+	reserveWETH, _ := NewTokenAmount(weth, mustUnits(1000, 18))
+	reserveUSDC, _ := NewTokenAmount(usdc, mustUnits(2000000, 6))
+	wethTax, _ := NewPair(mustTokenAmount(tax, mustUnits(1000000, 18)), reserveWETH)
+	taxUSDC, _ := NewPair(mustTokenAmount(tax, mustUnits(1000000, 18)), reserveUSDC)
+	untaxedWETHTax, _ := NewPair(mustTokenAmount(plain, mustUnits(1000000, 18)), reserveWETH)
+	untaxedTaxUSDC, _ := NewPair(mustTokenAmount(plain, mustUnits(1000000, 18)), reserveUSDC)
+	route, err := NewRoute([]Pair{wethTax, taxUSDC}, weth, usdc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the taxed token moves from pair to pair once, so it only takes the sell tax
+	{
+		amountIn, _ := NewTokenAmount(weth, mustUnits(1, 18))
+		sent, _, _ := untaxedWETHTax.GetOutputAmount(amountIn)
+		received, _ := NewTokenAmount(plain, taxed(sent.Raw(), 1000))
+		expect, _, _ := untaxedTaxUSDC.GetOutputAmount(received)
+		trade, err := ExactIn(route, amountIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trade.OutputAmount().Raw().Cmp(expect.Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), trade.OutputAmount().Raw())
+		}
+	}
+	{
+		amountOut, _ := NewTokenAmount(usdc, mustUnits(1000, 6))
+		received, _, _ := untaxedTaxUSDC.GetInputAmount(amountOut)
+		sent, _ := NewTokenAmount(plain, untaxed(received.Raw(), 1000))
+		expect, _, _ := untaxedWETHTax.GetInputAmount(sent)
+		trade, err := ExactOut(route, amountOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trade.InputAmount().Raw().Cmp(expect.Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), trade.InputAmount().Raw())
+		}
+		// and the input is enough for the output
+		exactIn, err := ExactIn(route, trade.InputAmount())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exactIn.OutputAmount().Raw().Cmp(amountOut.Raw()) < 0 {
+			t.Errorf("expect at least [%+v], but got[%+v]", amountOut.Raw(), exactIn.OutputAmount().Raw())
+		}
+	}
+
+	// the best trade search chains the pairs the same way
+	{
+		amountIn, _ := NewTokenAmount(weth, mustUnits(1, 18))
+		trade, _ := ExactIn(route, amountIn)
+		trades, err := BestTradeExactIn([]Pair{wethTax, taxUSDC}, amountIn, usdc, NewDefaultBestTradeOptions(), nil, amountIn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) != 1 || trades[0].OutputAmount().Raw().Cmp(trade.OutputAmount().Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", trade.OutputAmount().Raw(), trades)
+		}
+	}
+}
+
+func TestTaxed(t *testing.T) {
+	for _, amount := range []int64{1, 9, 10, 11, 12345, 1e18} {
+		for _, tax := range []uint64{1, 300, 999, 5000, 9999} {
+			gross := untaxed(big.NewInt(amount), tax)
+			if got := taxed(gross, tax); got.Cmp(big.NewInt(amount)) < 0 {
+				t.Errorf("expect at least [%+v], but got[%+v]", amount, got)
+			}
+			less := new(big.Int).Sub(gross, constants.One)
+			if got := taxed(less, tax); got.Cmp(big.NewInt(amount)) >= 0 {
+				t.Errorf("[%+v] is not the least amount", gross)
+			}
+		}
+	}
+}
@@ -41,6 +41,10 @@ type Trade struct {
 	 * The percent difference between the mid price before the trade and the trade execution price.
 	 */
 	PriceImpact *Percent
+	/**
+	 * The pairs of the route after the trade executes, in the order of the route.
+	 */
+	nextPairs []Pair
 }
 
 func (t *Trade) InputAmount() *TokenAmount {
@@ -83,7 +87,7 @@ func NewTrade(route *Route, amount *TokenAmount, tradeType constants.TradeType)
 			return nil, ErrDiffToken
 		}
 
-		amounts[0] = amount
+		amounts[0] = amount.detached()
 		for i := 0; i < len(route.Path)-1; i++ {
 			outputAmount, nextPair, err := route.Pairs[i].GetOutputAmount(amounts[i])
 			if err != nil {
@@ -100,7 +104,7 @@ func NewTrade(route *Route, amount *TokenAmount, tradeType constants.TradeType)
 			return nil, ErrDiffToken
 		}
 
-		amounts[len(amounts)-1] = amount
+		amounts[len(amounts)-1] = amount.detached()
 		for i := len(route.Path) - 1; i > 0; i-- {
 			inputAmount, nextPair, err := route.Pairs[i-1].GetInputAmount(amounts[i])
 			if err != nil {
@@ -121,21 +125,26 @@ func NewTrade(route *Route, amount *TokenAmount, tradeType constants.TradeType)
 	}
 	inputAmount := amount
 	if tradeType == constants.ExactOutput {
-		inputAmount = amounts[0]
+		if inputAmount, err = nativeAmount(route.Input, amounts[0]); err != nil {
+			return nil, err
+		}
 	}
 	outputAmount := amount
 	if tradeType == constants.ExactInput {
-		outputAmount = amounts[len(amounts)-1]
+		if outputAmount, err = nativeAmount(route.Output, amounts[len(amounts)-1]); err != nil {
+			return nil, err
+		}
 	}
 	price := NewPrice(inputAmount.Currency, outputAmount.Currency, inputAmount.Raw(), outputAmount.Raw())
 	return &Trade{
 		Route:          route,
 		TradeType:      tradeType,
-		inputAmount:    inputAmount,
-		outputAmount:   outputAmount,
+		inputAmount:    inputAmount.detached(),
+		outputAmount:   outputAmount.detached(),
 		ExecutionPrice: price,
 		NextMidPrice:   nextMidPrice,
 		PriceImpact:    computePriceImpact(route.MidPrice, inputAmount, outputAmount),
+		nextPairs:      nextPairs,
 	}, nil
 }
 
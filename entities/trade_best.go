@@ -180,8 +180,8 @@ func BestTradeExactIn(
 
 		amountOut, _, err := pair.GetOutputAmount(amountIn)
 		if err != nil {
-			// input too low
-			if err == ErrInsufficientInputAmount {
+			// input too low or not enough liquidity in this pair
+			if err == ErrInsufficientInputAmount || err == ErrInsufficientReserves {
 				continue
 			}
 			return nil, err
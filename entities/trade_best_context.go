@@ -0,0 +1,200 @@
+package entities
+
+import (
+	"context"
+	"sync"
+)
+
+// SearchBudget limits the work of a route search
+type SearchBudget struct {
+	// maximum number of paths explored by a route search, or by all the route searches of a smart trade search,
+	// 0 means no limit. the best trades found so far are returned when the limit is hit
+	MaxPaths int64
+	// maximum number of concurrent searches, 0 and 1 mean searching sequentially.
+	// route searches split by first hop, smart trade searches split by percentage
+	Parallelism int
+
+	// number of paths explored, shared by the route searches of a smart trade search
+	explored *int64
+}
+
+func (b *SearchBudget) parallelism() int {
+	if b == nil || b.Parallelism < 1 {
+		return 1
+	}
+	return b.Parallelism
+}
+
+/**
+ * same as BestTradeExactIn, but stops when ctx is done or the budget is exhausted
+ * the best trades found before ctx is done are returned along with ctx.Err()
+ * @param ctx context of the search
+ * @param pairs the pairs to consider in finding the best trade
+ * @param currencyAmountIn exact amount of input currency to spend
+ * @param currencyOut the desired currency out
+ * @param options maximum number of results and hops
+ * @param budget limits of the search, nil means no limit
+ */
+func BestTradeExactInContext(
+	ctx context.Context,
+	pairs []Pair,
+	currencyAmountIn *TokenAmount,
+	currencyOut *Token,
+	options *BestTradeOptions,
+	budget *SearchBudget,
+) ([]*Trade, error) {
+	return NewPairGraph(pairs).BestTradeExactInContext(ctx, currencyAmountIn, currencyOut, options, budget)
+}
+
+/**
+ * same as BestTradeExactOut, but stops when ctx is done or the budget is exhausted
+ * the best trades found before ctx is done are returned along with ctx.Err()
+ * @param ctx context of the search
+ * @param pairs the pairs to consider in finding the best trade
+ * @param currencyIn the currency to spend
+ * @param currencyAmountOut the exact amount of currency out
+ * @param options maximum number of results and hops
+ * @param budget limits of the search, nil means no limit
+ */
+func BestTradeExactOutContext(
+	ctx context.Context,
+	pairs []Pair,
+	currencyIn *Token,
+	currencyAmountOut *TokenAmount,
+	options *BestTradeOptions,
+	budget *SearchBudget,
+) ([]*Trade, error) {
+	return NewPairGraph(pairs).BestTradeExactOutContext(ctx, currencyIn, currencyAmountOut, options, budget)
+}
+
+// BestTradeExactInContext same as PairGraph.BestTradeExactIn, but stops when ctx is done or the budget is exhausted
+func (g *PairGraph) BestTradeExactInContext(
+	ctx context.Context,
+	currencyAmountIn *TokenAmount,
+	currencyOut *Token,
+	options *BestTradeOptions,
+	budget *SearchBudget,
+) ([]*Trade, error) {
+	s, err := g.newSearch(ctx, options, budget)
+	if err != nil {
+		return nil, err
+	}
+	if budget.parallelism() > 1 {
+		err = s.parallel(g.adjacent[newTokenKey(currencyAmountIn.Token)], budget.parallelism(), func(b *graphSearch, slot int) error {
+			return b.exactInThrough(slot, currencyAmountIn, currencyAmountIn, currencyOut, s.options.MaxHops)
+		})
+	} else {
+		err = s.exactIn(currencyAmountIn, currencyAmountIn, currencyOut, s.options.MaxHops)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.trades, ctx.Err()
+}
+
+// BestTradeExactOutContext same as PairGraph.BestTradeExactOut, but stops when ctx is done or the budget is exhausted
+func (g *PairGraph) BestTradeExactOutContext(
+	ctx context.Context,
+	currencyIn *Token,
+	currencyAmountOut *TokenAmount,
+	options *BestTradeOptions,
+	budget *SearchBudget,
+) ([]*Trade, error) {
+	s, err := g.newSearch(ctx, options, budget)
+	if err != nil {
+		return nil, err
+	}
+	if budget.parallelism() > 1 {
+		err = s.parallel(g.adjacent[newTokenKey(currencyAmountOut.Token)], budget.parallelism(), func(b *graphSearch, slot int) error {
+			return b.exactOutThrough(slot, currencyIn, currencyAmountOut, currencyAmountOut, s.options.MaxHops)
+		})
+	} else {
+		err = s.exactOut(currencyIn, currencyAmountOut, currencyAmountOut, s.options.MaxHops)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.trades, ctx.Err()
+}
+
+// parallel searches the first hop branches concurrently, then merges their trades in the order of the branches so
+// the results are the same as searching sequentially
+func (s *graphSearch) parallel(slots []int, parallelism int, visit func(b *graphSearch, slot int) error) error {
+	branches := make([]*graphSearch, len(slots))
+	errs := make([]error, len(slots))
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, slot := range slots {
+		if s.exhausted() {
+			break
+		}
+		branches[i] = s.branch()
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(i, slot int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			errs[i] = visit(branches[i], slot)
+		}(i, slot)
+	}
+	wg.Wait()
+
+	for i, branch := range branches {
+		if branch == nil {
+			continue
+		}
+		if errs[i] != nil {
+			return errs[i]
+		}
+		for _, trade := range branch.trades {
+			var err error
+			s.trades, _, err = SortedInsert(s.trades, trade, s.options.MaxNumResults, TradeComparator)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// searchPercentages runs the search of every percentage, concurrently up to parallelism, or inline if it is 1.
+// the trades of the percentages searched before ctx is done are returned along with ctx.Err()
+func searchPercentages(
+	ctx context.Context,
+	percents []int,
+	parallelism int,
+	search func(ctx context.Context, i int) ([]*Trade, error),
+) (map[int][]*Trade, error) {
+	results := make([][]*Trade, len(percents))
+	errs := make([]error, len(percents))
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := range percents {
+		if ctx.Err() != nil {
+			break
+		}
+		if parallelism <= 1 {
+			results[i], errs[i] = search(ctx, i)
+			continue
+		}
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[i], errs[i] = search(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	percentToTrades := make(map[int][]*Trade)
+	for i, percent := range percents {
+		if errs[i] != nil && errs[i] != ctx.Err() {
+			return nil, errs[i]
+		}
+		if results[i] != nil {
+			percentToTrades[percent] = results[i]
+		}
+	}
+	return percentToTrades, ctx.Err()
+}
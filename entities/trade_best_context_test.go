@@ -0,0 +1,140 @@
+package entities
+
+import (
+	"context"
+	"testing"
+)
+
+// nolint funlen
+func TestBestTradeContext(t *testing.T) {
+	tokens, pairs := randomPairs(12, 40)
+	options := &BestTradeOptions{MaxNumResults: 5, MaxHops: 3}
+	amountIn, _ := NewTokenAmount(tokens[0], mustEther(100))
+	amountOut, _ := NewTokenAmount(tokens[11], mustEther(100))
+
+	// parallel search gives the same results as sequential search
+	{
+		parallel := &SearchBudget{Parallelism: 4}
+		expect, err := BestTradeExactIn(pairs, amountIn, tokens[11], options, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := BestTradeExactInContext(context.Background(), pairs, amountIn, tokens[11], options, parallel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameTrades(t, expect, got)
+
+		expect, err = BestTradeExactOut(pairs, tokens[0], amountOut, options, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err = BestTradeExactOutContext(context.Background(), pairs, tokens[0], amountOut, options, parallel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameTrades(t, expect, got)
+	}
+
+	// returns the best trades found within the path budget
+	{
+		all, err := BestTradeExactInContext(context.Background(), pairs, amountIn, tokens[11], &BestTradeOptions{MaxNumResults: 100, MaxHops: 3}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, parallelism := range []int{1, 4} {
+			budget := &SearchBudget{MaxPaths: 20, Parallelism: parallelism}
+			got, err := BestTradeExactInContext(context.Background(), pairs, amountIn, tokens[11], &BestTradeOptions{MaxNumResults: 100, MaxHops: 3}, budget)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) == 0 || len(got) >= len(all) {
+				t.Errorf("expect less than [%+v] trades, but got[%+v]", len(all), len(got))
+			}
+		}
+	}
+
+	// stops when the context is done
+	{
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		got, err := BestTradeExactInContext(ctx, pairs, amountIn, tokens[11], options, &SearchBudget{Parallelism: 4})
+		if err != context.Canceled {
+			t.Errorf("expect[%+v], but got[%+v]", context.Canceled, err)
+		}
+		if len(got) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", 0, len(got))
+		}
+	}
+}
+
+func TestBestSmartTradeContext(t *testing.T) {
+	tokens, pairs := randomPairs(12, 40)
+	amountIn, _ := NewTokenAmount(tokens[0], mustEther(1000))
+	options := &BestSmartTradeOptions{
+		BestTradeOptions:        *NewDefaultBestTradeOptions(),
+		MaxSplit:                3,
+		MaxSmartTradeNumResults: 3,
+	}
+	expect, err := BestSmartTradeExactIn(pairs, amountIn, tokens[11], options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parallelOptions := *options
+	parallelOptions.Budget = SearchBudget{Parallelism: 4}
+	got, err := BestSmartTradeExactInContext(context.Background(), pairs, amountIn, tokens[11], &parallelOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expect) != len(got) {
+		t.Fatalf("expect[%+v], but got[%+v]", len(expect), len(got))
+	}
+	for i := range expect {
+		if !expect[i].OutputAmount().Equals(got[i].OutputAmount()) {
+			t.Errorf("expect[%+v], but got[%+v]", expect[i].OutputAmount().Raw(), got[i].OutputAmount().Raw())
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := BestSmartTradeExactInContext(ctx, pairs, amountIn, tokens[11], &parallelOptions); err != context.Canceled {
+		t.Errorf("expect[%+v], but got[%+v]", context.Canceled, err)
+	}
+}
+
+func TestSearchPercentagesBudget(t *testing.T) {
+	tokens, pairs := randomPairs(12, 40)
+	amountIn, _ := NewTokenAmount(tokens[0], mustEther(1000))
+	percents, amounts, err := getAmountDistribution(amountIn, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// searched inline and in order without parallelism
+	var order []int
+	if _, err := searchPercentages(context.Background(), percents, 1, func(ctx context.Context, i int) ([]*Trade, error) {
+		order = append(order, i)
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range percents {
+		if order[i] != i {
+			t.Fatalf("expect[%+v], but got[%+v]", i, order[i])
+		}
+	}
+
+	// the path budget is for the whole search, the first percentages use it up
+	options := &BestSmartTradeOptions{BestTradeOptions: *NewDefaultBestTradeOptions(), Budget: SearchBudget{MaxPaths: 20}}
+	percentToTrades, err := getPercentageToTrades(context.Background(), percents, amounts, pairs, tokens[11], options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(percentToTrades[percents[0]]) == 0 {
+		t.Errorf("expect trades of [%+v]", percents[0])
+	}
+	if trades := percentToTrades[percents[len(percents)-1]]; len(trades) != 0 {
+		t.Errorf("expect[%+v], but got[%+v]", 0, len(trades))
+	}
+}
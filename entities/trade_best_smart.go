@@ -1,7 +1,9 @@
 package entities
 
 import (
+	"context"
 	"math/big"
+	"sort"
 
 	"github.com/xiang-xx/uniswap-sdk-go/constants"
 )
@@ -19,6 +21,21 @@ type BestSmartTradeOptions struct {
 
 	// how many results to return
 	MaxSmartTradeNumResults int
+
+	// rank results by output net of gas cost (input plus gas cost for exact output), nil means ignore gas
+	GasModel *GasModel
+
+	// exact input only, allocate the input among up to MaxSplit routes that do not share pairs by equalizing
+	// their marginal outputs instead of trying multiples of SplitPercentage, Percents of the results are approximate
+	OptimalSplit bool
+
+	// let legs of a SmartTrade go through the same pairs, each leg is then simulated against the pairs as left by
+	// the previous legs, so the amounts are what executes on-chain when the legs are swapped in order.
+	// ignored by OptimalSplit
+	AllowSharedPairs bool
+
+	// limits the route searches of the context variants
+	Budget SearchBudget
 }
 
 func BestSmartTradeExactIn(
@@ -26,33 +43,130 @@ func BestSmartTradeExactIn(
 	currencyAmountIn *TokenAmount,
 	currencyOut *Token,
 	options *BestSmartTradeOptions) ([]*SmartTrade, error) {
+	return BestSmartTradeExactInContext(context.Background(), pairs, currencyAmountIn, currencyOut, options)
+}
+
+/**
+ * same as BestSmartTradeExactIn, but searches the routes of the percentages concurrently up to
+ * options.Budget.Parallelism, and stops when ctx is done or options.Budget is exhausted
+ * the best smart trades combined from the routes found before ctx is done are returned along with ctx.Err()
+ */
+func BestSmartTradeExactInContext(
+	ctx context.Context,
+	pairs []Pair,
+	currencyAmountIn *TokenAmount,
+	currencyOut *Token,
+	options *BestSmartTradeOptions) ([]*SmartTrade, error) {
+	splitPercentage, err := validateSmartTradeOptions(pairs, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.GasModel != nil && !options.GasModel.NativePrice.QuoteCurrency.Equals(currencyOut.Currency) {
+		return nil, ErrInvalidCurrency
+	}
+	if options.OptimalSplit {
+		return bestSmartTradeOptimalExactIn(ctx, pairs, currencyAmountIn, currencyOut, options)
+	}
+
+	percents, amounts, err := getAmountDistribution(currencyAmountIn, splitPercentage)
+	if err != nil {
+		return nil, err
+	}
+
+	percentToTrades, searchErr := getPercentageToTrades(ctx, percents, amounts, pairs, currencyOut, options)
+	if searchErr != nil && searchErr != ctx.Err() {
+		return nil, searchErr
+	}
+	smartTrades, err := bestSmartTrades(percents, percentToTrades, currencyAmountIn, constants.ExactInput, options)
+	if err != nil {
+		return nil, err
+	}
+	return smartTrades, searchErr
+}
+
+/**
+ * similar to BestSmartTradeExactIn but instead targets a fixed output amount
+ * splits the output amount among routes that do not share pairs (unless AllowSharedPairs), and minimizes the total
+ * input amount
+ * when routing with a GasModel, its NativePrice must be quoted in the input token
+ */
+func BestSmartTradeExactOut(
+	pairs []Pair,
+	currencyIn *Token,
+	currencyAmountOut *TokenAmount,
+	options *BestSmartTradeOptions) ([]*SmartTrade, error) {
+	return BestSmartTradeExactOutContext(context.Background(), pairs, currencyIn, currencyAmountOut, options)
+}
+
+/**
+ * same as BestSmartTradeExactOut, but searches the routes of the percentages concurrently up to
+ * options.Budget.Parallelism, and stops when ctx is done or options.Budget is exhausted
+ * the best smart trades combined from the routes found before ctx is done are returned along with ctx.Err()
+ */
+func BestSmartTradeExactOutContext(
+	ctx context.Context,
+	pairs []Pair,
+	currencyIn *Token,
+	currencyAmountOut *TokenAmount,
+	options *BestSmartTradeOptions) ([]*SmartTrade, error) {
+	splitPercentage, err := validateSmartTradeOptions(pairs, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.GasModel != nil && !options.GasModel.NativePrice.QuoteCurrency.Equals(currencyIn.Currency) {
+		return nil, ErrInvalidCurrency
+	}
+
+	percents, amounts, err := getAmountDistribution(currencyAmountOut, splitPercentage)
+	if err != nil {
+		return nil, err
+	}
+
+	percentToTrades, searchErr := getPercentageToTradesExactOut(ctx, percents, amounts, pairs, currencyIn, options)
+	if searchErr != nil && searchErr != ctx.Err() {
+		return nil, searchErr
+	}
+	smartTrades, err := bestSmartTrades(percents, percentToTrades, currencyAmountOut, constants.ExactOutput, options)
+	if err != nil {
+		return nil, err
+	}
+	return smartTrades, searchErr
+}
+
+func validateSmartTradeOptions(pairs []Pair, options *BestSmartTradeOptions) (int, error) {
 	if nil == options {
-		return nil, ErrInvalidOption
+		return 0, ErrInvalidOption
 	}
 	if len(pairs) == 0 {
-		return nil, ErrInvalidPairs
+		return 0, ErrInvalidPairs
 	}
 	splitPercentage := 10
 	if options.SplitPercentage > 0 {
-		if splitPercentage < 0 || splitPercentage > 100 {
-			return nil, ErrInvalidOption
+		if options.SplitPercentage > 100 {
+			return 0, ErrInvalidOption
 		}
 		if (100/options.SplitPercentage)*options.SplitPercentage != 100 {
-			return nil, ErrInvalidOption
+			return 0, ErrInvalidOption
 		}
+		splitPercentage = options.SplitPercentage
 	}
+	return splitPercentage, nil
+}
 
-	percents, amounts, err := getAmountDistribution(currencyAmountIn, splitPercentage)
-	if err != nil {
-		return nil, err
+// bestSmartTrades BFS combines the best trades of every percentage into SmartTrades of the fixed amount
+func bestSmartTrades(percents []int, percentToTrades map[int][]*Trade, amount *TokenAmount, tradeType constants.TradeType,
+	options *BestSmartTradeOptions) ([]*SmartTrade, error) {
+	comparator := SmartTradeComparator
+	if tradeType == constants.ExactOutput {
+		comparator = SmartTradeExactOutComparator
 	}
 
-	percentToTrades, err := getPercentageToTrades(percents, amounts, pairs, currencyOut, options)
-	if err != nil {
-		return nil, err
+	if options.GasModel != nil {
+		for _, trades := range percentToTrades {
+			sortTradesByGas(trades, options.GasModel, tradeType)
+		}
 	}
 
-	// BFS to get SmartTrades
 	smartTrades := make([]*SmartTrade, 0)
 	queue := make([]tradesWithPercent, 0)
 	for i, percent := range percents {
@@ -62,24 +176,26 @@ func BestSmartTradeExactIn(
 		}
 
 		if percent == 100 {
-			smartTrade := &SmartTrade{
-				Percents:     []int{100},
-				Trades:       []*Trade{trades[0]},
-				outputAmount: trades[0].outputAmount,
-				inputAmount:  trades[0].inputAmount,
+			smartTrade, err := newSmartTrade([]int{100}, []*Trade{trades[0]}, tradeType, options.GasModel)
+			if err != nil {
+				return nil, err
 			}
-			smartTrades, _, err = SortedInsert(smartTrades, smartTrade, options.MaxSmartTradeNumResults, SmartTradeComparator)
+			smartTrades, _, err = SortedInsert(smartTrades, smartTrade, options.MaxSmartTradeNumResults, comparator)
 			if err != nil {
 				return nil, err
 			}
 		} else {
-			queue = append(queue, tradesWithPercent{
+			item := tradesWithPercent{
 				RemainPercent: 100 - percent,
 				PercentIndex:  i,
 				Percents:      []int{percent},
 				Trades:        []*Trade{trades[0]},
 				CurrentPairs:  trades[0].Route.Pairs,
-			})
+			}
+			if options.AllowSharedPairs {
+				item.PairStates = pairStates{}.next(trades[0])
+			}
+			queue = append(queue, item)
 		}
 	}
 
@@ -98,36 +214,36 @@ func BestSmartTradeExactIn(
 				if !ok {
 					continue
 				}
-				matchedTrade := findFirstTradeNotUsingPair(item.CurrentPairs, trades)
+				var (
+					matchedTrade *Trade
+					nextStates   pairStates
+				)
+				if options.AllowSharedPairs {
+					matchedTrade, nextStates = findBestTradeOnPairStates(item.Trades, item.PairStates, trades)
+				} else {
+					matchedTrade = findFirstTradeNotUsingPair(item.CurrentPairs, trades)
+				}
 				if matchedTrade == nil {
 					continue
 				}
 
 				remainPerent := item.RemainPercent - percentA
-				currentPairs := append(item.CurrentPairs, matchedTrade.Route.Pairs...)
-				currentPercents := append(item.Percents, percentA)
-				currentTrades := append(item.Trades, matchedTrade)
+				// copy, siblings must not share the backing arrays
+				currentPairs := append(append([]Pair{}, item.CurrentPairs...), matchedTrade.Route.Pairs...)
+				currentPercents := append(append([]int{}, item.Percents...), percentA)
+				currentTrades := append(append([]*Trade{}, item.Trades...), matchedTrade)
 
 				if remainPerent == 0 {
-					outputAmount := currentTrades[0].OutputAmount()
-					inputAmount := currentTrades[0].InputAmount()
-					for k := 1; k < len(currentTrades); k++ {
-						outputAmount, err = outputAmount.Add(currentTrades[k].OutputAmount())
-						if err != nil {
-							return nil, err
-						}
-						inputAmount, err = inputAmount.Add(currentTrades[k].InputAmount())
-						if err != nil {
-							return nil, err
-						}
+					currentTrades, err := withRemainder(currentTrades, amount, tradeType)
+					if err != nil {
+						// the last leg can not take the remainder
+						continue
 					}
-					smartTrade := &SmartTrade{
-						Percents:     currentPercents,
-						Trades:       currentTrades,
-						outputAmount: outputAmount,
-						inputAmount:  inputAmount,
+					smartTrade, err := newSmartTrade(currentPercents, currentTrades, tradeType, options.GasModel)
+					if err != nil {
+						return nil, err
 					}
-					smartTrades, _, err = SortedInsert(smartTrades, smartTrade, options.MaxSmartTradeNumResults, SmartTradeComparator)
+					smartTrades, _, err = SortedInsert(smartTrades, smartTrade, options.MaxSmartTradeNumResults, comparator)
 					if err != nil {
 						return nil, err
 					}
@@ -138,6 +254,7 @@ func BestSmartTradeExactIn(
 						CurrentPairs:  currentPairs,
 						RemainPercent: remainPerent,
 						PercentIndex:  i,
+						PairStates:    nextStates,
 					})
 				}
 			}
@@ -154,7 +271,8 @@ func findFirstTradeNotUsingPair(currentPairs []Pair, trades []*Trade) *Trade {
 	outer:
 		for _, tradePair := range trade.Route.Pairs {
 			for _, pair := range currentPairs {
-				if tradePair.Equal(pair) {
+				// pools of the same tokens compare by their pool address on either side
+				if tradePair.Equal(pair) && pair.Equal(tradePair) {
 					existPair = true
 					break outer
 				}
@@ -167,42 +285,161 @@ func findFirstTradeNotUsingPair(currentPairs []Pair, trades []*Trade) *Trade {
 	return nil
 }
 
-func getPercentageToTrades(percents []int, amounts []*TokenAmount, pairs []Pair, currencyOut *Token, options *BestSmartTradeOptions) (map[int][]*Trade, error) {
-	percentToTrades := make(map[int][]*Trade)
-	for i, percent := range percents {
-		amount := amounts[i]
+func getPercentageToTrades(
+	ctx context.Context,
+	percents []int,
+	amounts []*TokenAmount,
+	pairs []Pair,
+	currencyOut *Token,
+	options *BestSmartTradeOptions) (map[int][]*Trade, error) {
+	graph := NewPairGraph(pairs)
+	budget := percentageBudget(options)
+	return searchPercentages(ctx, percents, options.Budget.parallelism(), func(ctx context.Context, i int) ([]*Trade, error) {
+		return graph.BestTradeExactInContext(ctx, amounts[i], currencyOut, &options.BestTradeOptions, budget)
+	})
+}
 
-		trades, err := BestTradeExactIn(pairs, amount, currencyOut, &options.BestTradeOptions, nil, amount, nil)
-		if err != nil {
-			return nil, err
-		}
-		percentToTrades[percent] = trades
-	}
-	return percentToTrades, nil
+func getPercentageToTradesExactOut(
+	ctx context.Context,
+	percents []int,
+	amounts []*TokenAmount,
+	pairs []Pair,
+	currencyIn *Token,
+	options *BestSmartTradeOptions) (map[int][]*Trade, error) {
+	graph := NewPairGraph(pairs)
+	budget := percentageBudget(options)
+	return searchPercentages(ctx, percents, options.Budget.parallelism(), func(ctx context.Context, i int) ([]*Trade, error) {
+		return graph.BestTradeExactOutContext(ctx, currencyIn, amounts[i], &options.BestTradeOptions, budget)
+	})
+}
+
+// percentageBudget returns the budget shared by the route searches of the percentages, which run concurrently
+// themselves, so MaxPaths limits the paths of the whole smart trade search
+func percentageBudget(options *BestSmartTradeOptions) *SearchBudget {
+	return &SearchBudget{MaxPaths: options.Budget.MaxPaths, explored: new(int64)}
 }
 
-func getAmountDistribution(currencyAmountOut *TokenAmount, splitPercentage int) ([]int, []*TokenAmount, error) {
+// getAmountDistribution returns the multiples of splitPercentage and their parts of the amount, rounded down,
+// withRemainder gives the rounded off remainder to a leg
+func getAmountDistribution(currencyAmount *TokenAmount, splitPercentage int) ([]int, []*TokenAmount, error) {
 	percents := make([]int, 0)
 	amounts := make([]*TokenAmount, 0)
-	inputAmount := currencyAmountOut.Raw()
+	amount := currencyAmount.Raw()
 	for i := 1; i <= 100/splitPercentage; i++ {
 		percents = append(percents, i*splitPercentage)
-		currencyAmount, err := NewCurrencyAmount(currencyAmountOut.Currency, new(big.Int).Div(
-			new(big.Int).Mul(inputAmount, big.NewInt(int64(i*splitPercentage))),
-			constants.B100,
-		))
+		numerator := new(big.Int).Mul(amount, big.NewInt(int64(i*splitPercentage)))
+		partAmount, err := NewCurrencyAmount(currencyAmount.Currency, new(big.Int).Div(numerator, constants.B100))
 		if err != nil {
 			return nil, nil, err
 		}
 		amounts = append(amounts, &TokenAmount{
-			CurrencyAmount: currencyAmount,
-			Token:          currencyAmountOut.Token,
+			CurrencyAmount: partAmount,
+			Token:          currencyAmount.Token,
 		})
 	}
 	return percents, amounts, nil
 }
 
+// withRemainder returns the legs with the last leg taking the remainder of the fixed amount rounded off by
+// getAmountDistribution, so the legs add up exactly to the amount. The last leg is routed through the pairs as left
+// by the previous legs, so it is traded again on its own route.
+func withRemainder(trades []*Trade, amount *TokenAmount, tradeType constants.TradeType) ([]*Trade, error) {
+	fixedAmount := func(trade *Trade) *TokenAmount {
+		if tradeType == constants.ExactInput {
+			return trade.InputAmount()
+		}
+		return trade.OutputAmount()
+	}
+	remainder := new(big.Int).Set(amount.Raw())
+	for _, trade := range trades {
+		remainder.Sub(remainder, fixedAmount(trade).Raw())
+	}
+	if remainder.Sign() == 0 {
+		return trades, nil
+	}
+
+	last := trades[len(trades)-1]
+	lastAmount, err := NewTokenAmount(fixedAmount(last).Token, new(big.Int).Add(fixedAmount(last).Raw(), remainder))
+	if err != nil {
+		return nil, err
+	}
+	trade, err := NewTrade(last.Route, lastAmount, tradeType)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]*Trade{}, trades[:len(trades)-1]...), trade), nil
+}
+
+// sortTradesByGas stable sorts trades of the same fixed amount by output net of their gas cost for exact input,
+// or by input plus their gas cost for exact output
+func sortTradesByGas(trades []*Trade, gasModel *GasModel, tradeType constants.TradeType) {
+	adjusted := make(map[*Trade]*big.Int, len(trades))
+	for _, trade := range trades {
+		gasCost := gasModel.NativePrice.Raw().Multiply(NewFraction(
+			new(big.Int).Mul(new(big.Int).SetUint64(gasModel.EstimateGas(trade)), gasModel.GasPrice), nil)).Quotient()
+		if tradeType == constants.ExactInput {
+			adjusted[trade] = new(big.Int).Sub(trade.OutputAmount().Raw(), gasCost)
+		} else {
+			// negate so that larger is better for both trade types
+			adjusted[trade] = new(big.Int).Neg(new(big.Int).Add(trade.InputAmount().Raw(), gasCost))
+		}
+	}
+	sort.SliceStable(trades, func(i, j int) bool {
+		return adjusted[trades[i]].Cmp(adjusted[trades[j]]) > 0
+	})
+}
+
 // extension of the input output comparator that also considers other dimensions of the trade in ranking them
+// when both trades are routed with a GasModel, outputs net of gas (inputs plus gas for exact output) are compared first
 func SmartTradeComparator(a, b *SmartTrade) int {
-	return InputOutputComparator(a, b)
+	if a.gasAdjustedOutputAmount != nil && b.gasAdjustedOutputAmount != nil &&
+		!a.gasAdjustedOutputAmount.EqualTo(b.gasAdjustedOutputAmount.Fraction) {
+		// trade A has more output net of gas, so A should come first
+		if a.gasAdjustedOutputAmount.GreaterThan(b.gasAdjustedOutputAmount.Fraction) {
+			return -1
+		}
+		return 1
+	}
+	if a.gasAdjustedInputAmount != nil && b.gasAdjustedInputAmount != nil &&
+		!a.gasAdjustedInputAmount.EqualTo(b.gasAdjustedInputAmount.Fraction) {
+		// trade A costs less input with gas, so A should come first
+		if a.gasAdjustedInputAmount.LessThan(b.gasAdjustedInputAmount.Fraction) {
+			return -1
+		}
+		return 1
+	}
+
+	ioComp := InputOutputComparator(a, b)
+	if ioComp != 0 {
+		return ioComp
+	}
+	return gasComparator(a, b)
+}
+
+// comparator of smart trades of the same output amount, i.e. exact output, by their inputs plus gas cost when both
+// are routed with a GasModel, otherwise by their inputs, in increasing order, and then by the gas used
+func SmartTradeExactOutComparator(a, b *SmartTrade) int {
+	inputA, inputB := a.inputAmount, b.inputAmount
+	if a.gasAdjustedInputAmount != nil && b.gasAdjustedInputAmount != nil {
+		inputA, inputB = a.gasAdjustedInputAmount, b.gasAdjustedInputAmount
+	}
+	if !inputA.EqualTo(inputB.Fraction) {
+		// trade A costs less input, so A should come first
+		if inputA.LessThan(inputB.Fraction) {
+			return -1
+		}
+		return 1
+	}
+	return gasComparator(a, b)
+}
+
+// gasComparator ranks the smart trade using less gas first
+func gasComparator(a, b *SmartTrade) int {
+	if a.EstimatedGas < b.EstimatedGas {
+		return -1
+	}
+	if a.EstimatedGas > b.EstimatedGas {
+		return 1
+	}
+	return 0
 }
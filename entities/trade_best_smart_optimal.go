@@ -0,0 +1,321 @@
+package entities
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+const (
+	floatPrecision = 256
+	// maximum hill climbing iterations when equalizing marginal outputs
+	maxOptimizeIterations = 4096
+)
+
+// bestSmartTradeOptimalExactIn allocates the input among non-overlapping candidate routes by equalizing their
+// marginal outputs, instead of trying multiples of SplitPercentage.
+// The allocation starts from the closed form solution when every pair of the routes is a ClassicPair, and is then
+// refined numerically against the exact pair math, so the split is exact to the wei.
+// One SmartTrade is returned for each prefix of the candidate routes, i.e. the optimal split among the best route,
+// among the best two routes, and so on.
+func bestSmartTradeOptimalExactIn(
+	ctx context.Context,
+	pairs []Pair,
+	currencyAmountIn *TokenAmount,
+	currencyOut *Token,
+	options *BestSmartTradeOptions) ([]*SmartTrade, error) {
+	maxSplit := options.MaxSplit
+	if maxSplit < 1 {
+		maxSplit = 1
+	}
+	candidateOptions := options.BestTradeOptions
+	if candidateOptions.MaxNumResults < 3*maxSplit {
+		candidateOptions.MaxNumResults = 3 * maxSplit
+	}
+	trades, searchErr := BestTradeExactInContext(ctx, pairs, currencyAmountIn, currencyOut, &candidateOptions, &options.Budget)
+	if searchErr != nil && searchErr != ctx.Err() {
+		return nil, searchErr
+	}
+
+	// pick the best routes that do not share pairs
+	routes := make([]*Route, 0, maxSplit)
+	usedPairs := make([]Pair, 0)
+	for len(routes) < maxSplit {
+		trade := findFirstTradeNotUsingPair(usedPairs, trades)
+		if trade == nil {
+			break
+		}
+		routes = append(routes, trade.Route)
+		usedPairs = append(usedPairs, trade.Route.Pairs...)
+	}
+
+	smartTrades := make([]*SmartTrade, 0)
+	for k := 1; k <= len(routes); k++ {
+		amounts := optimizeAllocation(routes[:k], currencyAmountIn.Raw())
+		smartTrade, err := newSmartTradeFromAllocation(routes[:k], amounts, currencyAmountIn, options.GasModel)
+		if err != nil {
+			return nil, err
+		}
+		smartTrades, _, err = SortedInsert(smartTrades, smartTrade, options.MaxSmartTradeNumResults, SmartTradeComparator)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return smartTrades, searchErr
+}
+
+func newSmartTradeFromAllocation(routes []*Route, amounts []*big.Int, currencyAmountIn *TokenAmount, gasModel *GasModel) (*SmartTrade, error) {
+	percents := make([]int, 0, len(routes))
+	trades := make([]*Trade, 0, len(routes))
+	largest, total := 0, 0
+	for i, route := range routes {
+		if amounts[i].Sign() == 0 {
+			continue
+		}
+		amountIn, err := NewTokenAmount(currencyAmountIn.Token, amounts[i])
+		if err != nil {
+			return nil, err
+		}
+		trade, err := NewTrade(route, amountIn, constants.ExactInput)
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, trade)
+		// approximate, the exact split is the input amount of each trade
+		percent := new(big.Int).Mul(amounts[i], constants.B100)
+		percent.Div(percent, currencyAmountIn.Raw())
+		if amounts[i].Cmp(trades[largest].InputAmount().Raw()) > 0 {
+			largest = len(trades) - 1
+		}
+		percents = append(percents, int(percent.Int64()))
+		total += percents[len(percents)-1]
+	}
+	// the percents are rounded down, the largest leg takes the rest of 100
+	if len(percents) > 0 {
+		percents[largest] += 100 - total
+	}
+	return newSmartTrade(percents, trades, constants.ExactInput, gasModel)
+}
+
+// optimizeAllocation splits amountIn among routes so that their marginal outputs are equal, the returned amounts
+// add up to amountIn exactly
+func optimizeAllocation(routes []*Route, amountIn *big.Int) []*big.Int {
+	amounts, ok := classicAllocation(routes, amountIn)
+	// the closed form solution is only off by rounding, so start hill climbing from a small step
+	step := new(big.Int).Rsh(amountIn, 20)
+	if !ok {
+		amounts = make([]*big.Int, len(routes))
+		for i := range routes {
+			amounts[i] = new(big.Int).Div(amountIn, big.NewInt(int64(len(routes))))
+		}
+		step = new(big.Int).Div(amountIn, big.NewInt(int64(2*len(routes))))
+	}
+	settleRemainder(amounts, amountIn)
+	if len(routes) == 1 {
+		return amounts
+	}
+	if step.Sign() == 0 {
+		step.SetInt64(1)
+	}
+
+	outputs := make([]*big.Int, len(routes))
+	for i, route := range routes {
+		outputs[i] = routeOutput(route, amounts[i])
+	}
+
+	// move step from the route with the lowest marginal output to the one with the highest, while it increases the
+	// total output, then halve the step. this converges to equal marginal outputs since outputs are concave.
+	for iteration := 0; iteration < maxOptimizeIterations && step.Sign() > 0; iteration++ {
+		var (
+			bestGain          = new(big.Int)
+			bestFrom, bestTo  = -1, -1
+			bestFromOutput    *big.Int
+			bestToOutput      *big.Int
+			decreasedOutputs  = make([]*big.Int, len(routes))
+			increasedOutputs  = make([]*big.Int, len(routes))
+			decreasedAmounts  = make([]*big.Int, len(routes))
+			increasedAmounts  = make([]*big.Int, len(routes))
+			canDecreaseAmount = make([]bool, len(routes))
+		)
+		for i, route := range routes {
+			if amounts[i].Cmp(step) >= 0 {
+				canDecreaseAmount[i] = true
+				decreasedAmounts[i] = new(big.Int).Sub(amounts[i], step)
+				decreasedOutputs[i] = routeOutput(route, decreasedAmounts[i])
+			}
+			increasedAmounts[i] = new(big.Int).Add(amounts[i], step)
+			increasedOutputs[i] = routeOutput(route, increasedAmounts[i])
+		}
+		for from := range routes {
+			if !canDecreaseAmount[from] {
+				continue
+			}
+			for to := range routes {
+				if from == to {
+					continue
+				}
+				// gain = (out_to(x+step) - out_to(x)) - (out_from(x) - out_from(x-step))
+				gain := new(big.Int).Sub(increasedOutputs[to], outputs[to])
+				gain.Sub(gain, new(big.Int).Sub(outputs[from], decreasedOutputs[from]))
+				if gain.Cmp(bestGain) > 0 {
+					bestGain, bestFrom, bestTo = gain, from, to
+					bestFromOutput, bestToOutput = decreasedOutputs[from], increasedOutputs[to]
+				}
+			}
+		}
+		if bestFrom < 0 {
+			step.Rsh(step, 1)
+			continue
+		}
+		amounts[bestFrom], outputs[bestFrom] = decreasedAmounts[bestFrom], bestFromOutput
+		amounts[bestTo], outputs[bestTo] = increasedAmounts[bestTo], bestToOutput
+	}
+	return amounts
+}
+
+// settleRemainder clamps the negative amounts to zero and gives the rounding remainder to the largest amount, taking
+// it from the next largest ones if it is negative, so the amounts add up to amountIn
+func settleRemainder(amounts []*big.Int, amountIn *big.Int) {
+	remainder := new(big.Int).Set(amountIn)
+	for _, amount := range amounts {
+		if amount.Sign() < 0 {
+			amount.SetInt64(0)
+		}
+		remainder.Sub(remainder, amount)
+	}
+	for remainder.Sign() != 0 {
+		largest := 0
+		for i, amount := range amounts {
+			if amount.Cmp(amounts[largest]) > 0 {
+				largest = i
+			}
+		}
+		amounts[largest].Add(amounts[largest], remainder)
+		remainder.SetInt64(0)
+		if amounts[largest].Sign() < 0 {
+			remainder.Set(amounts[largest])
+			amounts[largest].SetInt64(0)
+		}
+	}
+}
+
+// routeOutput returns the output amount of the route for amountIn, zero if the route can not be traded
+func routeOutput(route *Route, amountIn *big.Int) *big.Int {
+	if amountIn.Sign() == 0 {
+		return new(big.Int)
+	}
+	amount, err := NewTokenAmount(route.Input, amountIn)
+	if err != nil {
+		return new(big.Int)
+	}
+	for _, pair := range route.Pairs {
+		amount, _, err = pair.GetOutputAmount(amount)
+		if err != nil {
+			return new(big.Int)
+		}
+	}
+	return amount.Raw()
+}
+
+// VirtualReserves composes the ClassicPairs of a route into a single constant product pair with reserves
+// (reserveIn, reserveOut) and fee multiplier gamma, i.e. out = gamma * x * reserveOut / (reserveIn + gamma * x).
+// ok is false if the route has pairs of other types or fee on transfer tokens.
+func VirtualReserves(route *Route) (reserveIn, reserveOut, gamma *big.Float, ok bool) {
+	if route.HasTransferTax() {
+		return nil, nil, nil, false
+	}
+	for i, pair := range route.Pairs {
+		classicPair, isClassic := pair.(*ClassicPair)
+		if !isClassic {
+			return nil, nil, nil, false
+		}
+		inputReserve, err := classicPair.ReserveOf(route.Path[i])
+		if err != nil {
+			return nil, nil, nil, false
+		}
+		outputReserve, err := classicPair.ReserveOf(route.Path[i+1])
+		if err != nil {
+			return nil, nil, nil, false
+		}
+		a := newFloat().SetInt(inputReserve.Raw())
+		b := newFloat().SetInt(outputReserve.Raw())
+		g := newFloat().Quo(
+			newFloat().SetInt(new(big.Int).Sub(classicPair.feeBase, classicPair.fee)),
+			newFloat().SetInt(classicPair.feeBase),
+		)
+		if i == 0 {
+			reserveIn, reserveOut, gamma = a, b, g
+			continue
+		}
+		// reserveIn' = reserveIn * a / (a + g * reserveOut)
+		// reserveOut' = g * reserveOut * b / (a + g * reserveOut)
+		denominator := newFloat().Add(a, newFloat().Mul(g, reserveOut))
+		reserveIn = newFloat().Quo(newFloat().Mul(reserveIn, a), denominator)
+		reserveOut = newFloat().Quo(newFloat().Mul(newFloat().Mul(g, reserveOut), b), denominator)
+	}
+	return reserveIn, reserveOut, gamma, reserveIn.Sign() > 0 && reserveOut.Sign() > 0
+}
+
+// classicAllocation returns the closed form allocation that equalizes the marginal outputs of routes made of
+// ClassicPairs only. For a virtual pair (E0, E1, gamma) the marginal output is gamma*E0*E1 / (E0 + gamma*x)^2, so
+// equal marginal outputs lambda give x = s*sqrt(E0*E1/gamma) - E0/gamma with s = 1/sqrt(lambda), and s is solved
+// from the sum of x being amountIn. Routes that would get a negative amount are dropped and s is solved again.
+func classicAllocation(routes []*Route, amountIn *big.Int) ([]*big.Int, bool) {
+	roots := make([]*big.Float, len(routes))
+	offsets := make([]*big.Float, len(routes))
+	for i, route := range routes {
+		reserveIn, reserveOut, gamma, ok := VirtualReserves(route)
+		if !ok {
+			return nil, false
+		}
+		roots[i] = newFloat().Sqrt(newFloat().Quo(newFloat().Mul(reserveIn, reserveOut), gamma))
+		offsets[i] = newFloat().Quo(reserveIn, gamma)
+	}
+
+	amountInFloat := newFloat().SetInt(amountIn)
+	active := make([]bool, len(routes))
+	for i := range active {
+		active[i] = true
+	}
+	allocation := make([]*big.Float, len(routes))
+	for {
+		sumRoots, sumOffsets := newFloat(), newFloat()
+		for i := range routes {
+			if active[i] {
+				sumRoots.Add(sumRoots, roots[i])
+				sumOffsets.Add(sumOffsets, offsets[i])
+			}
+		}
+		if sumRoots.Sign() == 0 {
+			return nil, false
+		}
+		s := newFloat().Quo(newFloat().Add(amountInFloat, sumOffsets), sumRoots)
+
+		dropped := false
+		for i := range routes {
+			allocation[i] = newFloat()
+			if !active[i] {
+				continue
+			}
+			allocation[i].Sub(newFloat().Mul(s, roots[i]), offsets[i])
+			if allocation[i].Sign() < 0 {
+				active[i] = false
+				dropped = true
+			}
+		}
+		if !dropped {
+			break
+		}
+	}
+
+	amounts := make([]*big.Int, len(routes))
+	for i := range routes {
+		amounts[i], _ = allocation[i].Int(nil)
+	}
+	return amounts, true
+}
+
+func newFloat() *big.Float {
+	return new(big.Float).SetPrec(floatPrecision)
+}
@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+// pairStates maps pair addresses to the state of the pairs after the legs of a SmartTrade executed so far
+type pairStates map[common.Address]Pair
+
+// next returns a copy of the states updated with the pairs after trade executes
+func (s pairStates) next(trade *Trade) pairStates {
+	states := make(pairStates, len(s)+len(trade.nextPairs))
+	for address, pair := range s {
+		states[address] = pair
+	}
+	for _, pair := range trade.nextPairs {
+		states[pair.GetAddress()] = pair
+	}
+	return states
+}
+
+// simulate executes the trade against the current states of its pairs instead of the reserves it was created with,
+// the returned trade is routed through the current states
+func (s pairStates) simulate(trade *Trade) (*Trade, error) {
+	pairs := make([]Pair, len(trade.Route.Pairs))
+	touched := false
+	for i, pair := range trade.Route.Pairs {
+		pairs[i] = pair
+		if state, ok := s[pair.GetAddress()]; ok {
+			pairs[i] = pairOfState(pair, state)
+			touched = true
+		}
+	}
+	if !touched {
+		return trade, nil
+	}
+
+	route, err := NewRoute(pairs, trade.Route.Input, trade.Route.Output)
+	if err != nil {
+		return nil, err
+	}
+	amount := trade.InputAmount()
+	if trade.TradeType != constants.ExactInput {
+		amount = trade.OutputAmount()
+	}
+	return NewTrade(route, amount, trade.TradeType)
+}
+
+// findBestTradeOnPairStates returns the trade that gives the most output (costs the least input for exact output)
+// when executed after the current legs, along with the pair states after it executes.
+// trades following a route of the current legs are skipped, they are better off merged into that leg.
+func findBestTradeOnPairStates(currentTrades []*Trade, states pairStates, trades []*Trade) (*Trade, pairStates) {
+	var best *Trade
+outer:
+	for _, trade := range trades {
+		for _, currentTrade := range currentTrades {
+			if sameRoute(currentTrade.Route, trade.Route) {
+				continue outer
+			}
+		}
+		simulated, err := states.simulate(trade)
+		if err != nil {
+			// the earlier legs drained the pairs
+			continue
+		}
+		if best == nil || TradeComparator(simulated, best) < 0 {
+			best = simulated
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	return best, states.next(best)
+}
+
+func sameRoute(a, b *Route) bool {
+	if len(a.Pairs) != len(b.Pairs) || !a.Input.Equals(b.Input) {
+		return false
+	}
+	for i := range a.Pairs {
+		// pools have a pair for every two coins at the same address
+		if a.Pairs[i].GetAddress() != b.Pairs[i].GetAddress() || !a.Path[i+1].Equals(b.Path[i+1]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,404 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+func mustEther(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), big.NewInt(1e18))
+}
+
+func mustPair(tokenA *Token, amountA *big.Int, tokenB *Token, amountB *big.Int) Pair {
+	tokenAmountA, err := NewTokenAmount(tokenA, amountA)
+	if err != nil {
+		panic(err)
+	}
+	tokenAmountB, err := NewTokenAmount(tokenB, amountB)
+	if err != nil {
+		panic(err)
+	}
+	pair, err := NewPair(tokenAmountA, tokenAmountB)
+	if err != nil {
+		panic(err)
+	}
+	return pair
+}
+
+// nolint funlen
+func TestBestSmartTradeExactIn(t *testing.T) {
+	token0, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "t0", "")
+	token1, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "t1", "")
+	token3, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000004"), 18, "t3", "")
+
+	pair_0_3 := mustPair(token0, mustEther(1000000), token3, mustEther(1000000))
+	pair_0_1 := mustPair(token0, mustEther(1000000), token1, mustEther(1000000))
+	pair_1_3 := mustPair(token1, mustEther(1000000), token3, mustEther(1000000))
+	pairs := []Pair{pair_0_3, pair_0_1, pair_1_3}
+
+	amountIn, _ := NewTokenAmount(token0, mustEther(100000))
+	options := &BestSmartTradeOptions{
+		BestTradeOptions:        *NewDefaultBestTradeOptions(),
+		MaxSplit:                2,
+		MaxSmartTradeNumResults: 3,
+	}
+
+	// splits to get more output when gas is ignored
+	{
+		smartTrades, err := BestSmartTradeExactIn(pairs, amountIn, token3, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(smartTrades) == 0 {
+			t.Fatal("should find smart trades")
+		}
+		best := smartTrades[0]
+		if len(best.Trades) != 2 {
+			t.Errorf("expect[%+v], but got[%+v]", 2, len(best.Trades))
+		}
+		if !best.InputAmount().Equals(amountIn) {
+			t.Errorf("expect[%+v], but got[%+v]", amountIn.Raw(), best.InputAmount().Raw())
+		}
+		if best.GasAdjustedOutputAmount() != nil {
+			t.Error("should not estimate gas without gas model")
+		}
+		for i := 1; i < len(smartTrades); i++ {
+			if smartTrades[i].OutputAmount().GreaterThan(best.OutputAmount().Fraction) {
+				t.Error("smart trades should be sorted by output")
+			}
+		}
+	}
+
+	// does not split when gas costs more than splitting gains
+	{
+		// 1 native token is worth 1,000,000 t3
+		nativePrice := NewPrice(WETH[constants.Mainnet].Currency, token3.Currency, big.NewInt(1), big.NewInt(1000000))
+		gasOptions := *options
+		gasOptions.GasModel = NewDefaultGasModel(big.NewInt(100e9), nativePrice)
+		smartTrades, err := BestSmartTradeExactIn(pairs, amountIn, token3, &gasOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		best := smartTrades[0]
+		if len(best.Trades) != 1 || len(best.Trades[0].Route.Pairs) != 1 {
+			t.Fatalf("expect single one hop trade, but got[%+v]", best.Trades)
+		}
+		if best.EstimatedGas != DefaultBaseSwapGas+DefaultPerHopGas[Classic] {
+			t.Errorf("expect[%+v], but got[%+v]", DefaultBaseSwapGas+DefaultPerHopGas[Classic], best.EstimatedGas)
+		}
+		// tuning a model leaves the defaults
+		gasOptions.GasModel.PerHopGas[Classic]++
+		if DefaultPerHopGas[Classic] != 50000 {
+			t.Errorf("expect[%+v], but got[%+v]", 50000, DefaultPerHopGas[Classic])
+		}
+		expect, _ := best.OutputAmount().Subtract(best.GasCost())
+		if !best.GasAdjustedOutputAmount().Equals(expect) {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), best.GasAdjustedOutputAmount().Raw())
+		}
+		for i := 1; i < len(smartTrades); i++ {
+			if smartTrades[i].GasAdjustedOutputAmount().GreaterThan(best.GasAdjustedOutputAmount().Fraction) {
+				t.Error("smart trades should be sorted by gas adjusted output")
+			}
+		}
+
+		// native price must be quoted in the output token
+		gasOptions.GasModel = NewDefaultGasModel(big.NewInt(100e9), nativePrice.Invert())
+		if _, err := BestSmartTradeExactIn(pairs, amountIn, token3, &gasOptions); err != ErrInvalidCurrency {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidCurrency, err)
+		}
+	}
+}
+
+// nolint funlen
+func TestBestSmartTradeExactOut(t *testing.T) {
+	token0, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "t0", "")
+	token1, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "t1", "")
+	token3, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000004"), 18, "t3", "")
+
+	pair_0_3 := mustPair(token0, mustEther(1000000), token3, mustEther(1000000))
+	pair_0_1 := mustPair(token0, mustEther(1000000), token1, mustEther(1000000))
+	pair_1_3 := mustPair(token1, mustEther(1000000), token3, mustEther(1000000))
+	pairs := []Pair{pair_0_3, pair_0_1, pair_1_3}
+
+	amountOut, _ := NewTokenAmount(token3, mustEther(90000))
+	options := &BestSmartTradeOptions{
+		BestTradeOptions:        *NewDefaultBestTradeOptions(),
+		MaxSplit:                3,
+		MaxSmartTradeNumResults: 3,
+	}
+
+	smartTrades, err := BestSmartTradeExactOut(pairs, token0, amountOut, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(smartTrades) == 0 {
+		t.Fatal("should find smart trades")
+	}
+	best := smartTrades[0]
+	if len(best.Trades) < 2 {
+		t.Errorf("expect split trade, but got[%+v]", len(best.Trades))
+	}
+	for i, smartTrade := range smartTrades {
+		if !smartTrade.OutputAmount().Equals(amountOut) {
+			t.Errorf("expect[%+v], but got[%+v]", amountOut.Raw(), smartTrade.OutputAmount().Raw())
+		}
+		if i > 0 && smartTrade.InputAmount().LessThan(smartTrades[i-1].InputAmount().Fraction) {
+			t.Error("smart trades should be sorted by input")
+		}
+	}
+
+	// the split trade needs less input than the best single route
+	trades, err := BestTradeExactOut(pairs, token0, amountOut, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !best.InputAmount().LessThan(trades[0].InputAmount().Fraction) {
+		t.Errorf("expect input less than [%+v], but got[%+v]", trades[0].InputAmount().Raw(), best.InputAmount().Raw())
+	}
+
+	// legs do not share pairs
+	used := make(map[Pair]bool)
+	for _, trade := range best.Trades {
+		for _, pair := range trade.Route.Pairs {
+			if used[pair] {
+				t.Errorf("pair %s used by more than one leg", pair.GetAddress())
+			}
+			used[pair] = true
+		}
+	}
+
+	// the remainder of the split amounts goes to a leg, splits do not overshoot the output to rank first
+	{
+		pairs := []Pair{
+			mustPair(token0, big.NewInt(1000000000), token3, big.NewInt(1000000000)),
+			mustPair(token0, big.NewInt(10000000), token1, big.NewInt(10000000)),
+			mustPair(token1, big.NewInt(10000000), token3, big.NewInt(10000000)),
+		}
+		amountOut, _ := NewTokenAmount(token3, big.NewInt(1000001))
+		smartTrades, err := BestSmartTradeExactOut(pairs, token0, amountOut, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trades, err := BestTradeExactOut(pairs, token0, amountOut, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		best := smartTrades[0]
+		if len(best.Trades) != 1 || !best.InputAmount().Equals(trades[0].InputAmount()) {
+			t.Errorf("expect[%+v], but got[%+v]", trades[0].InputAmount().Raw(), best.InputAmount().Raw())
+		}
+		for _, smartTrade := range smartTrades {
+			if !smartTrade.OutputAmount().Equals(amountOut) {
+				t.Errorf("expect[%+v], but got[%+v]", amountOut.Raw(), smartTrade.OutputAmount().Raw())
+			}
+		}
+	}
+
+	// gas model is quoted in the input token
+	{
+		nativePrice := NewPrice(WETH[constants.Mainnet].Currency, token0.Currency, big.NewInt(1), big.NewInt(1000000))
+		gasOptions := *options
+		gasOptions.GasModel = NewDefaultGasModel(big.NewInt(100e9), nativePrice)
+		smartTrades, err := BestSmartTradeExactOut(pairs, token0, amountOut, &gasOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		best := smartTrades[0]
+		if len(best.Trades) != 1 {
+			t.Errorf("expect single trade, but got[%+v]", len(best.Trades))
+		}
+		expect, _ := best.InputAmount().Add(best.GasCost())
+		if !best.GasAdjustedInputAmount().Equals(expect) {
+			t.Errorf("expect[%+v], but got[%+v]", expect.Raw(), best.GasAdjustedInputAmount().Raw())
+		}
+	}
+}
+
+// nolint funlen
+func TestBestSmartTradeOptimalSplit(t *testing.T) {
+	token0, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "t0", "")
+	token1, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "t1", "")
+	token3, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000004"), 18, "t3", "")
+
+	pair_0_3 := mustPair(token0, mustEther(1000000), token3, mustEther(1300000))
+	pair_0_1 := mustPair(token0, mustEther(700000), token1, mustEther(900000))
+	pair_1_3 := mustPair(token1, mustEther(2000000), token3, mustEther(1800000))
+	pairs := []Pair{pair_0_3, pair_0_1, pair_1_3}
+
+	amountIn, _ := NewTokenAmount(token0, new(big.Int).Add(mustEther(333333), big.NewInt(7)))
+	options := &BestSmartTradeOptions{
+		BestTradeOptions:        *NewDefaultBestTradeOptions(),
+		MaxSplit:                2,
+		MaxSmartTradeNumResults: 3,
+	}
+	gridTrades, err := BestSmartTradeExactIn(pairs, amountIn, token3, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	optimalOptions := *options
+	optimalOptions.OptimalSplit = true
+	smartTrades, err := BestSmartTradeExactIn(pairs, amountIn, token3, &optimalOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best := smartTrades[0]
+	if len(best.Trades) != 2 {
+		t.Fatalf("expect[%+v], but got[%+v]", 2, len(best.Trades))
+	}
+	// exact to the wei
+	if !best.InputAmount().Equals(amountIn) {
+		t.Errorf("expect[%+v], but got[%+v]", amountIn.Raw(), best.InputAmount().Raw())
+	}
+	if best.OutputAmount().LessThan(gridTrades[0].OutputAmount().Fraction) {
+		t.Errorf("expect output at least [%+v], but got[%+v]", gridTrades[0].OutputAmount().Raw(), best.OutputAmount().Raw())
+	}
+
+	if percents := best.Percents; percents[0]+percents[1] != 100 {
+		t.Errorf("expect[%+v], but got[%+v]", 100, percents)
+	}
+
+	// the rounding remainder never makes an amount negative
+	{
+		amounts := []*big.Int{big.NewInt(-1), big.NewInt(5), big.NewInt(7)}
+		settleRemainder(amounts, big.NewInt(10))
+		for i, expect := range []int64{0, 5, 5} {
+			if amounts[i].Int64() != expect {
+				t.Errorf("expect[%+v], but got[%+v]", expect, amounts[i])
+			}
+		}
+		amounts = []*big.Int{big.NewInt(0), big.NewInt(3), big.NewInt(4)}
+		settleRemainder(amounts, big.NewInt(2))
+		if amounts[0].Sign() != 0 || amounts[1].Int64() != 2 || amounts[2].Sign() != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", []int64{0, 2, 0}, amounts)
+		}
+	}
+
+	// moving input between the legs does not increase the output
+	output := best.OutputAmount().Raw()
+	for _, delta := range []int64{1e12, 1e15, 1e18} {
+		for from := range best.Trades {
+			to := 1 - from
+			amounts := []*big.Int{best.Trades[0].InputAmount().Raw(), best.Trades[1].InputAmount().Raw()}
+			amounts[from] = new(big.Int).Sub(amounts[from], big.NewInt(delta))
+			amounts[to] = new(big.Int).Add(amounts[to], big.NewInt(delta))
+			moved := new(big.Int).Add(routeOutput(best.Trades[0].Route, amounts[0]), routeOutput(best.Trades[1].Route, amounts[1]))
+			if moved.Cmp(output) > 0 {
+				t.Errorf("moving [%+v] from leg %d gives more output [%+v] than [%+v]", delta, from, moved, output)
+			}
+		}
+	}
+
+	// numeric allocation for stable pairs
+	{
+		reserve0, _ := NewTokenAmount(token0, mustEther(500000))
+		reserve1, _ := NewTokenAmount(token1, mustEther(500000))
+		stable, err := NewPairBuilder().
+			SetTokenAmounts(reserve0, reserve1).
+			SetTokenMultiplier(constants.Ten, constants.Ten).
+			Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stablePairs := []Pair{stable, pair_1_3, pair_0_3}
+		smartTrades, err := BestSmartTradeExactIn(stablePairs, amountIn, token3, &optimalOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		best := smartTrades[0]
+		if len(best.Trades) != 2 {
+			t.Fatalf("expect[%+v], but got[%+v]", 2, len(best.Trades))
+		}
+		if !best.InputAmount().Equals(amountIn) {
+			t.Errorf("expect[%+v], but got[%+v]", amountIn.Raw(), best.InputAmount().Raw())
+		}
+		gridTrades, err := BestSmartTradeExactIn(stablePairs, amountIn, token3, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if best.OutputAmount().LessThan(gridTrades[0].OutputAmount().Fraction) {
+			t.Errorf("expect output at least [%+v], but got[%+v]", gridTrades[0].OutputAmount().Raw(), best.OutputAmount().Raw())
+		}
+	}
+}
+
+// nolint funlen
+func TestBestSmartTradeSharedPairs(t *testing.T) {
+	token0, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "t0", "")
+	token1, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "t1", "")
+	token2, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000003"), 18, "t2", "")
+	token3, _ := NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000004"), 18, "t3", "")
+
+	// every route starts through the deep t0/t1 pair
+	pair_0_1 := mustPair(token0, mustEther(100000000), token1, mustEther(100000000))
+	pair_1_3 := mustPair(token1, mustEther(1000000), token3, mustEther(1000000))
+	pair_1_2 := mustPair(token1, mustEther(1000000), token2, mustEther(1000000))
+	pair_2_3 := mustPair(token2, mustEther(1000000), token3, mustEther(1000000))
+	pairs := []Pair{pair_0_1, pair_1_3, pair_1_2, pair_2_3}
+
+	amountIn, _ := NewTokenAmount(token0, mustEther(200000))
+	options := &BestSmartTradeOptions{
+		BestTradeOptions:        *NewDefaultBestTradeOptions(),
+		MaxSplit:                2,
+		MaxSmartTradeNumResults: 3,
+	}
+
+	disjoint, err := BestSmartTradeExactIn(pairs, amountIn, token3, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(disjoint[0].Trades) != 1 {
+		t.Errorf("expect[%+v], but got[%+v]", 1, len(disjoint[0].Trades))
+	}
+
+	sharedOptions := *options
+	sharedOptions.AllowSharedPairs = true
+	smartTrades, err := BestSmartTradeExactIn(pairs, amountIn, token3, &sharedOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best := smartTrades[0]
+	if len(best.Trades) != 2 {
+		t.Fatalf("expect[%+v], but got[%+v]", 2, len(best.Trades))
+	}
+	if !best.InputAmount().Equals(amountIn) {
+		t.Errorf("expect[%+v], but got[%+v]", amountIn.Raw(), best.InputAmount().Raw())
+	}
+	if !best.OutputAmount().GreaterThan(disjoint[0].OutputAmount().Fraction) {
+		t.Errorf("expect output more than [%+v], but got[%+v]", disjoint[0].OutputAmount().Raw(), best.OutputAmount().Raw())
+	}
+
+	// the second leg executes against the t0/t1 pair as left by the first leg
+	_, next, err := pair_0_1.GetOutputAmount(best.Trades[0].InputAmount())
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := best.Trades[1].Route.Pairs[0]
+	if shared.Reserve0().Raw().Cmp(next.Reserve0().Raw()) != 0 || shared.Reserve1().Raw().Cmp(next.Reserve1().Raw()) != 0 {
+		t.Errorf("expect[%+v %+v], but got[%+v %+v]", next.Reserve0().Raw(), next.Reserve1().Raw(), shared.Reserve0().Raw(), shared.Reserve1().Raw())
+	}
+	independent, err := NewTrade(best.Trades[1].Route, best.Trades[1].InputAmount(), constants.ExactInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !independent.OutputAmount().Equals(best.Trades[1].OutputAmount()) {
+		t.Errorf("expect[%+v], but got[%+v]", independent.OutputAmount().Raw(), best.Trades[1].OutputAmount().Raw())
+	}
+
+	// exact output legs are chained as well
+	amountOut, _ := NewTokenAmount(token3, mustEther(150000))
+	exactOut, err := BestSmartTradeExactOut(pairs, token0, amountOut, &sharedOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exactOut[0].Trades) != 2 {
+		t.Fatalf("expect[%+v], but got[%+v]", 2, len(exactOut[0].Trades))
+	}
+	if exactOut[0].OutputAmount().LessThan(amountOut.Fraction) {
+		t.Errorf("output [%+v] should cover [%+v]", exactOut[0].OutputAmount().Raw(), amountOut.Raw())
+	}
+}
@@ -1,5 +1,7 @@
 package entities
 
+import "github.com/xiang-xx/uniswap-sdk-go/constants"
+
 type SmartTrade struct {
 	// len(Percents) == len(Trades)
 	Percents []int
@@ -7,6 +9,12 @@ type SmartTrade struct {
 
 	inputAmount  *TokenAmount
 	outputAmount *TokenAmount
+
+	// set when routing with a GasModel
+	EstimatedGas            uint64
+	gasCost                 *TokenAmount
+	gasAdjustedOutputAmount *TokenAmount
+	gasAdjustedInputAmount  *TokenAmount
 }
 
 func (t *SmartTrade) InputAmount() *TokenAmount {
@@ -17,10 +25,77 @@ func (t *SmartTrade) OutputAmount() *TokenAmount {
 	return t.outputAmount
 }
 
+// GasCost returns the estimated gas cost in terms of the output token (input token for exact output),
+// nil if routed without a GasModel
+func (t *SmartTrade) GasCost() *TokenAmount {
+	return t.gasCost
+}
+
+// GasAdjustedOutputAmount returns the output amount net of gas cost, nil if not routed exact input with a GasModel
+func (t *SmartTrade) GasAdjustedOutputAmount() *TokenAmount {
+	return t.gasAdjustedOutputAmount
+}
+
+// GasAdjustedInputAmount returns the input amount plus gas cost, nil if not routed exact output with a GasModel
+func (t *SmartTrade) GasAdjustedInputAmount() *TokenAmount {
+	return t.gasAdjustedInputAmount
+}
+
+// newSmartTrade sums the amounts of the trades, and estimates gas when gasModel is not nil
+func newSmartTrade(percents []int, trades []*Trade, tradeType constants.TradeType, gasModel *GasModel) (*SmartTrade, error) {
+	outputAmount := trades[0].OutputAmount()
+	inputAmount := trades[0].InputAmount()
+	var err error
+	for k := 1; k < len(trades); k++ {
+		outputAmount, err = outputAmount.Add(trades[k].OutputAmount())
+		if err != nil {
+			return nil, err
+		}
+		inputAmount, err = inputAmount.Add(trades[k].InputAmount())
+		if err != nil {
+			return nil, err
+		}
+	}
+	smartTrade := &SmartTrade{
+		Percents:     percents,
+		Trades:       trades,
+		outputAmount: outputAmount,
+		inputAmount:  inputAmount,
+	}
+	if gasModel == nil {
+		return smartTrade, nil
+	}
+
+	smartTrade.EstimatedGas = gasModel.EstimateGas(trades...)
+	if tradeType == constants.ExactOutput {
+		smartTrade.gasCost, err = gasModel.GasCost(smartTrade.EstimatedGas, inputAmount.Token)
+		if err != nil {
+			return nil, err
+		}
+		smartTrade.gasAdjustedInputAmount, err = inputAmount.Add(smartTrade.gasCost)
+		if err != nil {
+			return nil, err
+		}
+		return smartTrade, nil
+	}
+
+	smartTrade.gasCost, err = gasModel.GasCost(smartTrade.EstimatedGas, outputAmount.Token)
+	if err != nil {
+		return nil, err
+	}
+	smartTrade.gasAdjustedOutputAmount, err = gasAdjustedOutput(outputAmount, smartTrade.gasCost)
+	if err != nil {
+		return nil, err
+	}
+	return smartTrade, nil
+}
+
 type tradesWithPercent struct {
 	Percents      []int
 	Trades        []*Trade
 	CurrentPairs  []Pair
 	RemainPercent int
 	PercentIndex  int
+	// set when legs may share pairs
+	PairStates pairStates
 }
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// pairABI is the events of UniswapV2Pair changing its reserves
+const pairABI = `[
+	{"type":"event","name":"Sync","anonymous":false,"inputs":[{"name":"reserve0","type":"uint112","indexed":false},{"name":"reserve1","type":"uint112","indexed":false}]},
+	{"type":"event","name":"Swap","anonymous":false,"inputs":[{"name":"sender","type":"address","indexed":true},{"name":"amount0In","type":"uint256","indexed":false},{"name":"amount1In","type":"uint256","indexed":false},{"name":"amount0Out","type":"uint256","indexed":false},{"name":"amount1Out","type":"uint256","indexed":false},{"name":"to","type":"address","indexed":true}]},
+	{"type":"event","name":"Mint","anonymous":false,"inputs":[{"name":"sender","type":"address","indexed":true},{"name":"amount0","type":"uint256","indexed":false},{"name":"amount1","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Burn","anonymous":false,"inputs":[{"name":"sender","type":"address","indexed":true},{"name":"amount0","type":"uint256","indexed":false},{"name":"amount1","type":"uint256","indexed":false},{"name":"to","type":"address","indexed":true}]}
+]`
+
+// factoryABI is the PairCreated event of UniswapV2Factory
+const factoryABI = `[
+	{"type":"event","name":"PairCreated","anonymous":false,"inputs":[{"name":"token0","type":"address","indexed":true},{"name":"token1","type":"address","indexed":true},{"name":"pair","type":"address","indexed":false},{"name":"","type":"uint256","indexed":false}]}
+]`
+
+var (
+	// PairABI parsed UniswapV2Pair events
+	PairABI = mustParseABI(pairABI)
+	// FactoryABI parsed UniswapV2Factory events
+	FactoryABI = mustParseABI(factoryABI)
+
+	// SyncTopic the topic of Sync(uint112,uint112)
+	SyncTopic = PairABI.Events["Sync"].ID
+	// SwapTopic the topic of Swap(address,uint256,uint256,uint256,uint256,address)
+	SwapTopic = PairABI.Events["Swap"].ID
+	// MintTopic the topic of Mint(address,uint256,uint256)
+	MintTopic = PairABI.Events["Mint"].ID
+	// BurnTopic the topic of Burn(address,uint256,uint256,address)
+	BurnTopic = PairABI.Events["Burn"].ID
+	// PairCreatedTopic the topic of PairCreated(address,address,address,uint256)
+	PairCreatedTopic = FactoryABI.Events["PairCreated"].ID
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
@@ -0,0 +1,162 @@
+package events
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+var (
+	// ErrPairMismatch the event is not of the pair
+	ErrPairMismatch = fmt.Errorf("pair mismatch")
+)
+
+// PairEvent is an event of a pair changing its reserves
+type PairEvent interface {
+	Event
+	// Apply returns the pair updated by the event, the pair itself is not changed
+	Apply(pair entities.Pair) (entities.Pair, error)
+}
+
+// Apply sets the reserves of the pair to the synced reserves
+func (e *SyncEvent) Apply(pair entities.Pair) (entities.Pair, error) {
+	if e.Raw.Address != pair.GetAddress() {
+		return nil, ErrPairMismatch
+	}
+	return entities.CopyWithReserves(pair, e.Reserve0, e.Reserve1)
+}
+
+// Apply adds the input amounts to the reserves of the pair and subtracts the output amounts
+func (e *SwapEvent) Apply(pair entities.Pair) (entities.Pair, error) {
+	return applyDelta(pair, &e.Raw,
+		new(big.Int).Sub(e.Amount0In, e.Amount0Out), new(big.Int).Sub(e.Amount1In, e.Amount1Out))
+}
+
+// Apply adds the minted amounts to the reserves of the pair
+func (e *MintEvent) Apply(pair entities.Pair) (entities.Pair, error) {
+	return applyDelta(pair, &e.Raw, e.Amount0, e.Amount1)
+}
+
+// Apply subtracts the burned amounts from the reserves of the pair
+func (e *BurnEvent) Apply(pair entities.Pair) (entities.Pair, error) {
+	return applyDelta(pair, &e.Raw, new(big.Int).Neg(e.Amount0), new(big.Int).Neg(e.Amount1))
+}
+
+// applyDelta returns the pair with the deltas added to its reserves
+func applyDelta(pair entities.Pair, log *types.Log, delta0, delta1 *big.Int) (entities.Pair, error) {
+	if log.Address != pair.GetAddress() {
+		return nil, ErrPairMismatch
+	}
+	reserve0 := new(big.Int).Add(pair.Reserve0().Raw(), delta0)
+	reserve1 := new(big.Int).Add(pair.Reserve1().Raw(), delta1)
+	if reserve0.Sign() < 0 || reserve1.Sign() < 0 {
+		return nil, entities.ErrInsufficientReserves
+	}
+	return entities.CopyWithReserves(pair, reserve0, reserve1)
+}
+
+/**
+ * NewPair creates the created pair, without reserves
+ * @param token0 the token0 of the event
+ * @param token1 the token1 of the event
+ * @param factory factory emitting the event, nil is the Uniswap V2 factory
+ */
+func (e *PairCreatedEvent) NewPair(token0, token1 *entities.Token, factory *entities.Factory) (entities.Pair, error) {
+	if token0.Address != e.Token0 || token1.Address != e.Token1 {
+		return nil, ErrPairMismatch
+	}
+	emitter := entities.UniswapV2Factory
+	if factory != nil {
+		emitter = factory
+	}
+	if emitter.Address != e.Raw.Address {
+		return nil, ErrPairMismatch
+	}
+	tokenAmount0, err := entities.NewTokenAmount(token0, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	tokenAmount1, err := entities.NewTokenAmount(token1, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	return entities.NewPairBuilder().
+		SetTokenAmounts(tokenAmount0, tokenAmount1).
+		SetFactory(factory).
+		SetPairAddress(e.Pair).
+		Build()
+}
+
+// Applier applies the events of pairs in the order of the chain. It keeps the last Sync of each pair, so the logs of
+// a transaction can be applied across calls of ApplyLogs
+type Applier struct {
+	// the last Sync of each pair
+	syncs map[common.Address]*types.Log
+}
+
+// NewApplier creates an Applier of the logs following the pairs, use one Applier for the logs of the same pairs
+func NewApplier() *Applier {
+	return &Applier{syncs: make(map[common.Address]*types.Log)}
+}
+
+// apply returns the pair updated by the event. UniswapV2Pair emits Sync with the updated reserves right before Swap,
+// Mint and Burn, so a Swap, Mint or Burn right after the Sync of the same transaction is already applied.
+func (a *Applier) apply(pair entities.Pair, event PairEvent) (entities.Pair, error) {
+	log := event.Log()
+	if _, ok := event.(*SyncEvent); !ok {
+		sync := a.syncs[log.Address]
+		if sync != nil && sync.TxHash == log.TxHash && sync.Index+1 == log.Index {
+			return pair, nil
+		}
+	}
+	pair, err := event.Apply(pair)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := event.(*SyncEvent); ok {
+		a.syncs[log.Address] = log
+	}
+	return pair, nil
+}
+
+/**
+ * ApplyLogs applies the events of the logs of the pair in order, logs of other contracts and unknown events are
+ * ignored. Swap, Mint and Burn right after a Sync are already applied by the Sync. The logs must include every Sync
+ * of the pair to keep the reserves current, e.g. a filter of Sync or of all the pair events: Swap, Mint and Burn
+ * alone miss the reserve changes of sync() after direct transfers. Removed logs are ignored, roll back the pair to
+ * the pair before the removed block instead.
+ * The logs of a transaction must not be split across calls, or the Swap after a Sync is applied twice, use
+ * Applier.ApplyLogs to apply the logs in batches.
+ * @param pair the pair before the logs
+ * @param logs the logs in the order of the chain
+ */
+func ApplyLogs(pair entities.Pair, logs []types.Log) (entities.Pair, error) {
+	return NewApplier().ApplyLogs(pair, logs)
+}
+
+// ApplyLogs same as ApplyLogs, but remembers the last Sync of the pair for the next logs
+func (a *Applier) ApplyLogs(pair entities.Pair, logs []types.Log) (entities.Pair, error) {
+	for i := range logs {
+		log := &logs[i]
+		if log.Removed || log.Address != pair.GetAddress() {
+			continue
+		}
+		event, err := DecodeLog(log)
+		if err == ErrUnknownEvent {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if pairEvent, ok := event.(PairEvent); ok {
+			if pair, err = a.apply(pair, pairEvent); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pair, nil
+}
@@ -0,0 +1,172 @@
+package events
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// ErrUnknownEvent the log is not of a known event
+	ErrUnknownEvent = fmt.Errorf("unknown event")
+	// ErrInvalidLog the log does not match the topics of its event
+	ErrInvalidLog = fmt.Errorf("invalid log")
+)
+
+// Event is an event decoded from a log
+type Event interface {
+	// Log returns the log the event is decoded from
+	Log() *types.Log
+}
+
+// logEvent holds the log of an event
+type logEvent struct {
+	Raw types.Log
+}
+
+// Log returns the log the event is decoded from
+func (e *logEvent) Log() *types.Log {
+	return &e.Raw
+}
+
+// SyncEvent is the Sync event of a pair, emitted with the reserves after every change
+type SyncEvent struct {
+	logEvent
+
+	Reserve0 *big.Int
+	Reserve1 *big.Int
+}
+
+// SwapEvent is the Swap event of a pair
+type SwapEvent struct {
+	logEvent
+
+	Sender     common.Address
+	Amount0In  *big.Int
+	Amount1In  *big.Int
+	Amount0Out *big.Int
+	Amount1Out *big.Int
+	To         common.Address
+}
+
+// MintEvent is the Mint event of a pair, liquidity is added
+type MintEvent struct {
+	logEvent
+
+	Sender  common.Address
+	Amount0 *big.Int
+	Amount1 *big.Int
+}
+
+// BurnEvent is the Burn event of a pair, liquidity is removed
+type BurnEvent struct {
+	logEvent
+
+	Sender  common.Address
+	Amount0 *big.Int
+	Amount1 *big.Int
+	To      common.Address
+}
+
+// PairCreatedEvent is the PairCreated event of a factory
+type PairCreatedEvent struct {
+	logEvent
+
+	Token0 common.Address
+	Token1 common.Address
+	Pair   common.Address
+	// PairCount the number of pairs created by the factory, including this one
+	PairCount *big.Int
+}
+
+// unpackLog unpacks the data of the log of the event, whose topics are the signature and the indexed inputs
+func unpackLog(contract abi.ABI, name string, log *types.Log) ([]interface{}, error) {
+	indexed := 0
+	for _, input := range contract.Events[name].Inputs {
+		if input.Indexed {
+			indexed++
+		}
+	}
+	if len(log.Topics) != 1+indexed {
+		return nil, ErrInvalidLog
+	}
+	return contract.Unpack(name, log.Data)
+}
+
+// topicAddress returns the address of the indexed topic
+func topicAddress(topic common.Hash) common.Address {
+	return common.BytesToAddress(topic.Bytes())
+}
+
+// DecodeLog decodes the log of a pair or factory event, returns ErrUnknownEvent for other logs
+func DecodeLog(log *types.Log) (Event, error) {
+	if len(log.Topics) == 0 {
+		return nil, ErrUnknownEvent
+	}
+
+	switch log.Topics[0] {
+	case SyncTopic:
+		outputs, err := unpackLog(PairABI, "Sync", log)
+		if err != nil {
+			return nil, err
+		}
+		return &SyncEvent{
+			logEvent: logEvent{Raw: *log},
+			Reserve0: outputs[0].(*big.Int),
+			Reserve1: outputs[1].(*big.Int),
+		}, nil
+	case SwapTopic:
+		outputs, err := unpackLog(PairABI, "Swap", log)
+		if err != nil {
+			return nil, err
+		}
+		return &SwapEvent{
+			logEvent:   logEvent{Raw: *log},
+			Sender:     topicAddress(log.Topics[1]),
+			Amount0In:  outputs[0].(*big.Int),
+			Amount1In:  outputs[1].(*big.Int),
+			Amount0Out: outputs[2].(*big.Int),
+			Amount1Out: outputs[3].(*big.Int),
+			To:         topicAddress(log.Topics[2]),
+		}, nil
+	case MintTopic:
+		outputs, err := unpackLog(PairABI, "Mint", log)
+		if err != nil {
+			return nil, err
+		}
+		return &MintEvent{
+			logEvent: logEvent{Raw: *log},
+			Sender:   topicAddress(log.Topics[1]),
+			Amount0:  outputs[0].(*big.Int),
+			Amount1:  outputs[1].(*big.Int),
+		}, nil
+	case BurnTopic:
+		outputs, err := unpackLog(PairABI, "Burn", log)
+		if err != nil {
+			return nil, err
+		}
+		return &BurnEvent{
+			logEvent: logEvent{Raw: *log},
+			Sender:   topicAddress(log.Topics[1]),
+			Amount0:  outputs[0].(*big.Int),
+			Amount1:  outputs[1].(*big.Int),
+			To:       topicAddress(log.Topics[2]),
+		}, nil
+	case PairCreatedTopic:
+		outputs, err := unpackLog(FactoryABI, "PairCreated", log)
+		if err != nil {
+			return nil, err
+		}
+		return &PairCreatedEvent{
+			logEvent:  logEvent{Raw: *log},
+			Token0:    topicAddress(log.Topics[1]),
+			Token1:    topicAddress(log.Topics[2]),
+			Pair:      outputs[0].(common.Address),
+			PairCount: outputs[1].(*big.Int),
+		}, nil
+	}
+	return nil, ErrUnknownEvent
+}
@@ -0,0 +1,252 @@
+package events
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+// newLog returns the log of the event of contract, topics are the indexed inputs
+func newLog(contract abi.ABI, name string, address common.Address, topics []common.Hash, values ...interface{}) types.Log {
+	event := contract.Events[name]
+	data, err := event.Inputs.NonIndexed().Pack(values...)
+	if err != nil {
+		panic(err)
+	}
+	return types.Log{Address: address, Topics: append([]common.Hash{event.ID}, topics...), Data: data}
+}
+
+func addressTopic(address common.Address) common.Hash {
+	return common.BytesToHash(address.Bytes())
+}
+
+func syncLog(address common.Address, reserve0, reserve1 int64) types.Log {
+	return newLog(PairABI, "Sync", address, nil, big.NewInt(reserve0), big.NewInt(reserve1))
+}
+
+func swapLog(address common.Address, amount0In, amount1In, amount0Out, amount1Out int64) types.Log {
+	return newLog(PairABI, "Swap", address, []common.Hash{addressTopic(sender), addressTopic(sender)},
+		big.NewInt(amount0In), big.NewInt(amount1In), big.NewInt(amount0Out), big.NewInt(amount1Out))
+}
+
+func mintLog(address common.Address, amount0, amount1 int64) types.Log {
+	return newLog(PairABI, "Mint", address, []common.Hash{addressTopic(sender)}, big.NewInt(amount0), big.NewInt(amount1))
+}
+
+func burnLog(address common.Address, amount0, amount1 int64) types.Log {
+	return newLog(PairABI, "Burn", address, []common.Hash{addressTopic(sender), addressTopic(sender)},
+		big.NewInt(amount0), big.NewInt(amount1))
+}
+
+var (
+	sender = common.HexToAddress("0x0000000000000000000000000000000000000009")
+	token0 = mustToken("0x0000000000000000000000000000000000000001", "t0")
+	token1 = mustToken("0x0000000000000000000000000000000000000002", "t1")
+)
+
+func mustToken(address, symbol string) *entities.Token {
+	token, err := entities.NewToken(constants.Mainnet, common.HexToAddress(address), 18, symbol, "")
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+// frozenPair is a pair which can not be copied with new reserves
+type frozenPair struct {
+	entities.Pair
+}
+
+// nolint funlen
+func TestDecodeLog(t *testing.T) {
+	pair := common.HexToAddress("0x0000000000000000000000000000000000000007")
+
+	log := swapLog(pair, 10, 0, 0, 9)
+	event, err := DecodeLog(&log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swap, ok := event.(*SwapEvent); !ok || swap.Sender != sender || swap.To != sender || swap.Amount0In.Int64() != 10 ||
+		swap.Amount1In.Sign() != 0 || swap.Amount0Out.Sign() != 0 || swap.Amount1Out.Int64() != 9 || swap.Log().Address != pair {
+		t.Errorf("expect swap, but got[%+v]", event)
+	}
+
+	log = syncLog(pair, 1000, 2000)
+	if event, err = DecodeLog(&log); err != nil {
+		t.Fatal(err)
+	}
+	if sync, ok := event.(*SyncEvent); !ok || sync.Reserve0.Int64() != 1000 || sync.Reserve1.Int64() != 2000 {
+		t.Errorf("expect sync, but got[%+v]", event)
+	}
+
+	log = mintLog(pair, 5, 6)
+	if event, err = DecodeLog(&log); err != nil {
+		t.Fatal(err)
+	}
+	if mint, ok := event.(*MintEvent); !ok || mint.Sender != sender || mint.Amount0.Int64() != 5 || mint.Amount1.Int64() != 6 {
+		t.Errorf("expect mint, but got[%+v]", event)
+	}
+
+	log = burnLog(pair, 7, 8)
+	if event, err = DecodeLog(&log); err != nil {
+		t.Fatal(err)
+	}
+	if burn, ok := event.(*BurnEvent); !ok || burn.To != sender || burn.Amount0.Int64() != 7 || burn.Amount1.Int64() != 8 {
+		t.Errorf("expect burn, but got[%+v]", event)
+	}
+
+	log = newLog(FactoryABI, "PairCreated", constants.FactoryAddress,
+		[]common.Hash{addressTopic(token0.Address), addressTopic(token1.Address)}, pair, big.NewInt(42))
+	if event, err = DecodeLog(&log); err != nil {
+		t.Fatal(err)
+	}
+	if created, ok := event.(*PairCreatedEvent); !ok || created.Token0 != token0.Address || created.Token1 != token1.Address ||
+		created.Pair != pair || created.PairCount.Int64() != 42 {
+		t.Errorf("expect pair created, but got[%+v]", event)
+	}
+
+	// Transfer(address,address,uint256)
+	transfer := types.Log{Address: pair, Topics: []common.Hash{common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")}}
+	if _, err := DecodeLog(&transfer); err != ErrUnknownEvent {
+		t.Errorf("expect[%+v], but got[%+v]", ErrUnknownEvent, err)
+	}
+	log = syncLog(pair, 1, 1)
+	log.Topics = append(log.Topics, addressTopic(sender))
+	if _, err := DecodeLog(&log); err != ErrInvalidLog {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidLog, err)
+	}
+}
+
+// nolint funlen
+func TestApply(t *testing.T) {
+	tokenAmount_0_1000, _ := entities.NewTokenAmount(token0, big.NewInt(1000))
+	tokenAmount_1_2000, _ := entities.NewTokenAmount(token1, big.NewInt(2000))
+	classic, _ := entities.NewPair(tokenAmount_0_1000, tokenAmount_1_2000)
+	stable, _ := entities.NewStablePair(tokenAmount_0_1000, tokenAmount_1_2000, big.NewInt(1), big.NewInt(1))
+
+	for _, pair := range []entities.Pair{classic, stable} {
+		address := pair.GetAddress()
+		for _, test := range []struct {
+			log      types.Log
+			reserve0 int64
+			reserve1 int64
+			err      error
+		}{
+			{syncLog(address, 1200, 1700), 1200, 1700, nil},
+			{swapLog(address, 100, 0, 0, 180), 1100, 1820, nil},
+			{swapLog(address, 0, 100, 40, 0), 960, 2100, nil},
+			{mintLog(address, 10, 20), 1010, 2020, nil},
+			{burnLog(address, 10, 20), 990, 1980, nil},
+			{burnLog(address, 1001, 20), 0, 0, entities.ErrInsufficientReserves},
+			{syncLog(token0.Address, 1, 1), 0, 0, ErrPairMismatch},
+		} {
+			event, err := DecodeLog(&test.log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			updated, err := event.(PairEvent).Apply(pair)
+			if err != test.err {
+				t.Fatalf("expect[%+v], but got[%+v]", test.err, err)
+			}
+			if err != nil {
+				continue
+			}
+			if updated.PairType() != pair.PairType() || updated.GetAddress() != address ||
+				updated.Reserve0().Raw().Int64() != test.reserve0 || updated.Reserve1().Raw().Int64() != test.reserve1 {
+				t.Errorf("expect[%+v %+v], but got[%+v %+v]", test.reserve0, test.reserve1, updated.Reserve0().Raw(), updated.Reserve1().Raw())
+			}
+			// the pair itself is not changed
+			if pair.Reserve0().Raw().Int64() != 1000 {
+				t.Errorf("expect[%+v], but got[%+v]", 1000, pair.Reserve0().Raw())
+			}
+		}
+	}
+
+	log := syncLog(classic.GetAddress(), 1, 1)
+	event, _ := DecodeLog(&log)
+	if _, err := event.(PairEvent).Apply(frozenPair{classic}); err != entities.ErrNotImplemented {
+		t.Errorf("expect[%+v], but got[%+v]", entities.ErrNotImplemented, err)
+	}
+}
+
+// nolint funlen
+func TestApplyLogs(t *testing.T) {
+	tokenAmount_0_1000, _ := entities.NewTokenAmount(token0, big.NewInt(1000))
+	tokenAmount_1_2000, _ := entities.NewTokenAmount(token1, big.NewInt(2000))
+	pair, _ := entities.NewPair(tokenAmount_0_1000, tokenAmount_1_2000)
+	address := pair.GetAddress()
+	at := func(log types.Log, tx byte, index uint) types.Log {
+		log.TxHash = common.BytesToHash([]byte{tx})
+		log.Index = index
+		return log
+	}
+	removed := at(syncLog(address, 1, 1), 4, 0)
+	removed.Removed = true
+	logs := []types.Log{
+		// the swap is synced right before
+		at(syncLog(address, 1100, 1820), 1, 1),
+		at(swapLog(address, 100, 0, 0, 180), 1, 2),
+		// other contracts and unknown events
+		at(syncLog(token0.Address, 1, 1), 2, 0),
+		{Address: address, Topics: []common.Hash{common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")}},
+		// a swap without the sync before it is applied on its own
+		at(swapLog(address, 0, 20, 10, 0), 3, 5),
+		removed,
+	}
+	updated, err := ApplyLogs(pair, logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Reserve0().Raw().Int64() != 1090 || updated.Reserve1().Raw().Int64() != 1840 {
+		t.Errorf("expect[%+v %+v], but got[%+v %+v]", 1090, 1840, updated.Reserve0().Raw(), updated.Reserve1().Raw())
+	}
+
+	// an Applier keeps the Sync of a transaction split across batches
+	applier := NewApplier()
+	batched := pair
+	for _, batch := range [][]types.Log{logs[:1], logs[1:]} {
+		if batched, err = applier.ApplyLogs(batched, batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if batched.Reserve0().Raw().Cmp(updated.Reserve0().Raw()) != 0 || batched.Reserve1().Raw().Cmp(updated.Reserve1().Raw()) != 0 {
+		t.Errorf("expect[%+v %+v], but got[%+v %+v]", updated.Reserve0().Raw(), updated.Reserve1().Raw(),
+			batched.Reserve0().Raw(), batched.Reserve1().Raw())
+	}
+}
+
+func TestPairCreated(t *testing.T) {
+	factory := entities.NewFactory(common.HexToAddress("0x0000000000000000000000000000000000000008"),
+		constants.InitCodeHash, "Cake-LP", "Pancake LPs", 25, 10000)
+	address := common.HexToAddress("0x0000000000000000000000000000000000000007")
+	log := newLog(FactoryABI, "PairCreated", factory.Address,
+		[]common.Hash{addressTopic(token0.Address), addressTopic(token1.Address)}, address, big.NewInt(1))
+	event, err := DecodeLog(&log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := event.(*PairCreatedEvent)
+	pair, err := created.NewPair(token0, token1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.GetAddress() != address || pair.Reserve0().Raw().Sign() != 0 || pair.GetLiquidityToken().Symbol != "Cake-LP" {
+		t.Errorf("expect[%+v], but got[%+v]", address.String(), pair)
+	}
+	// the pair is kept current by the later logs
+	if pair, err = ApplyLogs(pair, []types.Log{mintLog(address, 10, 20)}); err != nil || pair.Reserve1().Raw().Int64() != 20 {
+		t.Errorf("expect[%+v], but got[%+v %+v]", 20, pair, err)
+	}
+	if _, err := created.NewPair(token1, token0, factory); err != ErrPairMismatch {
+		t.Errorf("expect[%+v], but got[%+v]", ErrPairMismatch, err)
+	}
+	if _, err := created.NewPair(token0, token1, nil); err != ErrPairMismatch {
+		t.Errorf("expect[%+v], but got[%+v]", ErrPairMismatch, err)
+	}
+}
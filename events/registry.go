@@ -0,0 +1,338 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+// DefaultHistoryDepth the default number of recent blocks a PoolRegistry can roll back to
+const DefaultHistoryDepth = 64
+
+var (
+	// ErrUnknownParent the parent of the block is not the current block of the registry
+	ErrUnknownParent = fmt.Errorf("unknown parent block")
+	// ErrUnknownBlock the block is not in the history of the registry
+	ErrUnknownBlock = fmt.Errorf("unknown block")
+)
+
+// Block is the decoded events of a block
+type Block struct {
+	Number     uint64
+	Hash       common.Hash
+	ParentHash common.Hash
+
+	// Events in the order of the logs
+	Events []Event
+}
+
+/**
+ * NewBlock decodes the logs of a block, unknown events and removed logs are ignored
+ * @param logs the logs of the block in the order of the chain
+ */
+func NewBlock(number uint64, hash, parentHash common.Hash, logs []types.Log) (*Block, error) {
+	block := &Block{
+		Number:     number,
+		Hash:       hash,
+		ParentHash: parentHash,
+	}
+	for i := range logs {
+		if logs[i].Removed {
+			continue
+		}
+		event, err := DecodeLog(&logs[i])
+		if err == ErrUnknownEvent {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		block.Events = append(block.Events, event)
+	}
+	return block, nil
+}
+
+// Snapshot is the state of the pools after a block. A snapshot is never changed, so route search over it is
+// consistent while the registry applies the next blocks.
+type Snapshot struct {
+	Number uint64
+	Hash   common.Hash
+
+	pairs map[common.Address]entities.Pair
+	// pair addresses in the order they were added
+	addresses []common.Address
+
+	graphOnce sync.Once
+	graph     *Graph
+}
+
+func newSnapshot(number uint64, hash common.Hash, pairs []entities.Pair) *Snapshot {
+	s := &Snapshot{
+		Number: number,
+		Hash:   hash,
+		pairs:  make(map[common.Address]entities.Pair, len(pairs)),
+	}
+	for _, pair := range pairs {
+		s.set(pair)
+	}
+	return s
+}
+
+// next returns a copy of the snapshot to apply the block to
+func (s *Snapshot) next(number uint64, hash common.Hash) *Snapshot {
+	pairs := make(map[common.Address]entities.Pair, len(s.pairs))
+	for address, pair := range s.pairs {
+		pairs[address] = pair
+	}
+	return &Snapshot{
+		Number: number,
+		Hash:   hash,
+		pairs:  pairs,
+		// capped, so appending never writes to the addresses of s
+		addresses: s.addresses[:len(s.addresses):len(s.addresses)],
+	}
+}
+
+// set adds or replaces the pair, only before the snapshot is published
+func (s *Snapshot) set(pair entities.Pair) {
+	address := pair.GetAddress()
+	if _, ok := s.pairs[address]; !ok {
+		s.addresses = append(s.addresses, address)
+	}
+	s.pairs[address] = pair
+}
+
+// Len returns the number of pairs
+func (s *Snapshot) Len() int {
+	return len(s.addresses)
+}
+
+// Pair returns the pair at address
+func (s *Snapshot) Pair(address common.Address) (entities.Pair, bool) {
+	pair, ok := s.pairs[address]
+	return pair, ok
+}
+
+// Pairs returns the pairs in the order they were added, e.g. for BestTradeExactIn
+func (s *Snapshot) Pairs() []entities.Pair {
+	pairs := make([]entities.Pair, len(s.addresses))
+	for i, address := range s.addresses {
+		pairs[i] = s.pairs[address]
+	}
+	return pairs
+}
+
+// Graph returns the graph of the pairs for route search, built once
+func (s *Snapshot) Graph() *Graph {
+	s.graphOnce.Do(func() {
+		s.graph = &Graph{graph: entities.NewPairGraph(s.Pairs())}
+	})
+	return s.graph
+}
+
+// Graph is the read-only route search over the pairs of a snapshot. It wraps a PairGraph which is never updated,
+// so it is searched concurrently.
+type Graph struct {
+	graph *entities.PairGraph
+}
+
+// Len returns the number of pairs in the graph
+func (g *Graph) Len() int {
+	return g.graph.Len()
+}
+
+// Pairs returns the pairs in the graph in insertion order
+func (g *Graph) Pairs() []entities.Pair {
+	return g.graph.Pairs()
+}
+
+// PairsOf returns the pairs involving token in insertion order
+func (g *Graph) PairsOf(token *entities.Token) []entities.Pair {
+	return g.graph.PairsOf(token)
+}
+
+// BestTradeExactIn same as PairGraph.BestTradeExactIn
+func (g *Graph) BestTradeExactIn(currencyAmountIn *entities.TokenAmount, currencyOut *entities.Token,
+	options *entities.BestTradeOptions) ([]*entities.Trade, error) {
+	return g.graph.BestTradeExactIn(currencyAmountIn, currencyOut, options)
+}
+
+// BestTradeExactOut same as PairGraph.BestTradeExactOut
+func (g *Graph) BestTradeExactOut(currencyIn *entities.Token, currencyAmountOut *entities.TokenAmount,
+	options *entities.BestTradeOptions) ([]*entities.Trade, error) {
+	return g.graph.BestTradeExactOut(currencyIn, currencyAmountOut, options)
+}
+
+// BestTradeExactInContext same as PairGraph.BestTradeExactInContext
+func (g *Graph) BestTradeExactInContext(ctx context.Context, currencyAmountIn *entities.TokenAmount, currencyOut *entities.Token,
+	options *entities.BestTradeOptions, budget *entities.SearchBudget) ([]*entities.Trade, error) {
+	return g.graph.BestTradeExactInContext(ctx, currencyAmountIn, currencyOut, options, budget)
+}
+
+// BestTradeExactOutContext same as PairGraph.BestTradeExactOutContext
+func (g *Graph) BestTradeExactOutContext(ctx context.Context, currencyIn *entities.Token, currencyAmountOut *entities.TokenAmount,
+	options *entities.BestTradeOptions, budget *entities.SearchBudget) ([]*entities.Trade, error) {
+	return g.graph.BestTradeExactOutContext(ctx, currencyIn, currencyAmountOut, options, budget)
+}
+
+// PoolRegistry holds the current pair of every known pool, applies the events of each block and keeps the
+// snapshots of the recent blocks to roll back to on reorgs.
+type PoolRegistry struct {
+	lk    *sync.RWMutex
+	depth int
+	// snapshots of the recent blocks, the last one is the current
+	history []*Snapshot
+
+	// tokens and factories to create the pairs of PairCreated
+	tokens    map[common.Address]*entities.Token
+	factories map[common.Address]*entities.Factory
+}
+
+/**
+ * NewPoolRegistry creates a PoolRegistry of the pairs at a block
+ * @param number number of the block
+ * @param hash hash of the block
+ * @param pairs the pairs at the block
+ * @param depth the number of recent blocks to keep, DefaultHistoryDepth if not positive
+ */
+func NewPoolRegistry(number uint64, hash common.Hash, pairs []entities.Pair, depth int) *PoolRegistry {
+	if depth <= 0 {
+		depth = DefaultHistoryDepth
+	}
+	r := &PoolRegistry{
+		lk:        new(sync.RWMutex),
+		depth:     depth,
+		history:   []*Snapshot{newSnapshot(number, hash, pairs)},
+		tokens:    make(map[common.Address]*entities.Token),
+		factories: make(map[common.Address]*entities.Factory),
+	}
+	for _, pair := range pairs {
+		r.addTokens(pair)
+	}
+	return r
+}
+
+func (r *PoolRegistry) addTokens(pair entities.Pair) {
+	for _, token := range []*entities.Token{pair.Token0(), pair.Token1()} {
+		if _, ok := r.tokens[token.Address]; !ok {
+			r.tokens[token.Address] = token
+		}
+	}
+}
+
+// AddFactory adds the factory whose PairCreated events create pairs in the registry
+func (r *PoolRegistry) AddFactory(factory *entities.Factory) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.factories[factory.Address] = factory
+}
+
+// AddToken adds the token the pairs of PairCreated events may be created with
+func (r *PoolRegistry) AddToken(token *entities.Token) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.tokens[token.Address] = token
+}
+
+// AddPair adds or replaces the pair in the current block, e.g. a pool loaded by the fetcher. A rollback to a block
+// before the current block drops it.
+func (r *PoolRegistry) AddPair(pair entities.Pair) {
+	r.AddPairs([]entities.Pair{pair})
+}
+
+// AddPairs adds or replaces the pairs in the current block like AddPair, the snapshot is copied once for all of them
+func (r *PoolRegistry) AddPairs(pairs []entities.Pair) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	current := r.history[len(r.history)-1]
+	snapshot := current.next(current.Number, current.Hash)
+	for _, pair := range pairs {
+		snapshot.set(pair)
+		r.addTokens(pair)
+	}
+	r.history[len(r.history)-1] = snapshot
+}
+
+// Snapshot returns the snapshot of the current block
+func (r *PoolRegistry) Snapshot() *Snapshot {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+	return r.history[len(r.history)-1]
+}
+
+// Pair returns the current pair at address
+func (r *PoolRegistry) Pair(address common.Address) (entities.Pair, bool) {
+	return r.Snapshot().Pair(address)
+}
+
+/**
+ * ApplyBlock applies the events of the block, the child of the current block, to the pairs. Events of unknown
+ * pools are ignored. The block is applied as a whole or not at all.
+ * @param block the next block
+ * @return the PairCreated events not applied because the factory or the tokens are unknown, add their pairs with
+ * AddPair once loaded
+ */
+func (r *PoolRegistry) ApplyBlock(block *Block) ([]*PairCreatedEvent, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	current := r.history[len(r.history)-1]
+	if block.ParentHash != current.Hash {
+		return nil, ErrUnknownParent
+	}
+
+	snapshot := current.next(block.Number, block.Hash)
+	applier := NewApplier()
+	var unresolved []*PairCreatedEvent
+	for _, event := range block.Events {
+		switch event := event.(type) {
+		case PairEvent:
+			pair, ok := snapshot.pairs[event.Log().Address]
+			if !ok {
+				continue
+			}
+			pair, err := applier.apply(pair, event)
+			if err != nil {
+				return nil, err
+			}
+			snapshot.set(pair)
+		case *PairCreatedEvent:
+			factory, ok := r.factories[event.Raw.Address]
+			token0, ok0 := r.tokens[event.Token0]
+			token1, ok1 := r.tokens[event.Token1]
+			if !ok || !ok0 || !ok1 {
+				unresolved = append(unresolved, event)
+				continue
+			}
+			pair, err := event.NewPair(token0, token1, factory)
+			if err != nil {
+				return nil, err
+			}
+			snapshot.set(pair)
+		}
+	}
+
+	r.history = append(r.history, snapshot)
+	if len(r.history) > r.depth {
+		r.history = append(r.history[:0:0], r.history[len(r.history)-r.depth:]...)
+	}
+	return unresolved, nil
+}
+
+// Rollback rolls the registry back to the recent block of hash, e.g. the common ancestor of a reorg, the next
+// block to apply is its new child
+func (r *PoolRegistry) Rollback(hash common.Hash) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	for i := len(r.history) - 1; i >= 0; i-- {
+		if r.history[i].Hash == hash {
+			r.history = r.history[:i+1]
+			return nil
+		}
+	}
+	return ErrUnknownBlock
+}
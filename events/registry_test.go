@@ -0,0 +1,200 @@
+package events
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+func blockHash(number uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(number))
+}
+
+func mustBlock(number uint64, parentHash common.Hash, logs ...types.Log) *Block {
+	for i := range logs {
+		logs[i].TxHash = blockHash(number)
+		logs[i].Index = uint(i)
+	}
+	block, err := NewBlock(number, blockHash(number), parentHash, logs)
+	if err != nil {
+		panic(err)
+	}
+	return block
+}
+
+func expectReserves(t *testing.T, pair entities.Pair, reserve0, reserve1 int64) {
+	t.Helper()
+	if pair.Reserve0().Raw().Int64() != reserve0 || pair.Reserve1().Raw().Int64() != reserve1 {
+		t.Errorf("expect[%+v %+v], but got[%+v %+v]", reserve0, reserve1, pair.Reserve0().Raw(), pair.Reserve1().Raw())
+	}
+}
+
+// nolint funlen
+func TestPoolRegistry(t *testing.T) {
+	token2 := mustToken("0x0000000000000000000000000000000000000003", "t2")
+	token3 := mustToken("0x0000000000000000000000000000000000000004", "t3")
+	token4 := mustToken("0x0000000000000000000000000000000000000006", "t4")
+	tokenAmount_0_1000, _ := entities.NewTokenAmount(token0, big.NewInt(1000))
+	tokenAmount_1_2000, _ := entities.NewTokenAmount(token1, big.NewInt(2000))
+	tokenAmount_1_3000, _ := entities.NewTokenAmount(token1, big.NewInt(3000))
+	tokenAmount_2_3000, _ := entities.NewTokenAmount(token2, big.NewInt(3000))
+	classic, _ := entities.NewPair(tokenAmount_0_1000, tokenAmount_1_2000)
+	stable, _ := entities.NewStablePair(tokenAmount_1_3000, tokenAmount_2_3000, big.NewInt(1), big.NewInt(1))
+
+	registry := NewPoolRegistry(1, blockHash(1), []entities.Pair{classic, stable}, 3)
+	registry.AddFactory(entities.UniswapV2Factory)
+	snapshot1 := registry.Snapshot()
+
+	// the events of known pools are applied, a swap right after its sync is already synced
+	unresolved, err := registry.ApplyBlock(mustBlock(2, blockHash(1),
+		syncLog(classic.GetAddress(), 1100, 1820),
+		swapLog(classic.GetAddress(), 100, 0, 0, 180),
+		swapLog(stable.GetAddress(), 0, 100, 99, 0),
+		syncLog(token3.Address, 1, 1),
+	))
+	if err != nil || len(unresolved) != 0 {
+		t.Fatalf("expect no error, but got[%+v %+v]", unresolved, err)
+	}
+	snapshot2 := registry.Snapshot()
+	if snapshot2.Number != 2 || snapshot2.Hash != blockHash(2) || snapshot2.Len() != 2 {
+		t.Errorf("expect block 2, but got[%+v]", snapshot2)
+	}
+	pair, _ := registry.Pair(classic.GetAddress())
+	expectReserves(t, pair, 1100, 1820)
+	pair, _ = registry.Pair(stable.GetAddress())
+	expectReserves(t, pair, 2901, 3100)
+	// snapshots taken before are not changed
+	pair, _ = snapshot1.Pair(classic.GetAddress())
+	expectReserves(t, pair, 1000, 2000)
+
+	if _, err := registry.ApplyBlock(mustBlock(3, blockHash(1))); err != ErrUnknownParent {
+		t.Errorf("expect[%+v], but got[%+v]", ErrUnknownParent, err)
+	}
+	// a block is applied as a whole or not at all
+	if _, err := registry.ApplyBlock(mustBlock(3, blockHash(2),
+		syncLog(classic.GetAddress(), 1, 1),
+		burnLog(stable.GetAddress(), 5000, 0),
+	)); err != entities.ErrInsufficientReserves {
+		t.Errorf("expect[%+v], but got[%+v]", entities.ErrInsufficientReserves, err)
+	}
+	if registry.Snapshot() != snapshot2 {
+		t.Errorf("expect[%+v], but got[%+v]", snapshot2, registry.Snapshot())
+	}
+
+	// pairs created by known factories of known tokens are added
+	created, _ := entities.UniswapV2Factory.GetPairAddress(token0, token2)
+	createdLog := func(tokenA, tokenB *entities.Token, pair common.Address) types.Log {
+		return newLog(FactoryABI, "PairCreated", entities.UniswapV2Factory.Address,
+			[]common.Hash{addressTopic(tokenA.Address), addressTopic(tokenB.Address)}, pair, big.NewInt(1))
+	}
+	unknown := mustToken("0x0000000000000000000000000000000000000005", "t4")
+	unresolved, err = registry.ApplyBlock(mustBlock(3, blockHash(2),
+		createdLog(token0, token2, created),
+		syncLog(created, 10, 20),
+		mintLog(created, 10, 20),
+		createdLog(token0, unknown, token3.Address),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unresolved) != 1 || unresolved[0].Token1 != unknown.Address {
+		t.Errorf("expect the pair of unknown token, but got[%+v]", unresolved)
+	}
+	pair, ok := registry.Pair(created)
+	if !ok || registry.Snapshot().Len() != 3 {
+		t.Fatalf("pair [%+v] should be created", created.String())
+	}
+	expectReserves(t, pair, 10, 20)
+
+	// route search over a consistent snapshot
+	{
+		snapshot := registry.Snapshot()
+		amountIn, _ := entities.NewTokenAmount(token0, big.NewInt(100))
+		trades, err := snapshot.Graph().BestTradeExactIn(amountIn, token2, entities.NewDefaultBestTradeOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect, err := entities.BestTradeExactIn(snapshot.Pairs(), amountIn, token2, entities.NewDefaultBestTradeOptions(), nil, amountIn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trades) == 0 || len(trades) != len(expect) || trades[0].OutputAmount().Raw().Cmp(expect[0].OutputAmount().Raw()) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect, trades)
+		}
+		if snapshot.Graph() != snapshot.Graph() {
+			t.Error("graph should be built once")
+		}
+	}
+
+	// reorg of block 3
+	if err := registry.Rollback(blockHash(2)); err != nil {
+		t.Fatal(err)
+	}
+	if registry.Snapshot() != snapshot2 {
+		t.Errorf("expect[%+v], but got[%+v]", snapshot2, registry.Snapshot())
+	}
+	if _, ok := registry.Pair(created); ok {
+		t.Errorf("pair [%+v] should be rolled back", created.String())
+	}
+	if _, err := registry.ApplyBlock(mustBlock(4, blockHash(2), syncLog(classic.GetAddress(), 7, 8))); err != nil {
+		t.Fatal(err)
+	}
+	pair, _ = registry.Pair(classic.GetAddress())
+	expectReserves(t, pair, 7, 8)
+
+	// pairs added later, e.g. loaded by the fetcher
+	tokenAmount_0_100, _ := entities.NewTokenAmount(token0, big.NewInt(100))
+	tokenAmount_2_100, _ := entities.NewTokenAmount(token2, big.NewInt(100))
+	tokenAmount_3_100, _ := entities.NewTokenAmount(token3, big.NewInt(100))
+	tokenAmount_4_100, _ := entities.NewTokenAmount(token4, big.NewInt(100))
+	pair_2_3, _ := entities.NewPair(tokenAmount_2_100, tokenAmount_3_100)
+	pair_3_4, _ := entities.NewPair(tokenAmount_3_100, tokenAmount_4_100)
+	pair_0_4, _ := entities.NewPair(tokenAmount_0_100, tokenAmount_4_100)
+	registry.AddPair(pair_2_3)
+	if registry.Snapshot().Len() != 3 || registry.Snapshot().Number != 4 {
+		t.Errorf("expect[%+v], but got[%+v]", 3, registry.Snapshot().Len())
+	}
+	registry.AddPairs([]entities.Pair{pair_3_4, pair_0_4})
+	if registry.Snapshot().Len() != 5 || registry.Snapshot().Number != 4 {
+		t.Errorf("expect[%+v], but got[%+v]", 5, registry.Snapshot().Len())
+	}
+
+	// the history is bounded
+	if _, err := registry.ApplyBlock(mustBlock(5, blockHash(4))); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Rollback(blockHash(1)); err != ErrUnknownBlock {
+		t.Errorf("expect[%+v], but got[%+v]", ErrUnknownBlock, err)
+	}
+	if err := registry.Rollback(blockHash(2)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewBlock(t *testing.T) {
+	tokenAmount_0_1000, _ := entities.NewTokenAmount(token0, big.NewInt(1000))
+	tokenAmount_1_2000, _ := entities.NewTokenAmount(token1, big.NewInt(2000))
+	pair, _ := entities.NewPair(tokenAmount_0_1000, tokenAmount_1_2000)
+	removed := syncLog(pair.GetAddress(), 1, 1)
+	removed.Removed = true
+	block, err := NewBlock(1, blockHash(1), blockHash(0), []types.Log{
+		syncLog(pair.GetAddress(), 1, 1),
+		removed,
+		{Address: pair.GetAddress(), Topics: []common.Hash{common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Events) != 1 {
+		t.Errorf("expect[%+v], but got[%+v]", 1, len(block.Events))
+	}
+	invalid := syncLog(pair.GetAddress(), 1, 1)
+	invalid.Topics = append(invalid.Topics, common.Hash{})
+	if _, err := NewBlock(1, blockHash(1), blockHash(0), []types.Log{invalid}); err != ErrInvalidLog {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidLog, err)
+	}
+}
@@ -0,0 +1,55 @@
+package fetcher
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// erc20ABI is the subset of the ERC20 interface used to load token metadata
+const erc20ABI = `[
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"symbol","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]}
+]`
+
+// erc20Bytes32ABI is the metadata of early tokens returning bytes32, e.g. MKR
+const erc20Bytes32ABI = `[
+	{"type":"function","name":"symbol","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]}
+]`
+
+// pairABI is the subset of the UniswapV2Pair interface used to load pairs
+const pairABI = `[
+	{"type":"function","name":"getReserves","stateMutability":"view","inputs":[],"outputs":[{"name":"reserve0","type":"uint112"},{"name":"reserve1","type":"uint112"},{"name":"blockTimestampLast","type":"uint32"}]},
+	{"type":"function","name":"token0","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"token1","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"kLast","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]}
+]`
+
+// multicall3ABI is the aggregate3 method of Multicall3
+const multicall3ABI = `[
+	{"type":"function","name":"aggregate3","stateMutability":"payable",
+		"inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],
+		"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}
+]`
+
+var (
+	// ERC20ABI parsed ERC20 metadata methods
+	ERC20ABI = mustParseABI(erc20ABI)
+	// ERC20Bytes32ABI parsed ERC20 metadata methods returning bytes32
+	ERC20Bytes32ABI = mustParseABI(erc20Bytes32ABI)
+	// PairABI parsed UniswapV2Pair methods
+	PairABI = mustParseABI(pairABI)
+	// Multicall3ABI parsed Multicall3 aggregate3 method
+	Multicall3ABI = mustParseABI(multicall3ABI)
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
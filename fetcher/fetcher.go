@@ -0,0 +1,187 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+var (
+	// ErrNoContract the call returned nothing, e.g. there is no contract at the address
+	ErrNoContract = fmt.Errorf("no contract")
+)
+
+// ContractCaller is the minimal backend the fetcher calls contracts with, it is satisfied by ethclient.Client and
+// backends.SimulatedBackend of go-ethereum
+type ContractCaller interface {
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// Fetcher loads tokens and pairs from the chain, like the Fetcher of the Uniswap SDK
+type Fetcher struct {
+	caller ContractCaller
+	// BlockNumber the block to load the state at, nil is the latest block
+	BlockNumber *big.Int
+}
+
+// NewFetcher creates a Fetcher calling contracts with caller
+func NewFetcher(caller ContractCaller) *Fetcher {
+	return &Fetcher{caller: caller}
+}
+
+// callRaw calls the view method of the contract at address and returns its output
+func (f *Fetcher) callRaw(ctx context.Context, contract abi.ABI, address common.Address, method string, args ...interface{}) ([]byte, error) {
+	data, err := contract.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := f.caller.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data}, f.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(output) == 0 {
+		return nil, ErrNoContract
+	}
+	return output, nil
+}
+
+// call calls the view method of the contract at address and returns its unpacked outputs
+func (f *Fetcher) call(ctx context.Context, contract abi.ABI, address common.Address, method string, args ...interface{}) ([]interface{}, error) {
+	output, err := f.callRaw(ctx, contract, address, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return contract.Unpack(method, output)
+}
+
+/**
+ * FetchTokenData loads the decimals, symbol and name of the token at address. Symbol and name are optional,
+ * they are empty if the token does not implement them.
+ * @param chainID chain of the token
+ * @param address address of the token
+ */
+func (f *Fetcher) FetchTokenData(ctx context.Context, chainID constants.ChainID, address common.Address) (*entities.Token, error) {
+	outputs, err := f.call(ctx, ERC20ABI, address, "decimals")
+	if err != nil {
+		return nil, err
+	}
+	decimals := outputs[0].(uint8)
+	symbol := f.fetchString(ctx, address, "symbol")
+	name := f.fetchString(ctx, address, "name")
+	return entities.NewToken(chainID, address, int(decimals), symbol, name)
+}
+
+// fetchString returns the string of the metadata method, or empty on failure
+func (f *Fetcher) fetchString(ctx context.Context, address common.Address, method string) string {
+	output, err := f.callRaw(ctx, ERC20ABI, address, method)
+	if err != nil {
+		return ""
+	}
+	value, _ := decodeString(method, output)
+	return value
+}
+
+// decodeString decodes the output of the metadata method returning string, or bytes32 like early tokens, e.g. MKR
+func decodeString(method string, output []byte) (string, bool) {
+	if outputs, err := ERC20ABI.Unpack(method, output); err == nil {
+		return outputs[0].(string), true
+	}
+	if outputs, err := ERC20Bytes32ABI.Unpack(method, output); err == nil {
+		value := outputs[0].([32]byte)
+		return string(bytes.TrimRight(value[:], "\x00")), true
+	}
+	return "", false
+}
+
+// fetchReserves returns the reserves of the pair at address, in the order of token0 and token1
+func (f *Fetcher) fetchReserves(ctx context.Context, address common.Address) (reserve0, reserve1 *big.Int, err error) {
+	outputs, err := f.call(ctx, PairABI, address, "getReserves")
+	if err != nil {
+		return nil, nil, err
+	}
+	return outputs[0].(*big.Int), outputs[1].(*big.Int), nil
+}
+
+/**
+ * FetchPairData loads the pair of the two tokens created by factory
+ * @param tokenA first token
+ * @param tokenB second token
+ * @param factory factory that created the pair, nil is the Uniswap V2 factory
+ */
+func (f *Fetcher) FetchPairData(ctx context.Context, tokenA, tokenB *entities.Token, factory *entities.Factory) (entities.Pair, error) {
+	address, err := factoryOrDefault(factory).GetPairAddress(tokenA, tokenB)
+	if err != nil {
+		return nil, err
+	}
+	reserve0, reserve1, err := f.fetchReserves(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if ok, _ := tokenA.SortsBefore(tokenB); !ok {
+		tokenA, tokenB = tokenB, tokenA
+	}
+	return newPair(tokenA, tokenB, reserve0, reserve1, entities.NewPairBuilder().SetFactory(factory))
+}
+
+/**
+ * FetchPair loads the pair at address with the data of its tokens
+ * @param chainID chain of the pair
+ * @param address address of the pair
+ * @param factory factory that created the pair, nil is the Uniswap V2 factory
+ */
+func (f *Fetcher) FetchPair(ctx context.Context, chainID constants.ChainID, address common.Address, factory *entities.Factory) (entities.Pair, error) {
+	var tokens [2]*entities.Token
+	for i, method := range []string{"token0", "token1"} {
+		outputs, err := f.call(ctx, PairABI, address, method)
+		if err != nil {
+			return nil, err
+		}
+		if tokens[i], err = f.FetchTokenData(ctx, chainID, outputs[0].(common.Address)); err != nil {
+			return nil, err
+		}
+	}
+	reserve0, reserve1, err := f.fetchReserves(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return newPair(tokens[0], tokens[1], reserve0, reserve1, pairBuilder(address, tokens[0], tokens[1], factory))
+}
+
+// factoryOrDefault returns factory, or the Uniswap V2 factory if it is nil
+func factoryOrDefault(factory *entities.Factory) *entities.Factory {
+	if factory == nil {
+		return entities.UniswapV2Factory
+	}
+	return factory
+}
+
+// pairBuilder returns the builder of the pair of token0 and token1 at address created by factory
+func pairBuilder(address common.Address, token0, token1 *entities.Token, factory *entities.Factory) *entities.PairBuilder {
+	builder := entities.NewPairBuilder().SetFactory(factory)
+	// keep the address of pairs not derived from the factory, e.g. deployed by a fork of another init code
+	if derived, err := factoryOrDefault(factory).GetPairAddress(token0, token1); err != nil || derived != address {
+		builder.SetPairAddress(address)
+	}
+	return builder
+}
+
+// newPair builds the pair of token0 and token1 with the reserves
+func newPair(token0, token1 *entities.Token, reserve0, reserve1 *big.Int, builder *entities.PairBuilder) (entities.Pair, error) {
+	tokenAmount0, err := entities.NewTokenAmount(token0, reserve0)
+	if err != nil {
+		return nil, err
+	}
+	tokenAmount1, err := entities.NewTokenAmount(token1, reserve1)
+	if err != nil {
+		return nil, err
+	}
+	return builder.SetTokenAmounts(tokenAmount0, tokenAmount1).Build()
+}
@@ -0,0 +1,178 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+var _ ContractCaller = (*ethclient.Client)(nil)
+var _ ContractCaller = (*backends.SimulatedBackend)(nil)
+
+// viewCall is a view method of a test contract and the data it returns
+type viewCall struct {
+	selector []byte
+	output   []byte
+}
+
+func newViewCall(contract abi.ABI, method string, values ...interface{}) viewCall {
+	output, err := contract.Methods[method].Outputs.Pack(values...)
+	if err != nil {
+		panic(err)
+	}
+	return viewCall{selector: contract.Methods[method].ID, output: output}
+}
+
+// viewContract assembles the runtime code of a contract returning the constant output of each call, and reverting
+// on other selectors
+func viewContract(calls ...viewCall) []byte {
+	sort.Slice(calls, func(i, j int) bool { return string(calls[i].selector) < string(calls[j].selector) })
+	u16 := func(v int) []byte {
+		b := make([]byte, 2)
+		binary.BigEndian.PutUint16(b, uint16(v))
+		return b
+	}
+
+	const header, dispatch, fallback, body = 6, 11, 4, 16
+	bodies := header + dispatch*len(calls) + fallback
+	data := bodies + body*len(calls)
+
+	// PUSH1 0 CALLDATALOAD PUSH1 0xe0 SHR
+	code := []byte{0x60, 0x00, 0x35, 0x60, 0xe0, 0x1c}
+	for i, call := range calls {
+		// DUP1 PUSH4 selector EQ PUSH2 body JUMPI
+		code = append(code, 0x80, 0x63)
+		code = append(code, call.selector...)
+		code = append(code, 0x14, 0x61)
+		code = append(code, u16(bodies+body*i)...)
+		code = append(code, 0x57)
+	}
+	// PUSH1 0 DUP1 REVERT
+	code = append(code, 0x60, 0x00, 0x80, 0xfd)
+	for _, call := range calls {
+		// JUMPDEST PUSH2 length PUSH2 offset PUSH1 0 CODECOPY PUSH2 length PUSH1 0 RETURN
+		code = append(code, 0x5b, 0x61)
+		code = append(code, u16(len(call.output))...)
+		code = append(code, 0x61)
+		code = append(code, u16(data)...)
+		code = append(code, 0x60, 0x00, 0x39, 0x61)
+		code = append(code, u16(len(call.output))...)
+		code = append(code, 0x60, 0x00, 0xf3)
+		data += len(call.output)
+	}
+	for _, call := range calls {
+		code = append(code, call.output...)
+	}
+	return code
+}
+
+func bytes32(s string) [32]byte {
+	var b [32]byte
+	copy(b[:], s)
+	return b
+}
+
+// nolint funlen
+func TestFetcher(t *testing.T) {
+	ctx := context.Background()
+	addressA := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	addressB := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	addressC := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	tokenA, _ := entities.NewToken(constants.Mainnet, addressA, 18, "", "")
+	tokenB, _ := entities.NewToken(constants.Mainnet, addressB, 6, "", "")
+	pairAddress, _ := entities.UniswapV2Factory.GetPairAddress(tokenA, tokenB)
+	forkPairAddress := common.HexToAddress("0x4000000000000000000000000000000000000004")
+	reserve0, reserve1 := big.NewInt(3000000), big.NewInt(1000)
+
+	pairCode := viewContract(
+		newViewCall(PairABI, "getReserves", reserve0, reserve1, uint32(1)),
+		newViewCall(PairABI, "token0", addressA),
+		newViewCall(PairABI, "token1", addressB),
+	)
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		addressA: {Balance: new(big.Int), Code: viewContract(
+			newViewCall(ERC20ABI, "decimals", uint8(18)),
+			newViewCall(ERC20ABI, "symbol", "TA"),
+			newViewCall(ERC20ABI, "name", "Token A"),
+		)},
+		// early tokens return bytes32 metadata
+		addressB: {Balance: new(big.Int), Code: viewContract(
+			newViewCall(ERC20ABI, "decimals", uint8(6)),
+			newViewCall(ERC20Bytes32ABI, "symbol", bytes32("TB")),
+			newViewCall(ERC20Bytes32ABI, "name", bytes32("Token B")),
+		)},
+		// metadata is optional
+		addressC:        {Balance: new(big.Int), Code: viewContract(newViewCall(ERC20ABI, "decimals", uint8(8)))},
+		pairAddress:     {Balance: new(big.Int), Code: pairCode},
+		forkPairAddress: {Balance: new(big.Int), Code: pairCode},
+	}, 8000000)
+	defer backend.Close()
+	fetcher := NewFetcher(backend)
+
+	for _, test := range []struct {
+		address  common.Address
+		decimals int
+		symbol   string
+		name     string
+	}{
+		{addressA, 18, "TA", "Token A"},
+		{addressB, 6, "TB", "Token B"},
+		{addressC, 8, "", ""},
+	} {
+		token, err := fetcher.FetchTokenData(ctx, constants.Mainnet, test.address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Address != test.address || token.Decimals != test.decimals || token.Symbol != test.symbol || token.Name != test.name {
+			t.Errorf("expect[%+v %+v %+v], but got[%+v]", test.decimals, test.symbol, test.name, token)
+		}
+	}
+	if _, err := fetcher.FetchTokenData(ctx, constants.Mainnet, common.HexToAddress("0x5000000000000000000000000000000000000005")); err != ErrNoContract {
+		t.Errorf("expect[%+v], but got[%+v]", ErrNoContract, err)
+	}
+
+	// the reserves are in the order of the sorted tokens
+	for _, tokens := range [][2]*entities.Token{{tokenA, tokenB}, {tokenB, tokenA}} {
+		pair, err := fetcher.FetchPairData(ctx, tokens[0], tokens[1], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pair.GetAddress() != pairAddress {
+			t.Errorf("expect[%+v], but got[%+v]", pairAddress.String(), pair.GetAddress().String())
+		}
+		if reserve, _ := pair.ReserveOf(tokenA); reserve.Raw().Cmp(reserve0) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", reserve0, reserve.Raw())
+		}
+		if reserve, _ := pair.ReserveOf(tokenB); reserve.Raw().Cmp(reserve1) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", reserve1, reserve.Raw())
+		}
+	}
+
+	// pairs loaded by address have the data of their tokens
+	for _, address := range []common.Address{pairAddress, forkPairAddress} {
+		pair, err := fetcher.FetchPair(ctx, constants.Mainnet, address, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pair.GetAddress() != address || pair.Token0().Symbol != "TA" || pair.Token1().Decimals != 6 {
+			t.Errorf("expect[%+v], but got[%+v %+v %+v]", address.String(), pair.GetAddress().String(), pair.Token0(), pair.Token1())
+		}
+		if pair.Reserve1().Raw().Cmp(reserve1) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", reserve1, pair.Reserve1().Raw())
+		}
+	}
+	if _, err := fetcher.FetchPair(ctx, constants.Mainnet, addressA, nil); err == nil {
+		t.Error("token is not a pair")
+	}
+}
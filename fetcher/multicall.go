@@ -0,0 +1,352 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+// DefaultChunkSize the default number of calls aggregated in one eth_call
+const DefaultChunkSize = 500
+
+var (
+	// ErrCallFailed the call in the batch reverted or returned nothing
+	ErrCallFailed = fmt.Errorf("call failed")
+	// ErrUnsupportedPair the pair can not be copied with new reserves
+	ErrUnsupportedPair = fmt.Errorf("unsupported pair")
+
+	// Multicall3Address the address Multicall3 is deployed at on most chains
+	Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+)
+
+// Call3 is a call aggregated by aggregate3
+type Call3 struct {
+	Target common.Address
+	// AllowFailure the call may revert without reverting the whole aggregate3
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Result3 is the result of a call aggregated by aggregate3
+type Result3 struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// Failure is a contract the batch failed to load, with the method and error of its failed call
+type Failure struct {
+	Address common.Address
+	Method  string
+	Err     error
+}
+
+// PairData is a pair loaded by the batch, with the state of its liquidity token
+type PairData struct {
+	Pair        entities.Pair
+	TotalSupply *big.Int
+	// KLast nil if the pair does not implement kLast
+	KLast *big.Int
+}
+
+// PairsResult is the pairs loaded by the batch and the pairs it failed to load
+type PairsResult struct {
+	Pairs  []*PairData
+	Failed []*Failure
+}
+
+// Multicall loads tokens and pairs in bulk, batching their calls through Multicall3 aggregate3
+type Multicall struct {
+	caller ContractCaller
+	// Address the address of Multicall3
+	Address common.Address
+	// ChunkSize the number of calls aggregated in one eth_call
+	ChunkSize int
+	// AllowFailure reports the tokens and pairs whose calls failed, instead of failing the whole chunk
+	AllowFailure bool
+	// BlockNumber the block to load the state at, nil is the latest block
+	BlockNumber *big.Int
+}
+
+// NewMulticall creates a Multicall calling the canonical Multicall3 with caller, tolerating failed calls
+func NewMulticall(caller ContractCaller) *Multicall {
+	return &Multicall{
+		caller:       caller,
+		Address:      Multicall3Address,
+		ChunkSize:    DefaultChunkSize,
+		AllowFailure: true,
+	}
+}
+
+// Aggregate3 calls aggregate3 with the calls, in chunks of ChunkSize calls, and returns the results in the order of
+// the calls
+func (m *Multicall) Aggregate3(ctx context.Context, calls []Call3) ([]*Result3, error) {
+	chunkSize := m.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	results := make([]*Result3, 0, len(calls))
+	for start := 0; start < len(calls); start += chunkSize {
+		end := start + chunkSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		data, err := Multicall3ABI.Pack("aggregate3", calls[start:end])
+		if err != nil {
+			return nil, err
+		}
+		output, err := m.caller.CallContract(ctx, ethereum.CallMsg{To: &m.Address, Data: data}, m.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if len(output) == 0 {
+			return nil, ErrNoContract
+		}
+		outputs, err := Multicall3ABI.Unpack("aggregate3", output)
+		if err != nil {
+			return nil, err
+		}
+		chunk := *abi.ConvertType(outputs[0], new([]Result3)).(*[]Result3)
+		if len(chunk) != end-start {
+			return nil, ErrCallFailed
+		}
+		for i := range chunk {
+			results = append(results, &chunk[i])
+		}
+	}
+	return results, nil
+}
+
+// newCall3 returns the call of the view method without arguments of the contract at target
+func newCall3(target common.Address, contract abi.ABI, method string, allowFailure bool) Call3 {
+	return Call3{
+		Target:       target,
+		AllowFailure: allowFailure,
+		CallData:     contract.Methods[method].ID,
+	}
+}
+
+// unpack returns the unpacked outputs of the result of the method
+func unpack(contract abi.ABI, method string, result *Result3) ([]interface{}, error) {
+	if !result.Success || len(result.ReturnData) == 0 {
+		return nil, ErrCallFailed
+	}
+	return contract.Unpack(method, result.ReturnData)
+}
+
+/**
+ * FetchTokens loads the decimals, symbol and name of the tokens at addresses. Symbol and name are optional,
+ * they are empty if the token does not implement them.
+ * @param chainID chain of the tokens
+ * @param addresses addresses of the tokens
+ * @return the loaded tokens by address, and the tokens failed to load
+ */
+func (m *Multicall) FetchTokens(ctx context.Context, chainID constants.ChainID, addresses []common.Address) (map[common.Address]*entities.Token, []*Failure, error) {
+	const callsPerToken = 3
+	calls := make([]Call3, 0, callsPerToken*len(addresses))
+	for _, address := range addresses {
+		calls = append(calls,
+			newCall3(address, ERC20ABI, "decimals", m.AllowFailure),
+			newCall3(address, ERC20ABI, "symbol", true),
+			newCall3(address, ERC20ABI, "name", true),
+		)
+	}
+	results, err := m.Aggregate3(ctx, calls)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens := make(map[common.Address]*entities.Token, len(addresses))
+	var failed []*Failure
+	for i, address := range addresses {
+		result := results[callsPerToken*i : callsPerToken*(i+1)]
+		outputs, err := unpack(ERC20ABI, "decimals", result[0])
+		if err != nil {
+			failed = append(failed, &Failure{Address: address, Method: "decimals", Err: err})
+			continue
+		}
+		var symbol, name string
+		if result[1].Success {
+			symbol, _ = decodeString("symbol", result[1].ReturnData)
+		}
+		if result[2].Success {
+			name, _ = decodeString("name", result[2].ReturnData)
+		}
+		token, err := entities.NewToken(chainID, address, int(outputs[0].(uint8)), symbol, name)
+		if err != nil {
+			failed = append(failed, &Failure{Address: address, Method: "decimals", Err: err})
+			continue
+		}
+		tokens[address] = token
+	}
+	return tokens, failed, nil
+}
+
+// pairState is the state of a pair loaded by the batch
+type pairState struct {
+	reserve0, reserve1 *big.Int
+	totalSupply, kLast *big.Int
+}
+
+// callsPerPairState the number of calls of pairStateCalls
+const callsPerPairState = 3
+
+// pairStateCalls returns the calls loading the state of the pair at address
+func (m *Multicall) pairStateCalls(address common.Address) []Call3 {
+	return []Call3{
+		newCall3(address, PairABI, "getReserves", m.AllowFailure),
+		newCall3(address, PairABI, "totalSupply", m.AllowFailure),
+		// forks may not implement kLast
+		newCall3(address, PairABI, "kLast", true),
+	}
+}
+
+// decodePairState decodes the results of pairStateCalls, returns the method of the failed call on failure
+func decodePairState(results []*Result3) (*pairState, string, error) {
+	outputs, err := unpack(PairABI, "getReserves", results[0])
+	if err != nil {
+		return nil, "getReserves", err
+	}
+	state := &pairState{reserve0: outputs[0].(*big.Int), reserve1: outputs[1].(*big.Int)}
+	if outputs, err = unpack(PairABI, "totalSupply", results[1]); err != nil {
+		return nil, "totalSupply", err
+	}
+	state.totalSupply = outputs[0].(*big.Int)
+	if outputs, err = unpack(PairABI, "kLast", results[2]); err == nil {
+		state.kLast = outputs[0].(*big.Int)
+	}
+	return state, "", nil
+}
+
+/**
+ * FetchPairs loads the pairs at addresses with the data of their tokens
+ * @param chainID chain of the pairs
+ * @param addresses addresses of the pairs
+ * @param factory factory that created the pairs, nil is the Uniswap V2 factory
+ * @return the loaded pairs in the order of addresses, and the pairs failed to load
+ */
+func (m *Multicall) FetchPairs(ctx context.Context, chainID constants.ChainID, addresses []common.Address, factory *entities.Factory) (*PairsResult, error) {
+	const callsPerPair = 2 + callsPerPairState
+	calls := make([]Call3, 0, callsPerPair*len(addresses))
+	for _, address := range addresses {
+		calls = append(calls,
+			newCall3(address, PairABI, "token0", m.AllowFailure),
+			newCall3(address, PairABI, "token1", m.AllowFailure),
+		)
+		calls = append(calls, m.pairStateCalls(address)...)
+	}
+	results, err := m.Aggregate3(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	type pending struct {
+		address        common.Address
+		token0, token1 common.Address
+		state          *pairState
+	}
+	result := &PairsResult{}
+	pairs := make([]*pending, 0, len(addresses))
+	var tokenAddresses []common.Address
+	seen := make(map[common.Address]bool)
+	for i, address := range addresses {
+		pairResults := results[callsPerPair*i : callsPerPair*(i+1)]
+		var tokens [2]common.Address
+		var err error
+		for j, method := range []string{"token0", "token1"} {
+			var outputs []interface{}
+			if outputs, err = unpack(PairABI, method, pairResults[j]); err != nil {
+				result.Failed = append(result.Failed, &Failure{Address: address, Method: method, Err: err})
+				break
+			}
+			tokens[j] = outputs[0].(common.Address)
+		}
+		if err != nil {
+			continue
+		}
+		state, method, err := decodePairState(pairResults[2:])
+		if err != nil {
+			result.Failed = append(result.Failed, &Failure{Address: address, Method: method, Err: err})
+			continue
+		}
+		pairs = append(pairs, &pending{address: address, token0: tokens[0], token1: tokens[1], state: state})
+		for _, token := range tokens {
+			if !seen[token] {
+				seen[token] = true
+				tokenAddresses = append(tokenAddresses, token)
+			}
+		}
+	}
+
+	tokens, failedTokens, err := m.FetchTokens(ctx, chainID, tokenAddresses)
+	if err != nil {
+		return nil, err
+	}
+	tokenFailures := make(map[common.Address]*Failure, len(failedTokens))
+	for _, failure := range failedTokens {
+		tokenFailures[failure.Address] = failure
+	}
+	for _, p := range pairs {
+		if failure, ok := tokenFailures[p.token0]; ok {
+			result.Failed = append(result.Failed, &Failure{Address: p.address, Method: failure.Method, Err: failure.Err})
+			continue
+		}
+		if failure, ok := tokenFailures[p.token1]; ok {
+			result.Failed = append(result.Failed, &Failure{Address: p.address, Method: failure.Method, Err: failure.Err})
+			continue
+		}
+		token0, token1 := tokens[p.token0], tokens[p.token1]
+		pair, err := newPair(token0, token1, p.state.reserve0, p.state.reserve1, pairBuilder(p.address, token0, token1, factory))
+		if err != nil {
+			result.Failed = append(result.Failed, &Failure{Address: p.address, Err: err})
+			continue
+		}
+		result.Pairs = append(result.Pairs, &PairData{Pair: pair, TotalSupply: p.state.totalSupply, KLast: p.state.kLast})
+	}
+	return result, nil
+}
+
+/**
+ * FetchReserves reloads the reserves of the known pairs, e.g. to refresh the pairs of a route search
+ * @param pairs pairs to reload, copied with the new reserves
+ * @return the reloaded pairs, and the pairs failed to reload
+ */
+func (m *Multicall) FetchReserves(ctx context.Context, pairs []entities.Pair) (*PairsResult, error) {
+	result := &PairsResult{}
+	copied := make([]entities.Pair, 0, len(pairs))
+	calls := make([]Call3, 0, callsPerPairState*len(pairs))
+	for _, pair := range pairs {
+		if _, ok := pair.(entities.CopyablePair); !ok {
+			result.Failed = append(result.Failed, &Failure{Address: pair.GetAddress(), Err: ErrUnsupportedPair})
+			continue
+		}
+		copied = append(copied, pair)
+		calls = append(calls, m.pairStateCalls(pair.GetAddress())...)
+	}
+	results, err := m.Aggregate3(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, pair := range copied {
+		state, method, err := decodePairState(results[callsPerPairState*i : callsPerPairState*(i+1)])
+		if err != nil {
+			result.Failed = append(result.Failed, &Failure{Address: pair.GetAddress(), Method: method, Err: err})
+			continue
+		}
+		reloaded, err := entities.CopyWithReserves(pair, state.reserve0, state.reserve1)
+		if err != nil {
+			result.Failed = append(result.Failed, &Failure{Address: pair.GetAddress(), Err: err})
+			continue
+		}
+		result.Pairs = append(result.Pairs, &PairData{Pair: reloaded, TotalSupply: state.totalSupply, KLast: state.kLast})
+	}
+	return result, nil
+}
@@ -0,0 +1,218 @@
+package fetcher
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+// multicall3Code is the runtime code of Multicall3 deployed at Multicall3Address, built by solc 0.8.12
+var multicall3Code = common.FromHex("0x" +
+	"6080604052600436106100f35760003560e01c80634d2301cc1161008a578063a8b0574e11610059578063a8b0574e1461025a578063bce38bd7146102755780" +
+	"63c3077fa914610288578063ee82ac5e1461029b57600080fd5b80634d2301cc146101ec57806372425d9d1461022157806382ad56cb1461023457806386d516" +
+	"e81461024757600080fd5b80633408e470116100c65780633408e47014610191578063399542e9146101a45780633e64a696146101c657806342cbb15c146101" +
+	"d957600080fd5b80630f28c97d146100f8578063174dea711461011a578063252dba421461013a57806327e86d6e1461015b575b600080fd5b34801561010457" +
+	"600080fd5b50425b6040519081526020015b60405180910390f35b61012d610128366004610a85565b6102ba565b6040516101119190610bbe565b61014d6101" +
+	"48366004610a85565b6104ef565b604051610111929190610bd8565b34801561016757600080fd5b50437fffffffffffffffffffffffffffffffffffffffffff" +
+	"ffffffffffffffffffffff0140610107565b34801561019d57600080fd5b5046610107565b6101b76101b2366004610c60565b610690565b6040516101119392" +
+	"9190610cba565b3480156101d257600080fd5b5048610107565b3480156101e557600080fd5b5043610107565b3480156101f857600080fd5b50610107610207" +
+	"366004610ce2565b73ffffffffffffffffffffffffffffffffffffffff163190565b34801561022d57600080fd5b5044610107565b61012d610242366004610a" +
+	"85565b6106ab565b34801561025357600080fd5b5045610107565b34801561026657600080fd5b50604051418152602001610111565b61012d61028336600461" +
+	"0c60565b61085a565b6101b7610296366004610a85565b610a1a565b3480156102a757600080fd5b506101076102b6366004610d18565b4090565b6060600082" +
+	"8067ffffffffffffffff8111156102d8576102d8610d31565b60405190808252806020026020018201604052801561031e57816020015b604080518082019091" +
+	"5260008152606060208201528152602001906001900390816102f65790505b5092503660005b8281101561047757600085828151811061034157610341610d60" +
+	"565b6020026020010151905087878381811061035d5761035d610d60565b905060200281019061036f9190610d8f565b60408101359586019590935061038860" +
+	"20850185610ce2565b73ffffffffffffffffffffffffffffffffffffffff16816103ac6060870187610dcd565b6040516103ba929190610e32565b6000604051" +
+	"8083038185875af1925050503d80600081146103f7576040519150601f19603f3d011682016040523d82523d6000602084013e6103fc565b606091505b506020" +
+	"80850191909152901515808452908501351761046d577f08c379a000000000000000000000000000000000000000000000000000000000600052602060045260" +
+	"176024527f4d756c746963616c6c333a2063616c6c206661696c656400000000000000000060445260846000fd5b5050600101610325565b508234146104e657" +
+	"6040517f08c379a000000000000000000000000000000000000000000000000000000000815260206004820152601a60248201527f4d756c746963616c6c333a" +
+	"2076616c7565206d69736d6174636800000000000060448201526064015b60405180910390fd5b50505092915050565b436060828067ffffffffffffffff8111" +
+	"1561050c5761050c610d31565b60405190808252806020026020018201604052801561053f57816020015b606081526020019060019003908161052a5790505b" +
+	"5091503660005b8281101561068657600087878381811061056257610562610d60565b90506020028101906105749190610e42565b9250610583602084018461" +
+	"0ce2565b73ffffffffffffffffffffffffffffffffffffffff166105a66020850185610dcd565b6040516105b4929190610e32565b6000604051808303816000" +
+	"865af19150503d80600081146105f1576040519150601f19603f3d011682016040523d82523d6000602084013e6105f6565b606091505b508684815181106106" +
+	"0957610609610d60565b602090810291909101015290508061067d576040517f08c379a000000000000000000000000000000000000000000000000000000000" +
+	"815260206004820152601760248201527f4d756c746963616c6c333a2063616c6c206661696c656400000000000000000060448201526064016104dd565b5060" +
+	"0101610546565b5050509250929050565b43804060606106a086868661085a565b905093509350939050565b6060818067ffffffffffffffff8111156106c757" +
+	"6106c7610d31565b60405190808252806020026020018201604052801561070d57816020015b6040805180820190915260008152606060208201528152602001" +
+	"906001900390816106e55790505b5091503660005b828110156104e657600084828151811061073057610730610d60565b602002602001015190508686838181" +
+	"1061074c5761074c610d60565b905060200281019061075e9190610e76565b925061076d6020840184610ce2565b73ffffffffffffffffffffffffffffffffff" +
+	"ffffff166107906040850185610dcd565b60405161079e929190610e32565b6000604051808303816000865af19150503d80600081146107db57604051915060" +
+	"1f19603f3d011682016040523d82523d6000602084013e6107e0565b606091505b506020808401919091529015158083529084013517610851577f08c379a000" +
+	"000000000000000000000000000000000000000000000000000000600052602060045260176024527f4d756c746963616c6c333a2063616c6c206661696c6564" +
+	"00000000000000000060445260646000fd5b50600101610714565b6060818067ffffffffffffffff81111561087657610876610d31565b604051908082528060" +
+	"2002602001820160405280156108bc57816020015b6040805180820190915260008152606060208201528152602001906001900390816108945790505b509150" +
+	"3660005b82811015610a105760008482815181106108df576108df610d60565b602002602001015190508686838181106108fb576108fb610d60565b90506020" +
+	"0281019061090d9190610e42565b925061091c6020840184610ce2565b73ffffffffffffffffffffffffffffffffffffffff1661093f6020850185610dcd565b" +
+	"60405161094d929190610e32565b6000604051808303816000865af19150503d806000811461098a576040519150601f19603f3d011682016040523d82523d60" +
+	"00602084013e61098f565b606091505b506020830152151581528715610a07578051610a07576040517f08c379a0000000000000000000000000000000000000" +
+	"00000000000000000000815260206004820152601760248201527f4d756c746963616c6c333a2063616c6c206661696c65640000000000000000006044820152" +
+	"6064016104dd565b506001016108c3565b5050509392505050565b6000806060610a2b60018686610690565b919790965090945092505050565b60008083601f" +
+	"840112610a4b57600080fd5b50813567ffffffffffffffff811115610a6357600080fd5b6020830191508360208260051b8501011115610a7e57600080fd5b92" +
+	"50929050565b60008060208385031215610a9857600080fd5b823567ffffffffffffffff811115610aaf57600080fd5b610abb85828601610a39565b90969095" +
+	"509350505050565b6000815180845260005b81811015610aed57602081850181015186830182015201610ad1565b81811115610aff576000602083870101525b" +
+	"50601f017fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe0169290920160200192915050565b60008282518085526020808601" +
+	"9550808260051b84010181860160005b84811015610bb1578583037fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe001895281" +
+	"518051151584528401516040858501819052610b9d81860183610ac7565b9a86019a9450505090830190600101610b4f565b5090979650505050505050565b60" +
+	"2081526000610bd16020830184610b32565b9392505050565b600060408201848352602060408185015281855180845260608601915060608160051b87010193" +
+	"5082870160005b82811015610c52577fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffa0888703018452610c40868351610ac756" +
+	"5b95509284019290840190600101610c06565b509398975050505050505050565b600080600060408486031215610c7557600080fd5b83358015158114610c85" +
+	"57600080fd5b9250602084013567ffffffffffffffff811115610ca157600080fd5b610cad86828701610a39565b9497909650939450505050565b8381528260" +
+	"20820152606060408201526000610cd96060830184610b32565b95945050505050565b600060208284031215610cf457600080fd5b813573ffffffffffffffff" +
+	"ffffffffffffffffffffffff81168114610bd157600080fd5b600060208284031215610d2a57600080fd5b5035919050565b7f4e487b71000000000000000000" +
+	"00000000000000000000000000000000000000600052604160045260246000fd5b7f4e487b710000000000000000000000000000000000000000000000000000" +
+	"0000600052603260045260246000fd5b600082357fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff81833603018112610dc35760" +
+	"0080fd5b9190910192915050565b60008083357fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe1843603018112610e02576000" +
+	"80fd5b83018035915067ffffffffffffffff821115610e1d57600080fd5b602001915036819003821315610a7e57600080fd5b81838237600091019081529190" +
+	"50565b600082357fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffc1833603018112610dc357600080fd5b600082357fffffffff" +
+	"ffffffffffffffffffffffffffffffffffffffffffffffffffffffa1833603018112610dc357600080fdfea2646970667358221220bb2b5c71a328032f97c676" +
+	"ae39a1ec2148d3e5d6f73d95e9b17910152d61f16264736f6c634300080c0033")
+
+// frozenPair is a pair which can not be copied with new reserves
+type frozenPair struct {
+	entities.Pair
+}
+
+// nolint funlen
+func TestMulticall(t *testing.T) {
+	ctx := context.Background()
+	addressA := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	addressB := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	addressC := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	addressD := common.HexToAddress("0x4000000000000000000000000000000000000004")
+	tokenA, _ := entities.NewToken(constants.Mainnet, addressA, 18, "", "")
+	tokenB, _ := entities.NewToken(constants.Mainnet, addressB, 6, "", "")
+	pairAB, _ := entities.UniswapV2Factory.GetPairAddress(tokenA, tokenB)
+	pairAD := common.HexToAddress("0x5000000000000000000000000000000000000005")
+	pairBroken := common.HexToAddress("0x6000000000000000000000000000000000000006")
+	pairAC := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	eoa := common.HexToAddress("0x8000000000000000000000000000000000000008")
+
+	pairCode := func(token0, token1 common.Address, reserve0, reserve1, totalSupply int64, kLast bool) []byte {
+		calls := []viewCall{
+			newViewCall(PairABI, "token0", token0),
+			newViewCall(PairABI, "token1", token1),
+			newViewCall(PairABI, "getReserves", big.NewInt(reserve0), big.NewInt(reserve1), uint32(1)),
+			newViewCall(PairABI, "totalSupply", big.NewInt(totalSupply)),
+		}
+		if kLast {
+			calls = append(calls, newViewCall(PairABI, "kLast", big.NewInt(reserve0*reserve1)))
+		}
+		return viewContract(calls...)
+	}
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		Multicall3Address: {Balance: new(big.Int), Code: multicall3Code},
+		addressA: {Balance: new(big.Int), Code: viewContract(
+			newViewCall(ERC20ABI, "decimals", uint8(18)),
+			newViewCall(ERC20ABI, "symbol", "TA"),
+			newViewCall(ERC20ABI, "name", "Token A"),
+		)},
+		addressB: {Balance: new(big.Int), Code: viewContract(
+			newViewCall(ERC20ABI, "decimals", uint8(6)),
+			newViewCall(ERC20Bytes32ABI, "symbol", bytes32("TB")),
+			newViewCall(ERC20Bytes32ABI, "name", bytes32("Token B")),
+		)},
+		// not a token
+		addressC: {Balance: new(big.Int), Code: viewContract(newViewCall(ERC20ABI, "symbol", "TC"))},
+		addressD: {Balance: new(big.Int), Code: viewContract(newViewCall(ERC20ABI, "decimals", uint8(8)))},
+		pairAB:   {Balance: new(big.Int), Code: pairCode(addressA, addressB, 3000, 1000, 1700, true)},
+		// forks may not implement kLast
+		pairAD: {Balance: new(big.Int), Code: pairCode(addressA, addressD, 500, 700, 600, false)},
+		pairBroken: {Balance: new(big.Int), Code: viewContract(
+			newViewCall(PairABI, "token0", addressA),
+			newViewCall(PairABI, "token1", addressB),
+		)},
+		pairAC: {Balance: new(big.Int), Code: pairCode(addressA, addressC, 1, 1, 1, true)},
+	}, 80000000)
+	defer backend.Close()
+
+	multicall := NewMulticall(backend)
+	// several chunks
+	multicall.ChunkSize = 4
+	result, err := multicall.FetchPairs(ctx, constants.Mainnet, []common.Address{pairAB, pairBroken, pairAD, eoa, pairAC}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Pairs) != 2 {
+		t.Fatalf("expect[%+v], but got[%+v]", 2, len(result.Pairs))
+	}
+	for i, test := range []struct {
+		address     common.Address
+		symbol0     string
+		symbol1     string
+		decimals1   int
+		reserve0    int64
+		reserve1    int64
+		totalSupply int64
+		kLast       *big.Int
+	}{
+		{pairAB, "TA", "TB", 6, 3000, 1000, 1700, big.NewInt(3000000)},
+		{pairAD, "TA", "", 8, 500, 700, 600, nil},
+	} {
+		data := result.Pairs[i]
+		pair := data.Pair
+		if pair.GetAddress() != test.address || pair.Token0().Symbol != test.symbol0 ||
+			pair.Token1().Symbol != test.symbol1 || pair.Token1().Decimals != test.decimals1 {
+			t.Errorf("expect[%+v], but got[%+v %+v %+v]", test.address.String(), pair.GetAddress().String(), pair.Token0(), pair.Token1())
+		}
+		if pair.Reserve0().Raw().Int64() != test.reserve0 || pair.Reserve1().Raw().Int64() != test.reserve1 {
+			t.Errorf("expect[%+v %+v], but got[%+v %+v]", test.reserve0, test.reserve1, pair.Reserve0().Raw(), pair.Reserve1().Raw())
+		}
+		if data.TotalSupply.Int64() != test.totalSupply || (data.KLast == nil) != (test.kLast == nil) ||
+			(data.KLast != nil && data.KLast.Cmp(test.kLast) != 0) {
+			t.Errorf("expect[%+v %+v], but got[%+v %+v]", test.totalSupply, test.kLast, data.TotalSupply, data.KLast)
+		}
+	}
+	expectFailed := []*Failure{
+		{Address: pairBroken, Method: "getReserves", Err: ErrCallFailed},
+		{Address: eoa, Method: "token0", Err: ErrCallFailed},
+		{Address: pairAC, Method: "decimals", Err: ErrCallFailed},
+	}
+	if len(result.Failed) != len(expectFailed) {
+		t.Fatalf("expect[%+v], but got[%+v]", len(expectFailed), len(result.Failed))
+	}
+	for i, expect := range expectFailed {
+		if failed := result.Failed[i]; failed.Address != expect.Address || failed.Method != expect.Method || failed.Err != expect.Err {
+			t.Errorf("expect[%+v], but got[%+v]", expect, failed)
+		}
+	}
+
+	// reload the reserves of known pairs
+	{
+		stale0, _ := entities.NewTokenAmount(result.Pairs[0].Pair.Token0(), big.NewInt(1))
+		stale1, _ := entities.NewTokenAmount(result.Pairs[0].Pair.Token1(), big.NewInt(1))
+		stale, _ := entities.NewPair(stale0, stale1)
+		result, err := multicall.FetchReserves(ctx, []entities.Pair{stale, frozenPair{stale}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Pairs) != 1 || result.Pairs[0].Pair.Reserve0().Raw().Int64() != 3000 || result.Pairs[0].TotalSupply.Int64() != 1700 {
+			t.Errorf("expect reloaded reserves, but got[%+v]", result.Pairs)
+		}
+		if len(result.Failed) != 1 || result.Failed[0].Err != ErrUnsupportedPair {
+			t.Errorf("expect[%+v], but got[%+v]", ErrUnsupportedPair, result.Failed)
+		}
+	}
+
+	// failed calls revert the whole chunk if not allowed
+	multicall.AllowFailure = false
+	if _, err := multicall.FetchPairs(ctx, constants.Mainnet, []common.Address{pairAB}, nil); err != nil {
+		t.Error(err)
+	}
+	if _, err := multicall.FetchPairs(ctx, constants.Mainnet, []common.Address{pairAB, pairBroken}, nil); err == nil {
+		t.Error("failed call should fail the batch")
+	}
+
+	multicall.Address = eoa
+	if _, err := multicall.FetchPairs(ctx, constants.Mainnet, []common.Address{pairAB}, nil); err != ErrNoContract {
+		t.Errorf("expect[%+v], but got[%+v]", ErrNoContract, err)
+	}
+}
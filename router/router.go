@@ -0,0 +1,160 @@
+package router
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+var (
+	// ErrEtherInOut trade can not have ether as both input and output
+	ErrEtherInOut = fmt.Errorf("ether in and ether out")
+	// ErrExactOutFeeOnTransfer fee on transfer tokens only support exact input
+	ErrExactOutFeeOnTransfer = fmt.Errorf("exact out fee on transfer")
+	// ErrInvalidTTL ttl or deadline must be set
+	ErrInvalidTTL = fmt.Errorf("invalid ttl")
+	// ErrInvalidOptions trade options must be set
+	ErrInvalidOptions = fmt.Errorf("invalid options")
+)
+
+// TradeOptions options for producing the arguments to send call to the router
+type TradeOptions struct {
+	// how much the execution price is allowed to move unfavorably from the trade execution price
+	AllowedSlippage *entities.Percent
+	// how long the swap is valid until it expires, in seconds
+	// this will be used to produce a `deadline` parameter which is computed from when the swap call parameters
+	// are generated
+	TTL int64
+	// unix timestamp after which the swap expires, takes precedence over TTL when set
+	Deadline int64
+	// the account that should receive the output of the swap
+	Recipient common.Address
+	// whether any of the tokens in the path are fee on transfer tokens, which should be handled with special methods.
+	// it is implied if tokens of the route carry taxes
+	FeeOnTransfer bool
+}
+
+// SwapParameters the parameters to use in the call to the Uniswap V2 Router to execute a trade
+type SwapParameters struct {
+	// the method to call on the Uniswap V2 Router
+	MethodName string
+	// the arguments to pass to the method, in ABI order
+	Args []interface{}
+	// the amount of wei to send
+	Value *big.Int
+	// the ABI encoded call data, ready to be sent to the router
+	Calldata []byte
+}
+
+// SwapCallParameters produces the on-chain method name to call and the hex encoded parameters to pass as arguments
+// for a given trade.
+// @param trade to produce call parameters for
+// @param options options for the call parameters
+func SwapCallParameters(trade *entities.Trade, options *TradeOptions) (*SwapParameters, error) {
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	etherIn := isEther(trade.Route.Input)
+	etherOut := isEther(trade.Route.Output)
+	// the router does not support both ether in and out
+	if etherIn && etherOut {
+		return nil, ErrEtherInOut
+	}
+	if options.TTL <= 0 && options.Deadline <= 0 {
+		return nil, ErrInvalidTTL
+	}
+	feeOnTransfer := options.FeeOnTransfer || trade.Route.HasTransferTax()
+
+	amountIn, err := trade.MaximumAmountIn(options.AllowedSlippage)
+	if err != nil {
+		return nil, err
+	}
+	amountOut, err := trade.MinimumAmountOut(options.AllowedSlippage)
+	if err != nil {
+		return nil, err
+	}
+
+	path := make([]common.Address, len(trade.Route.Path))
+	for i, token := range trade.Route.Path {
+		path[i] = token.Address
+	}
+	deadline := options.Deadline
+	if deadline <= 0 {
+		deadline = time.Now().Unix() + options.TTL
+	}
+	deadlineBI := big.NewInt(deadline)
+	to := options.Recipient
+
+	var (
+		methodName string
+		args       []interface{}
+		value      = big.NewInt(0)
+	)
+	switch trade.TradeType {
+	case constants.ExactInput:
+		if etherIn {
+			methodName = "swapExactETHForTokens"
+			if feeOnTransfer {
+				methodName = "swapExactETHForTokensSupportingFeeOnTransferTokens"
+			}
+			// (uint amountOutMin, address[] calldata path, address to, uint deadline)
+			args = []interface{}{amountOut.Raw(), path, to, deadlineBI}
+			value = amountIn.Raw()
+		} else if etherOut {
+			methodName = "swapExactTokensForETH"
+			if feeOnTransfer {
+				methodName = "swapExactTokensForETHSupportingFeeOnTransferTokens"
+			}
+			// (uint amountIn, uint amountOutMin, address[] calldata path, address to, uint deadline)
+			args = []interface{}{amountIn.Raw(), amountOut.Raw(), path, to, deadlineBI}
+		} else {
+			methodName = "swapExactTokensForTokens"
+			if feeOnTransfer {
+				methodName = "swapExactTokensForTokensSupportingFeeOnTransferTokens"
+			}
+			// (uint amountIn, uint amountOutMin, address[] calldata path, address to, uint deadline)
+			args = []interface{}{amountIn.Raw(), amountOut.Raw(), path, to, deadlineBI}
+		}
+	case constants.ExactOutput:
+		if feeOnTransfer {
+			return nil, ErrExactOutFeeOnTransfer
+		}
+		if etherIn {
+			methodName = "swapETHForExactTokens"
+			// (uint amountOut, address[] calldata path, address to, uint deadline)
+			args = []interface{}{amountOut.Raw(), path, to, deadlineBI}
+			value = amountIn.Raw()
+		} else if etherOut {
+			methodName = "swapTokensForExactETH"
+			// (uint amountOut, uint amountInMax, address[] calldata path, address to, uint deadline)
+			args = []interface{}{amountOut.Raw(), amountIn.Raw(), path, to, deadlineBI}
+		} else {
+			methodName = "swapTokensForExactTokens"
+			// (uint amountOut, uint amountInMax, address[] calldata path, address to, uint deadline)
+			args = []interface{}{amountOut.Raw(), amountIn.Raw(), path, to, deadlineBI}
+		}
+	default:
+		return nil, fmt.Errorf("unknown trade type %d", trade.TradeType)
+	}
+
+	calldata, err := Router02ABI.Pack(methodName, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &SwapParameters{
+		MethodName: methodName,
+		Args:       args,
+		Value:      value,
+		Calldata:   calldata,
+	}, nil
+}
+
+// isEther whether the token stands for the chain's native currency rather than an ERC20
+func isEther(token *entities.Token) bool {
+	return token.IsNative()
+}
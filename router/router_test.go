@@ -0,0 +1,198 @@
+package router
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+	"github.com/xiang-xx/uniswap-sdk-go/entities"
+)
+
+// nolint funlen
+func TestSwapCallParameters(t *testing.T) {
+	token0, _ := entities.NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "t0", "")
+	token1, _ := entities.NewToken(constants.Mainnet, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "t1", "")
+	weth := entities.WETH[constants.Mainnet]
+	ether := entities.NewETHRToken(constants.Mainnet, weth.Address)
+
+	tokenAmount_0_1000, _ := entities.NewTokenAmount(token0, big.NewInt(1000))
+	tokenAmount_1_1000, _ := entities.NewTokenAmount(token1, big.NewInt(1000))
+	tokenAmount_weth_1000, _ := entities.NewTokenAmount(weth, big.NewInt(1000))
+	pair_0_1, _ := entities.NewPair(tokenAmount_0_1000, tokenAmount_1_1000)
+	pair_weth_0, _ := entities.NewPair(tokenAmount_weth_1000, tokenAmount_0_1000)
+	taxed0, _ := token0.WithTax(0, 1000, 0)
+	tokenAmount_taxed0_1000, _ := entities.NewTokenAmount(taxed0, big.NewInt(1000))
+	pair_taxed0_1, _ := entities.NewPair(tokenAmount_taxed0_1000, tokenAmount_1_1000)
+
+	recipient := common.HexToAddress("0x0000000000000000000000000000000000000004")
+	options := &TradeOptions{
+		AllowedSlippage: entities.NewPercent(big.NewInt(1), big.NewInt(100)),
+		Recipient:       recipient,
+		Deadline:        50,
+	}
+	deadline := big.NewInt(50)
+
+	amount := func(token *entities.Token, raw int64) *entities.TokenAmount {
+		tokenAmount, err := entities.NewTokenAmount(token, big.NewInt(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenAmount
+	}
+	newTrade := func(pairs []entities.Pair, input, output *entities.Token, tokenAmount *entities.TokenAmount, tradeType constants.TradeType) *entities.Trade {
+		route, err := entities.NewRoute(pairs, input, output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trade, err := entities.NewTrade(route, tokenAmount, tradeType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return trade
+	}
+
+	tests := []struct {
+		name       string
+		trade      *entities.Trade
+		options    *TradeOptions
+		methodName string
+		args       []interface{}
+		value      *big.Int
+	}{
+		{
+			name:       "exact in token to token",
+			trade:      newTrade([]entities.Pair{pair_0_1}, token0, token1, amount(token0, 100), constants.ExactInput),
+			options:    options,
+			methodName: "swapExactTokensForTokens",
+			args:       []interface{}{big.NewInt(100), big.NewInt(89), []common.Address{token0.Address, token1.Address}, recipient, deadline},
+			value:      big.NewInt(0),
+		},
+		{
+			name:       "exact in ether to token",
+			trade:      newTrade([]entities.Pair{pair_weth_0, pair_0_1}, ether, token1, amount(ether, 100), constants.ExactInput),
+			options:    options,
+			methodName: "swapExactETHForTokens",
+			args:       []interface{}{big.NewInt(81), []common.Address{weth.Address, token0.Address, token1.Address}, recipient, deadline},
+			value:      big.NewInt(100),
+		},
+		{
+			name:       "exact in token to ether",
+			trade:      newTrade([]entities.Pair{pair_0_1, pair_weth_0}, token1, ether, amount(token1, 100), constants.ExactInput),
+			options:    options,
+			methodName: "swapExactTokensForETH",
+			args:       []interface{}{big.NewInt(100), big.NewInt(81), []common.Address{token1.Address, token0.Address, weth.Address}, recipient, deadline},
+			value:      big.NewInt(0),
+		},
+		{
+			name:  "exact in fee on transfer",
+			trade: newTrade([]entities.Pair{pair_0_1}, token0, token1, amount(token0, 100), constants.ExactInput),
+			options: &TradeOptions{
+				AllowedSlippage: options.AllowedSlippage,
+				Recipient:       recipient,
+				Deadline:        50,
+				FeeOnTransfer:   true,
+			},
+			methodName: "swapExactTokensForTokensSupportingFeeOnTransferTokens",
+			args:       []interface{}{big.NewInt(100), big.NewInt(89), []common.Address{token0.Address, token1.Address}, recipient, deadline},
+			value:      big.NewInt(0),
+		},
+		{
+			name:       "exact in transfer tax",
+			trade:      newTrade([]entities.Pair{pair_taxed0_1}, token0, token1, amount(token0, 100), constants.ExactInput),
+			options:    options,
+			methodName: "swapExactTokensForTokensSupportingFeeOnTransferTokens",
+			args:       []interface{}{big.NewInt(100), big.NewInt(81), []common.Address{token0.Address, token1.Address}, recipient, deadline},
+			value:      big.NewInt(0),
+		},
+		{
+			name:       "exact out token to token",
+			trade:      newTrade([]entities.Pair{pair_0_1}, token0, token1, amount(token1, 100), constants.ExactOutput),
+			options:    options,
+			methodName: "swapTokensForExactTokens",
+			args:       []interface{}{big.NewInt(100), big.NewInt(113), []common.Address{token0.Address, token1.Address}, recipient, deadline},
+			value:      big.NewInt(0),
+		},
+		{
+			name:       "exact out ether to token",
+			trade:      newTrade([]entities.Pair{pair_weth_0}, ether, token0, amount(token0, 100), constants.ExactOutput),
+			options:    options,
+			methodName: "swapETHForExactTokens",
+			args:       []interface{}{big.NewInt(100), []common.Address{weth.Address, token0.Address}, recipient, deadline},
+			value:      big.NewInt(113),
+		},
+		{
+			name:       "exact out token to ether",
+			trade:      newTrade([]entities.Pair{pair_weth_0}, token0, ether, amount(ether, 100), constants.ExactOutput),
+			options:    options,
+			methodName: "swapTokensForExactETH",
+			args:       []interface{}{big.NewInt(100), big.NewInt(113), []common.Address{token0.Address, weth.Address}, recipient, deadline},
+			value:      big.NewInt(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SwapCallParameters(tt.trade, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.MethodName != tt.methodName {
+				t.Errorf("expect[%+v], but got[%+v]", tt.methodName, got.MethodName)
+			}
+			if !reflect.DeepEqual(got.Args, tt.args) {
+				t.Errorf("expect[%+v], but got[%+v]", tt.args, got.Args)
+			}
+			if got.Value.Cmp(tt.value) != 0 {
+				t.Errorf("expect[%+v], but got[%+v]", tt.value, got.Value)
+			}
+
+			method := Router02ABI.Methods[tt.methodName]
+			if !reflect.DeepEqual(got.Calldata[:4], method.ID) {
+				t.Errorf("expect[%x], but got[%x]", method.ID, got.Calldata[:4])
+			}
+			unpacked, err := method.Inputs.Unpack(got.Calldata[4:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(unpacked, tt.args) {
+				t.Errorf("expect[%+v], but got[%+v]", tt.args, unpacked)
+			}
+		})
+	}
+
+	// fails for exact out fee on transfer
+	{
+		trade := newTrade([]entities.Pair{pair_0_1}, token0, token1, amount(token1, 100), constants.ExactOutput)
+		_, err := SwapCallParameters(trade, &TradeOptions{
+			AllowedSlippage: options.AllowedSlippage,
+			Recipient:       recipient,
+			TTL:             50,
+			FeeOnTransfer:   true,
+		})
+		if err != ErrExactOutFeeOnTransfer {
+			t.Errorf("expect[%+v], but got[%+v]", ErrExactOutFeeOnTransfer, err)
+		}
+	}
+
+	// fails without ttl or deadline
+	{
+		trade := newTrade([]entities.Pair{pair_0_1}, token0, token1, amount(token0, 100), constants.ExactInput)
+		_, err := SwapCallParameters(trade, &TradeOptions{
+			AllowedSlippage: options.AllowedSlippage,
+			Recipient:       recipient,
+		})
+		if err != ErrInvalidTTL {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidTTL, err)
+		}
+	}
+
+	// fails without options
+	{
+		trade := newTrade([]entities.Pair{pair_0_1}, token0, token1, amount(token0, 100), constants.ExactInput)
+		if _, err := SwapCallParameters(trade, nil); err != ErrInvalidOptions {
+			t.Errorf("expect[%+v], but got[%+v]", ErrInvalidOptions, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+var (
+	// ErrZeroDenominator the derivative of the curve is zero, the pair reverts
+	ErrZeroDenominator = errors.New("zero denominator")
+	// ErrNotConverged newton's method did not converge in 255 iterations, the pair reverts
+	ErrNotConverged = errors.New("not converged")
+)
+
+// SolidlyK returns the invariant x^3*y + y^3*x of the balances normalized to 18 decimals, as _k of Solidly stable
+// pairs. the rounding differs from SolidlyF, both are needed to match the contracts.
+func SolidlyK(x, y *big.Int) *big.Int {
+	a := mulDiv(x, y, constants.B1e18)
+	b := add(mulDiv(x, x, constants.B1e18), mulDiv(y, y, constants.B1e18))
+	return mulDiv(a, b, constants.B1e18)
+}
+
+// SolidlyF returns x0*y^3 + x0^3*y, as _f of Solidly stable pairs
+func SolidlyF(x0, y *big.Int) *big.Int {
+	y3 := mulDiv(mulDiv(y, y, constants.B1e18), y, constants.B1e18)
+	x03 := mulDiv(mulDiv(x0, x0, constants.B1e18), x0, constants.B1e18)
+	return add(mulDiv(x0, y3, constants.B1e18), mulDiv(x03, y, constants.B1e18))
+}
+
+// solidlyD returns the derivative 3*x0*y^2 + x0^3 of SolidlyF by y, as _d of Solidly stable pairs
+func solidlyD(x0, y *big.Int) *big.Int {
+	y2 := mulDiv(y, y, constants.B1e18)
+	x03 := mulDiv(mulDiv(x0, x0, constants.B1e18), x0, constants.B1e18)
+	return add(mulDiv(mul(constants.Three, x0), y2, constants.B1e18), x03)
+}
+
+/**
+ * SolidlyGetY returns the balance y keeping the invariant xy at balance x0, newton's method starting from y,
+ * as _get_y of Velodrome V2 pools
+ * @param decimals0 10^decimals of token0 of the pair
+ * @param decimals1 10^decimals of token1 of the pair, _get_y checks _k(x0, y + 1) of the pair, which normalizes
+ * the already normalized x0 and y + 1 again by the decimals of token0 and token1
+ */
+func SolidlyGetY(x0, xy, y, decimals0, decimals1 *big.Int) (*big.Int, error) {
+	y = new(big.Int).Set(y)
+	for i := 0; i < 255; i++ {
+		k := SolidlyF(x0, y)
+		d := solidlyD(x0, y)
+		if d.Sign() == 0 {
+			return nil, ErrZeroDenominator
+		}
+		if k.Cmp(xy) < 0 {
+			dy := mulDiv(new(big.Int).Sub(xy, k), constants.B1e18, d)
+			if dy.Sign() == 0 {
+				// either y converged, or the rounding of (xy - k) / d hides the last unit
+				_x := mulDiv(x0, constants.B1e18, decimals0)
+				_y := mulDiv(add(y, constants.One), constants.B1e18, decimals1)
+				if SolidlyK(_x, _y).Cmp(xy) > 0 {
+					return add(y, constants.One), nil
+				}
+				dy = constants.One
+			}
+			y = add(y, dy)
+		} else {
+			dy := mulDiv(new(big.Int).Sub(k, xy), constants.B1e18, d)
+			if dy.Sign() == 0 {
+				if k.Cmp(xy) == 0 || SolidlyF(x0, new(big.Int).Sub(y, constants.One)).Cmp(xy) < 0 {
+					return y, nil
+				}
+				dy = constants.One
+			}
+			y = new(big.Int).Sub(y, dy)
+		}
+	}
+	return nil, ErrNotConverged
+}
+
+// SolidlySpotPrice returns the marginal price -dy/dx of the curve x^3*y + y^3*x = k at the normalized balances
+// (x, y), as numerator / denominator: (3*x^2*y + y^3) / (x^3 + 3*x*y^2)
+func SolidlySpotPrice(x, y *big.Int) (numerator, denominator *big.Int) {
+	x2, y2 := mul(x, x), mul(y, y)
+	numerator = add(mul(mul(constants.Three, x2), y), mul(y2, y))
+	denominator = add(mul(x2, x), mul(mul(constants.Three, x), y2))
+	return numerator, denominator
+}
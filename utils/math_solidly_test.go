@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+func TestSolidlyMath(t *testing.T) {
+	x, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+	y, _ := new(big.Int).SetString("3000000000000000000000000", 10)
+	xy := SolidlyK(x, y)
+	decimals := constants.B1e18
+
+	// y is recovered from x, starting from anywhere near
+	for _, start := range []*big.Int{y, new(big.Int).Div(y, big.NewInt(2)), new(big.Int).Mul(y, big.NewInt(2))} {
+		if got, err := SolidlyGetY(x, xy, start, decimals, decimals); err != nil || new(big.Int).Sub(got, y).CmpAbs(big.NewInt(1e6)) > 0 {
+			t.Errorf("expect[%+v], but got[%+v %+v]", y, got, err)
+		}
+	}
+	// the curve is symmetric
+	if got, err := SolidlyGetY(y, xy, x, decimals, decimals); err != nil || new(big.Int).Sub(got, x).CmpAbs(big.NewInt(1e6)) > 0 {
+		t.Errorf("expect[%+v], but got[%+v %+v]", x, got, err)
+	}
+
+	// the pair reverts instead of quoting y when _k(x0, y + 1) of the last unit does not cross xy, e.g. less than
+	// a coin in the pair, when the derivative is zero, and when y does not converge
+	{
+		x, _ := new(big.Int).SetString("430331862602798275", 10)
+		y, _ := new(big.Int).SetString("215557651881292984", 10)
+		x0 := new(big.Int).Add(x, big.NewInt(587298511233148))
+		if got, err := SolidlyGetY(x0, SolidlyK(x, y), y, decimals, decimals); err != ErrNotConverged {
+			t.Errorf("expect[%+v], but got[%+v %+v]", ErrNotConverged, got, err)
+		}
+	}
+	if got, err := SolidlyGetY(big.NewInt(0), big.NewInt(1), big.NewInt(0), decimals, decimals); err != ErrZeroDenominator {
+		t.Errorf("expect[%+v], but got[%+v %+v]", ErrZeroDenominator, got, err)
+	}
+	{
+		start := new(big.Int).Exp(big.NewInt(10), big.NewInt(77), nil)
+		if got, err := SolidlyGetY(decimals, SolidlyK(decimals, decimals), start, decimals, decimals); err != ErrNotConverged {
+			t.Errorf("expect[%+v], but got[%+v %+v]", ErrNotConverged, got, err)
+		}
+	}
+
+	// the scarce coin is the expensive one, and the balanced curve prices 1:1
+	numerator, denominator := SolidlySpotPrice(x, y)
+	if numerator.Cmp(denominator) <= 0 {
+		t.Errorf("expect price more than 1, but got[%+v/%+v]", numerator, denominator)
+	}
+	numerator, denominator = SolidlySpotPrice(x, x)
+	if numerator.Cmp(denominator) != 0 {
+		t.Errorf("expect price 1, but got[%+v/%+v]", numerator, denominator)
+	}
+}
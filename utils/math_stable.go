@@ -6,14 +6,22 @@ import (
 	"github.com/xiang-xx/uniswap-sdk-go/constants"
 )
 
+// GetY returns the balance y of the other coin given the balance x and invariant d, with the default amplification
 func GetY(x, d *big.Int) *big.Int {
+	return GetYWithAmp(x, d, constants.DefaultAmplification)
+}
+
+// GetYWithAmp returns the balance y of the other coin given the balance x and invariant d, with amplification amp.
+// Ann = amp * 2 as in Curve and Saddle, amp 1000 gives the constants of GetY
+func GetYWithAmp(x, d, amp *big.Int) *big.Int {
+	ann := mul(amp, constants.Two)
 	// c = (d * d) / (x * 2);
 	c := mulDiv(d, d, mul(constants.Two, x))
-	//c = (c * d) / 4000;
-	c = mulDiv(c, d, constants.B4000)
+	//c = (c * d) / (ann * 2);
+	c = mulDiv(c, d, mul(ann, constants.Two))
 
-	// b = x + (d / 2000)
-	b := new(big.Int).Add(x, new(big.Int).Div(d, constants.B2000))
+	// b = x + (d / ann)
+	b := new(big.Int).Add(x, new(big.Int).Div(d, ann))
 	yPrev := new(big.Int)
 	y := d
 
@@ -47,6 +55,13 @@ func GetY(x, d *big.Int) *big.Int {
 // Overflow checks should be applied before calling this function.
 // The maximum XPs are `3802571709128108338056982581425910818` of uint128.
 func ComputeDFromAdjustedBalances(xp0, xp1 *big.Int) *big.Int {
+	return ComputeDFromAdjustedBalancesWithAmp(xp0, xp1, constants.DefaultAmplification)
+}
+
+// ComputeDFromAdjustedBalancesWithAmp returns the invariant D of the adjusted balances with amplification amp
+func ComputeDFromAdjustedBalancesWithAmp(xp0, xp1, amp *big.Int) *big.Int {
+	ann := mul(amp, constants.Two)
+	annMinusOne := new(big.Int).Sub(ann, constants.One)
 	s := add(xp0, xp1)
 
 	computed := new(big.Int)
@@ -61,12 +76,12 @@ func ComputeDFromAdjustedBalances(xp0, xp1 *big.Int) *big.Int {
 			dP := div(mulDiv(mulDiv(d, d, xp0), d, xp1), constants.Four)
 
 			prevD = d
-			//d = (((2000 * s) + 2 * dP) * d) / ((2000 - 1) * d + 3 * dP);
+			//d = (((ann * s) + 2 * dP) * d) / ((ann - 1) * d + 3 * dP);
 			d = mulDiv(
 				// `s` cannot be zero and this value will never be zero.
-				add(mul(constants.B2000, s), mul(constants.Two, dP)),
+				add(mul(ann, s), mul(constants.Two, dP)),
 				d,
-				add(mul(constants.B1999, d), mul(constants.Three, dP)),
+				add(mul(annMinusOne, d), mul(constants.Three, dP)),
 			)
 
 			if within1(d, prevD) {
@@ -80,3 +95,20 @@ func ComputeDFromAdjustedBalances(xp0, xp1 *big.Int) *big.Int {
 	}
 	return computed
 }
+
+// GetSpotPriceFromAdjustedBalances returns the marginal price -dy/dx of the curve at the adjusted balances (x, y)
+// with invariant d, as numerator / denominator, with the default amplification
+func GetSpotPriceFromAdjustedBalances(x, y, d *big.Int) (numerator, denominator *big.Int) {
+	return GetSpotPriceFromAdjustedBalancesWithAmp(x, y, d, constants.DefaultAmplification)
+}
+
+// GetSpotPriceFromAdjustedBalancesWithAmp returns the marginal price -dy/dx of the curve at the adjusted balances
+// (x, y) with invariant d and amplification amp, as numerator / denominator.
+// From Ann(x+y) + d = Ann*d + d^3/(4xy), -dy/dx = (4*Ann*x^2*y^2 + d^3*y) / (4*Ann*x^2*y^2 + d^3*x)
+func GetSpotPriceFromAdjustedBalancesWithAmp(x, y, d, amp *big.Int) (numerator, denominator *big.Int) {
+	ann := mul(amp, constants.Two)
+	xy := mul(x, y)
+	common := mul(mul(ann, constants.Four), mul(xy, xy))
+	d3 := mul(mul(d, d), d)
+	return add(common, mul(d3, y)), add(common, mul(d3, x))
+}
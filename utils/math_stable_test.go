@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+func TestStableMathWithAmp(t *testing.T) {
+	x, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+	y, _ := new(big.Int).SetString("1500000000000000000000000", 10)
+
+	// the default amplification keeps the constants of the original curve
+	if ComputeDFromAdjustedBalances(x, y).Cmp(ComputeDFromAdjustedBalancesWithAmp(x, y, constants.DefaultAmplification)) != 0 {
+		t.Error("default amplification should give the same D")
+	}
+
+	var prevD *big.Int
+	for _, amp := range []int64{1, 10, 100, 1000, 5000} {
+		d := ComputeDFromAdjustedBalancesWithAmp(x, y, big.NewInt(amp))
+		// D approaches x + y as the curve flattens
+		if prevD != nil && d.Cmp(prevD) <= 0 {
+			t.Errorf("expect D greater than [%+v], but got[%+v]", prevD, d)
+		}
+		prevD = d
+
+		// y is recovered from x and D
+		got := GetYWithAmp(x, d, big.NewInt(amp))
+		if new(big.Int).Sub(got, y).CmpAbs(big.NewInt(1e6)) > 0 {
+			t.Errorf("amp %d: expect[%+v], but got[%+v]", amp, y, got)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"math/big"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+// ComputeDN returns the invariant D of the balances xp of an n coin StableSwap pool with amplification amp,
+// as get_D of Curve pools, Ann = amp * n.
+// Ann * sum(x_i) + D = Ann * D + D^(n+1) / (n^n * prod(x_i))
+func ComputeDN(xp []*big.Int, amp *big.Int) *big.Int {
+	n := big.NewInt(int64(len(xp)))
+	s := new(big.Int)
+	for _, x := range xp {
+		s.Add(s, x)
+	}
+	if IsZero(s) {
+		return new(big.Int)
+	}
+
+	ann := mul(amp, n)
+	annMinusOne := new(big.Int).Sub(ann, constants.One)
+	nPlusOne := add(n, constants.One)
+	d := new(big.Int).Set(s)
+	for i := 0; i < 256; i++ {
+		// dP = D^(n+1) / (n^n * prod(x_i))
+		dP := new(big.Int).Set(d)
+		for _, x := range xp {
+			dP = mulDiv(dP, d, mul(x, n))
+		}
+		prevD := d
+		// d = (Ann * S + dP * n) * d / ((Ann - 1) * d + (n + 1) * dP)
+		d = mulDiv(
+			add(mul(ann, s), mul(dP, n)),
+			d,
+			add(mul(annMinusOne, d), mul(nPlusOne, dP)),
+		)
+		if within1(d, prevD) {
+			break
+		}
+	}
+	return d
+}
+
+// GetYN returns the balance of coin j after the balance of coin i changes to x, keeping the invariant of the
+// balances xp, as get_y of Curve pools
+func GetYN(i, j int, x *big.Int, xp []*big.Int, amp *big.Int) *big.Int {
+	n := big.NewInt(int64(len(xp)))
+	d := ComputeDN(xp, amp)
+	ann := mul(amp, n)
+
+	c := new(big.Int).Set(d)
+	s := new(big.Int)
+	for k := range xp {
+		var _x *big.Int
+		switch k {
+		case i:
+			_x = x
+		case j:
+			continue
+		default:
+			_x = xp[k]
+		}
+		s.Add(s, _x)
+		c = mulDiv(c, d, mul(_x, n))
+	}
+	c = mulDiv(c, d, mul(ann, n))
+	b := add(s, div(d, ann))
+
+	y := d
+	for k := 0; k < 256; k++ {
+		prevY := y
+		// y = (y * y + c) / (2 * y + b - d)
+		y = div(
+			add(mul(y, y), c),
+			new(big.Int).Sub(add(mul(constants.Two, y), b), d),
+		)
+		if within1(y, prevY) {
+			break
+		}
+	}
+	return y
+}
+
+// GetSpotPriceN returns the marginal price -dx_j/dx_i of an n coin StableSwap pool at the balances xp with invariant
+// d, as numerator / denominator.
+// With K = D^(n+1) / (n^n * prod(x)), -dx_j/dx_i = (Ann * x_i * x_j + K * x_j) / (Ann * x_i * x_j + K * x_i),
+// both sides are multiplied by n^n * prod(x) to stay in integers
+func GetSpotPriceN(i, j int, xp []*big.Int, d, amp *big.Int) (numerator, denominator *big.Int) {
+	n := big.NewInt(int64(len(xp)))
+	ann := mul(amp, n)
+
+	// n^n * prod(x)
+	prod := new(big.Int).Exp(n, n, nil)
+	for _, x := range xp {
+		prod.Mul(prod, x)
+	}
+	dN := new(big.Int).Exp(d, add(n, constants.One), nil)
+	common := mul(mul(ann, prod), mul(xp[i], xp[j]))
+	return add(common, mul(dN, xp[j])), add(common, mul(dN, xp[i]))
+}
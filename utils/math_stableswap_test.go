@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestStableSwapMath(t *testing.T) {
+	x, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+	y, _ := new(big.Int).SetString("1500000000000000000000000", 10)
+	z, _ := new(big.Int).SetString("800000000000000000000000", 10)
+	amp := big.NewInt(100)
+
+	// two coins are the curve of the stable pairs
+	expect := ComputeDFromAdjustedBalancesWithAmp(x, y, amp)
+	if got := ComputeDN([]*big.Int{x, y}, amp); got.Cmp(expect) != 0 {
+		t.Errorf("expect[%+v], but got[%+v]", expect, got)
+	}
+	if got := GetYN(0, 1, x, []*big.Int{x, y}, amp); new(big.Int).Sub(got, GetYWithAmp(x, expect, amp)).CmpAbs(big.NewInt(1)) > 0 {
+		t.Errorf("expect[%+v], but got[%+v]", GetYWithAmp(x, expect, amp), got)
+	}
+
+	xp := []*big.Int{x, y, z}
+	d := ComputeDN(xp, amp)
+	sum := new(big.Int).Add(new(big.Int).Add(x, y), z)
+	if d.Cmp(sum) >= 0 {
+		t.Errorf("expect D less than [%+v], but got[%+v]", sum, d)
+	}
+	// the balance of a coin is recovered from the others
+	for i := range xp {
+		j := (i + 1) % len(xp)
+		if got := GetYN(i, j, xp[i], xp, amp); new(big.Int).Sub(got, xp[j]).CmpAbs(big.NewInt(1e6)) > 0 {
+			t.Errorf("%d -> %d: expect[%+v], but got[%+v]", i, j, xp[j], got)
+		}
+	}
+
+	// the most abundant coin is the cheapest
+	numerator, denominator := GetSpotPriceN(1, 2, xp, d, amp)
+	if numerator.Cmp(denominator) >= 0 {
+		t.Errorf("expect price less than 1, but got[%+v/%+v]", numerator, denominator)
+	}
+	// and prices are reciprocal
+	inverseNumerator, inverseDenominator := GetSpotPriceN(2, 1, xp, d, amp)
+	if inverseNumerator.Cmp(denominator) != 0 || inverseDenominator.Cmp(numerator) != 0 {
+		t.Error("prices should be inverse")
+	}
+}
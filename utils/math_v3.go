@@ -0,0 +1,293 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+var (
+	// ErrInvalidTick tick out of [MinTick, MaxTick]
+	ErrInvalidTick = errors.New("invalid tick")
+	// ErrInvalidSqrtRatio sqrt ratio out of [MinSqrtRatio, MaxSqrtRatio)
+	ErrInvalidSqrtRatio = errors.New("invalid sqrt ratio")
+	// ErrInvalidLiquidity liquidity underflow or overflow
+	ErrInvalidLiquidity = errors.New("invalid liquidity")
+	// ErrInvalidSqrtPrice next sqrt price can not be reached
+	ErrInvalidSqrtPrice = errors.New("invalid sqrt price")
+
+	maxUint160 = new(big.Int).Sub(new(big.Int).Lsh(constants.One, 160), constants.One)
+	maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(constants.One, 256), constants.One)
+	q256       = new(big.Int).Lsh(constants.One, 256)
+
+	// tick math ratios, 2^128 / sqrt(1.0001)^(2^i)
+	tickRatios = []*big.Int{
+		hexToBig("fff97272373d413259a46990580e213a"),
+		hexToBig("fff2e50f5f656932ef12357cf3c7fdcc"),
+		hexToBig("ffe5caca7e10e4e61c3624eaa0941cd0"),
+		hexToBig("ffcb9843d60f6159c9db58835c926644"),
+		hexToBig("ff973b41fa98c081472e6896dfb254c0"),
+		hexToBig("ff2ea16466c96a3843ec78b326b52861"),
+		hexToBig("fe5dee046a99a2a811c461f1969c3053"),
+		hexToBig("fcbe86c7900a88aedcffc83b479aa3a4"),
+		hexToBig("f987a7253ac413176f2b074cf7815e54"),
+		hexToBig("f3392b0822b70005940c7a398e4b70f3"),
+		hexToBig("e7159475a2c29b7443b29c7fa6e889d9"),
+		hexToBig("d097f3bdfd2022b8845ad8f792aa5825"),
+		hexToBig("a9f746462d870fdf8a65dc1f90e061e5"),
+		hexToBig("70d869a156d2a1b890bb3df62baf32f7"),
+		hexToBig("31be135f97d08fd981231505542fcfa6"),
+		hexToBig("9aa508b5b7a84e1c677de54f3e99bc9"),
+		hexToBig("5d6af8dedb81196699c329225ee604"),
+		hexToBig("2216e584f5fa1ea926041bedfe98"),
+		hexToBig("48a170391f7dc42444e8fa2"),
+	}
+	tickRatioOdd = hexToBig("fffcb933bd6fad37aa2d162d1a594001")
+)
+
+func hexToBig(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex " + s)
+	}
+	return v
+}
+
+// MulDivRoundingUp returns ceil(a * b / denominator)
+func MulDivRoundingUp(a, b, denominator *big.Int) *big.Int {
+	product := mul(a, b)
+	result, rem := new(big.Int).QuoRem(product, denominator, new(big.Int))
+	if rem.Sign() != 0 {
+		result.Add(result, constants.One)
+	}
+	return result
+}
+
+// AddDelta adds a signed liquidity delta to liquidity
+func AddDelta(x, y *big.Int) (*big.Int, error) {
+	z := add(x, y)
+	if z.Sign() < 0 {
+		return nil, ErrInvalidLiquidity
+	}
+	return z, nil
+}
+
+// GetSqrtRatioAtTick returns the sqrt ratio as a Q64.96 for the given tick. The sqrt ratio is computed as
+// sqrt(1.0001)^tick
+func GetSqrtRatioAtTick(tick int) (*big.Int, error) {
+	if tick < constants.MinTick || tick > constants.MaxTick {
+		return nil, ErrInvalidTick
+	}
+	absTick := tick
+	if tick < 0 {
+		absTick = -tick
+	}
+
+	ratio := new(big.Int).Set(constants.Q128)
+	if absTick&0x1 != 0 {
+		ratio.Set(tickRatioOdd)
+	}
+	for i, tickRatio := range tickRatios {
+		if absTick&(0x2<<i) != 0 {
+			ratio = mul(ratio, tickRatio)
+			ratio.Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		ratio = div(maxUint256, ratio)
+	}
+
+	// back to Q96
+	result, rem := new(big.Int).QuoRem(ratio, constants.Q32, new(big.Int))
+	if rem.Sign() != 0 {
+		result.Add(result, constants.One)
+	}
+	return result, nil
+}
+
+// GetTickAtSqrtRatio returns the greatest tick such that GetSqrtRatioAtTick(tick) <= sqrtRatioX96
+func GetTickAtSqrtRatio(sqrtRatioX96 *big.Int) (int, error) {
+	if sqrtRatioX96.Cmp(constants.MinSqrtRatio) < 0 || sqrtRatioX96.Cmp(constants.MaxSqrtRatio) >= 0 {
+		return 0, ErrInvalidSqrtRatio
+	}
+
+	lo, hi := constants.MinTick, constants.MaxTick
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		ratio, err := GetSqrtRatioAtTick(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ratio.Cmp(sqrtRatioX96) <= 0 {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo, nil
+}
+
+// GetAmount0Delta returns the amount0 delta between two prices, i.e. liquidity / sqrt(lower) - liquidity / sqrt(upper)
+func GetAmount0Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) *big.Int {
+	if sqrtRatioAX96.Cmp(sqrtRatioBX96) > 0 {
+		sqrtRatioAX96, sqrtRatioBX96 = sqrtRatioBX96, sqrtRatioAX96
+	}
+
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	numerator2 := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+	if roundUp {
+		return MulDivRoundingUp(MulDivRoundingUp(numerator1, numerator2, sqrtRatioBX96), constants.One, sqrtRatioAX96)
+	}
+	return div(mulDiv(numerator1, numerator2, sqrtRatioBX96), sqrtRatioAX96)
+}
+
+// GetAmount1Delta returns the amount1 delta between two prices, i.e. liquidity * (sqrt(upper) - sqrt(lower))
+func GetAmount1Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) *big.Int {
+	if sqrtRatioAX96.Cmp(sqrtRatioBX96) > 0 {
+		sqrtRatioAX96, sqrtRatioBX96 = sqrtRatioBX96, sqrtRatioAX96
+	}
+
+	if roundUp {
+		return MulDivRoundingUp(liquidity, new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96), constants.Q96)
+	}
+	return mulDiv(liquidity, new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96), constants.Q96)
+}
+
+// GetNextSqrtPriceFromInput returns the next sqrt price given an input amount of token0 or token1
+func GetNextSqrtPriceFromInput(sqrtPX96, liquidity, amountIn *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, ErrInvalidSqrtPrice
+	}
+	if zeroForOne {
+		return getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountIn, true)
+	}
+	return getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountIn, true)
+}
+
+// GetNextSqrtPriceFromOutput returns the next sqrt price given an output amount of token0 or token1
+func GetNextSqrtPriceFromOutput(sqrtPX96, liquidity, amountOut *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, ErrInvalidSqrtPrice
+	}
+	if zeroForOne {
+		return getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountOut, false)
+	}
+	return getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountOut, false)
+}
+
+func getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if amount.Sign() == 0 {
+		return sqrtPX96, nil
+	}
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	product := mul(amount, sqrtPX96)
+
+	if add {
+		// solidity falls back to a less precise formula when the product overflows uint256
+		if product.Cmp(maxUint256) <= 0 {
+			denominator := new(big.Int).Add(numerator1, product)
+			if denominator.Cmp(maxUint256) <= 0 {
+				return MulDivRoundingUp(numerator1, sqrtPX96, denominator), nil
+			}
+		}
+		return MulDivRoundingUp(numerator1, constants.One, new(big.Int).Add(div(numerator1, sqrtPX96), amount)), nil
+	}
+
+	if product.Cmp(maxUint256) > 0 || numerator1.Cmp(product) <= 0 {
+		return nil, ErrInvalidSqrtPrice
+	}
+	denominator := new(big.Int).Sub(numerator1, product)
+	result := MulDivRoundingUp(numerator1, sqrtPX96, denominator)
+	if result.Cmp(maxUint160) > 0 {
+		return nil, ErrInvalidSqrtPrice
+	}
+	return result, nil
+}
+
+func getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if add {
+		quotient := div(new(big.Int).Lsh(amount, 96), liquidity)
+		result := new(big.Int).Add(sqrtPX96, quotient)
+		if result.Cmp(maxUint160) > 0 {
+			return nil, ErrInvalidSqrtPrice
+		}
+		return result, nil
+	}
+
+	quotient := MulDivRoundingUp(amount, constants.Q96, liquidity)
+	if sqrtPX96.Cmp(quotient) <= 0 {
+		return nil, ErrInvalidSqrtPrice
+	}
+	return new(big.Int).Sub(sqrtPX96, quotient), nil
+}
+
+// ComputeSwapStep computes the result of swapping some amount in, or amount out, given the parameters of the swap
+// amountRemaining is positive for exact input and negative for exact output, feePips is in hundredths of a bip
+func ComputeSwapStep(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, amountRemaining *big.Int, feePips uint32) (
+	sqrtRatioNextX96, amountIn, amountOut, feeAmount *big.Int, err error) {
+	fee := big.NewInt(int64(feePips))
+	feeComplement := new(big.Int).Sub(constants.B1e6, fee)
+	zeroForOne := sqrtRatioCurrentX96.Cmp(sqrtRatioTargetX96) >= 0
+	exactIn := amountRemaining.Sign() >= 0
+
+	if exactIn {
+		amountRemainingLessFee := mulDiv(amountRemaining, feeComplement, constants.B1e6)
+		if zeroForOne {
+			amountIn = GetAmount0Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, true)
+		} else {
+			amountIn = GetAmount1Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, true)
+		}
+		if amountRemainingLessFee.Cmp(amountIn) >= 0 {
+			sqrtRatioNextX96 = sqrtRatioTargetX96
+		} else {
+			sqrtRatioNextX96, err = GetNextSqrtPriceFromInput(sqrtRatioCurrentX96, liquidity, amountRemainingLessFee, zeroForOne)
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+		}
+	} else {
+		if zeroForOne {
+			amountOut = GetAmount1Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, false)
+		} else {
+			amountOut = GetAmount0Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, false)
+		}
+		if new(big.Int).Neg(amountRemaining).Cmp(amountOut) >= 0 {
+			sqrtRatioNextX96 = sqrtRatioTargetX96
+		} else {
+			sqrtRatioNextX96, err = GetNextSqrtPriceFromOutput(sqrtRatioCurrentX96, liquidity, new(big.Int).Neg(amountRemaining), zeroForOne)
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+		}
+	}
+
+	max := sqrtRatioTargetX96.Cmp(sqrtRatioNextX96) == 0
+	if zeroForOne {
+		if !(max && exactIn) {
+			amountIn = GetAmount0Delta(sqrtRatioNextX96, sqrtRatioCurrentX96, liquidity, true)
+		}
+		if !(max && !exactIn) {
+			amountOut = GetAmount1Delta(sqrtRatioNextX96, sqrtRatioCurrentX96, liquidity, false)
+		}
+	} else {
+		if !(max && exactIn) {
+			amountIn = GetAmount1Delta(sqrtRatioCurrentX96, sqrtRatioNextX96, liquidity, true)
+		}
+		if !(max && !exactIn) {
+			amountOut = GetAmount0Delta(sqrtRatioCurrentX96, sqrtRatioNextX96, liquidity, false)
+		}
+	}
+
+	if !exactIn && amountOut.Cmp(new(big.Int).Neg(amountRemaining)) > 0 {
+		amountOut = new(big.Int).Neg(amountRemaining)
+	}
+
+	if exactIn && sqrtRatioNextX96.Cmp(sqrtRatioTargetX96) != 0 {
+		// we didn't reach the target, so take the remainder of the maximum input as fee
+		feeAmount = new(big.Int).Sub(amountRemaining, amountIn)
+	} else {
+		feeAmount = MulDivRoundingUp(amountIn, fee, feeComplement)
+	}
+	return sqrtRatioNextX96, amountIn, amountOut, feeAmount, nil
+}
@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+func TestGetSqrtRatioAtTick(t *testing.T) {
+	tests := []struct {
+		tick   int
+		expect *big.Int
+	}{
+		{constants.MinTick, constants.MinSqrtRatio},
+		{constants.MaxTick, constants.MaxSqrtRatio},
+		{0, constants.Q96},
+	}
+	for i, test := range tests {
+		output, err := GetSqrtRatioAtTick(test.tick)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output.Cmp(test.expect) != 0 {
+			t.Errorf("test #%d: expect[%+v], but got[%+v]", i, test.expect, output)
+		}
+	}
+
+	if _, err := GetSqrtRatioAtTick(constants.MinTick - 1); err != ErrInvalidTick {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidTick, err)
+	}
+	if _, err := GetSqrtRatioAtTick(constants.MaxTick + 1); err != ErrInvalidTick {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidTick, err)
+	}
+}
+
+func TestGetTickAtSqrtRatio(t *testing.T) {
+	tests := []struct {
+		sqrtRatio *big.Int
+		expect    int
+	}{
+		{constants.MinSqrtRatio, constants.MinTick},
+		{new(big.Int).Sub(constants.MaxSqrtRatio, constants.One), constants.MaxTick - 1},
+		{constants.Q96, 0},
+		{new(big.Int).Sub(constants.Q96, constants.One), -1},
+	}
+	for i, test := range tests {
+		output, err := GetTickAtSqrtRatio(test.sqrtRatio)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output != test.expect {
+			t.Errorf("test #%d: expect[%+v], but got[%+v]", i, test.expect, output)
+		}
+	}
+
+	for _, tick := range []int{-887200, -50000, -10, 1, 60, 100000, 887000} {
+		ratio, err := GetSqrtRatioAtTick(tick)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := GetTickAtSqrtRatio(ratio)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output != tick {
+			t.Errorf("expect[%+v], but got[%+v]", tick, output)
+		}
+	}
+}
+
+// encodePriceSqrt returns the sqrt price of reserve1 / reserve0 as a Q64.96
+func encodePriceSqrt(reserve1, reserve0 int64) *big.Int {
+	ratioX192 := new(big.Int).Div(new(big.Int).Lsh(big.NewInt(reserve1), 192), big.NewInt(reserve0))
+	return new(big.Int).Sqrt(ratioX192)
+}
+
+func mustBigInt(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid int " + s)
+	}
+	return v
+}
+
+// the vectors of SqrtPriceMath.spec.ts of v3-core
+// nolint funlen
+func TestSqrtPriceMath(t *testing.T) {
+	oneEther := mustBigInt("1000000000000000000")
+	tenthEther := mustBigInt("100000000000000000")
+	tests := []struct {
+		name       string
+		input      bool
+		sqrtP      *big.Int
+		liquidity  *big.Int
+		amount     *big.Int
+		zeroForOne bool
+		expect     *big.Int
+	}{
+		{"input of zero token0", true, encodePriceSqrt(1, 1), tenthEther, constants.Zero, true, encodePriceSqrt(1, 1)},
+		{"input of zero token1", true, encodePriceSqrt(1, 1), tenthEther, constants.Zero, false, encodePriceSqrt(1, 1)},
+		{"input of 0.1 token1", true, encodePriceSqrt(1, 1), oneEther, tenthEther, false, mustBigInt("87150978765690771352898345369")},
+		{"input of 0.1 token0", true, encodePriceSqrt(1, 1), oneEther, tenthEther, true, mustBigInt("72025602285694852357767227579")},
+		{"input over uint96 of token0", true, encodePriceSqrt(1, 1), mustBigInt("10000000000000000000"),
+			new(big.Int).Lsh(constants.One, 100), true, mustBigInt("624999999995069620")},
+		{"input of max token0", true, encodePriceSqrt(1, 1), constants.One, new(big.Int).Rsh(maxUint256, 1), true, constants.One},
+		{"output of 0.1 token1", false, encodePriceSqrt(1, 1), oneEther, tenthEther, true, mustBigInt("71305346262837903834189555302")},
+		{"output of 0.1 token0", false, encodePriceSqrt(1, 1), oneEther, tenthEther, false, mustBigInt("88031291682515930659493278152")},
+	}
+	for _, test := range tests {
+		var (
+			output *big.Int
+			err    error
+		)
+		if test.input {
+			output, err = GetNextSqrtPriceFromInput(test.sqrtP, test.liquidity, test.amount, test.zeroForOne)
+		} else {
+			output, err = GetNextSqrtPriceFromOutput(test.sqrtP, test.liquidity, test.amount, test.zeroForOne)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if output.Cmp(test.expect) != 0 {
+			t.Errorf("%s: expect[%+v], but got[%+v]", test.name, test.expect, output)
+		}
+	}
+
+	// amounts for the price of 1 to 1.21
+	{
+		amount0 := GetAmount0Delta(encodePriceSqrt(1, 1), encodePriceSqrt(121, 100), oneEther, true)
+		if expect := mustBigInt("90909090909090910"); amount0.Cmp(expect) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect, amount0)
+		}
+		amount0 = GetAmount0Delta(encodePriceSqrt(1, 1), encodePriceSqrt(121, 100), oneEther, false)
+		if expect := mustBigInt("90909090909090909"); amount0.Cmp(expect) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect, amount0)
+		}
+		amount1 := GetAmount1Delta(encodePriceSqrt(1, 1), encodePriceSqrt(121, 100), oneEther, true)
+		if amount1.Cmp(tenthEther) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", tenthEther, amount1)
+		}
+		amount1 = GetAmount1Delta(encodePriceSqrt(1, 1), encodePriceSqrt(121, 100), oneEther, false)
+		if expect := new(big.Int).Sub(tenthEther, constants.One); amount1.Cmp(expect) != 0 {
+			t.Errorf("expect[%+v], but got[%+v]", expect, amount1)
+		}
+	}
+}
+
+// nolint funlen
+func TestComputeSwapStep(t *testing.T) {
+	liquidity, _ := new(big.Int).SetString("1000000000000000000", 10)
+	sqrtP := constants.Q96
+	target, _ := GetSqrtRatioAtTick(-100)
+
+	// exact input stays within the step and takes all remainder as amount in plus fee
+	{
+		amount := big.NewInt(1000000)
+		next, amountIn, amountOut, feeAmount, err := ComputeSwapStep(sqrtP, target, liquidity, amount, 3000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next.Cmp(target) <= 0 {
+			t.Errorf("should not reach target")
+		}
+		if new(big.Int).Add(amountIn, feeAmount).Cmp(amount) != 0 {
+			t.Errorf("expect amountIn + fee [%v], but got[%v]", amount, new(big.Int).Add(amountIn, feeAmount))
+		}
+		if amountOut.Cmp(amountIn) >= 0 {
+			t.Errorf("amountOut %v should be less than amountIn %v", amountOut, amountIn)
+		}
+	}
+
+	// exact output is capped at the remaining amount
+	{
+		amount := big.NewInt(-1000000)
+		next, amountIn, amountOut, _, err := ComputeSwapStep(sqrtP, target, liquidity, amount, 3000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next.Cmp(target) <= 0 {
+			t.Errorf("should not reach target")
+		}
+		if amountOut.Cmp(big.NewInt(1000000)) != 0 {
+			t.Errorf("expect[%v], but got[%v]", 1000000, amountOut)
+		}
+		if amountIn.Cmp(amountOut) <= 0 {
+			t.Errorf("amountIn %v should be greater than amountOut %v", amountIn, amountOut)
+		}
+	}
+
+	// the vectors of SwapMath.spec.ts of v3-core
+	oneEther := mustBigInt("1000000000000000000")
+	twoEther := mustBigInt("2000000000000000000")
+	tests := []struct {
+		name      string
+		sqrtP     *big.Int
+		target    *big.Int
+		liquidity *big.Int
+		amount    *big.Int
+		fee       uint32
+		next      *big.Int
+		amountIn  *big.Int
+		amountOut *big.Int
+		feeAmount *big.Int
+	}{
+		{"exact in capped at price target", encodePriceSqrt(1, 1), encodePriceSqrt(101, 100), twoEther, oneEther, 600,
+			encodePriceSqrt(101, 100), mustBigInt("9975124224178055"), mustBigInt("9925619580021728"), mustBigInt("5988667735148")},
+		{"exact out capped at price target", encodePriceSqrt(1, 1), encodePriceSqrt(101, 100), twoEther, new(big.Int).Neg(oneEther), 600,
+			encodePriceSqrt(101, 100), mustBigInt("9975124224178055"), mustBigInt("9925619580021728"), mustBigInt("5988667735148")},
+		{"exact in fully spent", encodePriceSqrt(1, 1), encodePriceSqrt(1000, 100), twoEther, oneEther, 600,
+			nil, mustBigInt("999400000000000000"), mustBigInt("666399946655997866"), mustBigInt("600000000000000")},
+		{"exact out fully received", encodePriceSqrt(1, 1), encodePriceSqrt(10000, 100), twoEther, new(big.Int).Neg(oneEther), 600,
+			nil, twoEther, oneEther, mustBigInt("1200720432259356")},
+		{"amount out capped at the desired amount", mustBigInt("417332158212080721273783715441582"), mustBigInt("1452870262520218020823638996"),
+			mustBigInt("159344665391607089467575320103"), big.NewInt(-1), 1,
+			mustBigInt("417332158212080721273783715441581"), constants.One, constants.One, constants.One},
+		{"target price of 1 uses partial input", big.NewInt(2), big.NewInt(1), constants.One, mustBigInt("3915081100057732413702495386755767"), 1,
+			constants.One, mustBigInt("39614081257132168796771975168"), constants.Zero, mustBigInt("39614120871253040049813")},
+		{"entire input taken as fee", big.NewInt(2413), mustBigInt("79887613182836312"), mustBigInt("1985041575832132834610021537970"),
+			big.NewInt(10), 1872, big.NewInt(2413), constants.Zero, constants.Zero, big.NewInt(10)},
+		{"insufficient liquidity zero for one exact out", mustBigInt("20282409603651670423947251286016"),
+			mustBigInt("22310650564016837466341976414617"), big.NewInt(1024), big.NewInt(-4), 3000,
+			mustBigInt("22310650564016837466341976414617"), big.NewInt(26215), constants.Zero, big.NewInt(79)},
+		{"insufficient liquidity one for zero exact out", mustBigInt("20282409603651670423947251286016"),
+			mustBigInt("18254168643286503381552526157414"), big.NewInt(1024), big.NewInt(-263000), 3000,
+			mustBigInt("18254168643286503381552526157414"), constants.One, big.NewInt(26214), constants.One},
+	}
+	for _, test := range tests {
+		next, amountIn, amountOut, feeAmount, err := ComputeSwapStep(test.sqrtP, test.target, test.liquidity, test.amount, test.fee)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if test.next != nil && next.Cmp(test.next) != 0 {
+			t.Errorf("%s: expect[%+v], but got[%+v]", test.name, test.next, next)
+		}
+		if amountIn.Cmp(test.amountIn) != 0 || amountOut.Cmp(test.amountOut) != 0 || feeAmount.Cmp(test.feeAmount) != 0 {
+			t.Errorf("%s: expect[%+v %+v %+v], but got[%+v %+v %+v]", test.name, test.amountIn, test.amountOut, test.feeAmount,
+				amountIn, amountOut, feeAmount)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/xiang-xx/uniswap-sdk-go/constants"
+)
+
+// Balancer weighted math, ported from FixedPoint, LogExpMath and WeightedMath of balancer-v2-monorepo.
+// Fixed point numbers have 18 decimals.
+
+var (
+	// ErrMaxInRatio the input is more than 30% of the input balance
+	ErrMaxInRatio = errors.New("max in ratio")
+	// ErrMaxOutRatio the output is more than 30% of the output balance
+	ErrMaxOutRatio = errors.New("max out ratio")
+	// ErrInvalidExponent the power is out of the range of LogExpMath
+	ErrInvalidExponent = errors.New("invalid exponent")
+
+	// WeightedMaxInRatio maximum input relative to the input balance
+	WeightedMaxInRatio = big.NewInt(3e17)
+	// WeightedMaxOutRatio maximum output relative to the output balance
+	WeightedMaxOutRatio = big.NewInt(3e17)
+
+	one18 = constants.B1e18
+	one20 = new(big.Int).Exp(constants.Ten, big.NewInt(20), nil)
+	one36 = new(big.Int).Exp(constants.Ten, big.NewInt(36), nil)
+
+	maxPowRelativeError = big.NewInt(10000)
+
+	maxNaturalExponent = new(big.Int).Mul(big.NewInt(130), one18)
+	minNaturalExponent = new(big.Int).Mul(big.NewInt(-41), one18)
+	ln36LowerBound     = new(big.Int).Sub(one18, big.NewInt(1e17))
+	ln36UpperBound     = new(big.Int).Add(one18, big.NewInt(1e17))
+	mildExponentBound  = new(big.Int).Div(new(big.Int).Lsh(constants.One, 254), one20)
+
+	// 18 decimals x, e^x without decimals
+	expX0 = decimalToBig("128000000000000000000")
+	expA0 = decimalToBig("38877084059945950922200000000000000000000000000000000000")
+	expX1 = decimalToBig("64000000000000000000")
+	expA1 = decimalToBig("6235149080811616882910000000")
+
+	// 20 decimals x and e^x
+	lnTerms = []struct{ x, a *big.Int }{
+		{decimalToBig("3200000000000000000000"), decimalToBig("7896296018268069516100000000000000")},
+		{decimalToBig("1600000000000000000000"), decimalToBig("888611052050787263676000000")},
+		{decimalToBig("800000000000000000000"), decimalToBig("298095798704172827474000")},
+		{decimalToBig("400000000000000000000"), decimalToBig("5459815003314423907810")},
+		{decimalToBig("200000000000000000000"), decimalToBig("738905609893065022723")},
+		{decimalToBig("100000000000000000000"), decimalToBig("271828182845904523536")},
+		{decimalToBig("50000000000000000000"), decimalToBig("164872127070012814685")},
+		{decimalToBig("25000000000000000000"), decimalToBig("128402541668774148407")},
+		{decimalToBig("12500000000000000000"), decimalToBig("113314845306682631683")},
+		{decimalToBig("6250000000000000000"), decimalToBig("106449445891785942956")},
+	}
+)
+
+func decimalToBig(s string) *big.Int {
+	b, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid decimal " + s)
+	}
+	return b
+}
+
+// MulDown returns a * b rounded down
+func MulDown(a, b *big.Int) *big.Int {
+	return mulDiv(a, b, one18)
+}
+
+// MulUp returns a * b rounded up
+func MulUp(a, b *big.Int) *big.Int {
+	product := mul(a, b)
+	if product.Sign() == 0 {
+		return product
+	}
+	return add(div(product.Sub(product, constants.One), one18), constants.One)
+}
+
+// DivDown returns a / b rounded down
+func DivDown(a, b *big.Int) *big.Int {
+	return mulDiv(a, one18, b)
+}
+
+// DivUp returns a / b rounded up
+func DivUp(a, b *big.Int) *big.Int {
+	if a.Sign() == 0 {
+		return new(big.Int)
+	}
+	aInflated := mul(a, one18)
+	return add(div(aInflated.Sub(aInflated, constants.One), b), constants.One)
+}
+
+// Complement returns 1 - x, or 0 if x is more than 1
+func Complement(x *big.Int) *big.Int {
+	if x.Cmp(one18) < 0 {
+		return new(big.Int).Sub(one18, x)
+	}
+	return new(big.Int)
+}
+
+// PowUp returns x^y rounded up, x and y are fixed point
+func PowUp(x, y *big.Int) (*big.Int, error) {
+	switch {
+	case y.Cmp(one18) == 0:
+		return new(big.Int).Set(x), nil
+	case y.Cmp(mul(one18, constants.Two)) == 0:
+		return MulUp(x, x), nil
+	case y.Cmp(mul(one18, constants.Four)) == 0:
+		square := MulUp(x, x)
+		return MulUp(square, square), nil
+	}
+	raw, err := Pow(x, y)
+	if err != nil {
+		return nil, err
+	}
+	maxError := add(MulUp(raw, maxPowRelativeError), constants.One)
+	return raw.Add(raw, maxError), nil
+}
+
+// Pow returns x^y = exp(y * ln(x)), x and y are fixed point, as LogExpMath.pow
+func Pow(x, y *big.Int) (*big.Int, error) {
+	if y.Sign() == 0 {
+		return new(big.Int).Set(one18), nil
+	}
+	if x.Sign() == 0 {
+		return new(big.Int), nil
+	}
+	if x.BitLen() > 255 || y.Cmp(mildExponentBound) >= 0 {
+		return nil, ErrInvalidExponent
+	}
+
+	var logxTimesY *big.Int
+	if ln36LowerBound.Cmp(x) < 0 && x.Cmp(ln36UpperBound) < 0 {
+		ln36X := ln36(x)
+		// ln36X has 36 decimals, multiply in two parts to avoid losing precision
+		logxTimesY = mul(new(big.Int).Quo(ln36X, one18), y)
+		logxTimesY.Add(logxTimesY, new(big.Int).Quo(mul(new(big.Int).Rem(ln36X, one18), y), one18))
+	} else {
+		logxTimesY = mul(ln(x), y)
+	}
+	logxTimesY.Quo(logxTimesY, one18)
+	if logxTimesY.Cmp(minNaturalExponent) < 0 || logxTimesY.Cmp(maxNaturalExponent) > 0 {
+		return nil, ErrInvalidExponent
+	}
+	return exp(logxTimesY), nil
+}
+
+// exp returns e^x, x is fixed point in [minNaturalExponent, maxNaturalExponent]
+func exp(x *big.Int) *big.Int {
+	if x.Sign() < 0 {
+		return new(big.Int).Quo(mul(one18, one18), exp(new(big.Int).Neg(x)))
+	}
+
+	x = new(big.Int).Set(x)
+	firstAN := constants.One
+	if x.Cmp(expX0) >= 0 {
+		x.Sub(x, expX0)
+		firstAN = expA0
+	} else if x.Cmp(expX1) >= 0 {
+		x.Sub(x, expX1)
+		firstAN = expA1
+	}
+
+	// 20 decimals from here
+	x.Mul(x, constants.B100)
+	product := new(big.Int).Set(one20)
+	// e^x2 to e^x9
+	for _, term := range lnTerms[:8] {
+		if x.Cmp(term.x) >= 0 {
+			x.Sub(x, term.x)
+			product.Quo(product.Mul(product, term.a), one20)
+		}
+	}
+
+	// taylor series of the remaining x < x9, 12 terms
+	seriesSum := new(big.Int).Set(one20)
+	term := new(big.Int).Set(x)
+	seriesSum.Add(seriesSum, term)
+	for i := int64(2); i <= 12; i++ {
+		term = new(big.Int).Quo(new(big.Int).Quo(mul(term, x), one20), big.NewInt(i))
+		seriesSum.Add(seriesSum, term)
+	}
+
+	result := new(big.Int).Quo(mul(product, seriesSum), one20)
+	return result.Mul(result, firstAN).Quo(result, constants.B100)
+}
+
+// ln returns the natural logarithm of a, a is fixed point
+func ln(a *big.Int) *big.Int {
+	if a.Cmp(one18) < 0 {
+		return new(big.Int).Neg(ln(new(big.Int).Quo(mul(one18, one18), a)))
+	}
+
+	a = new(big.Int).Set(a)
+	sum := new(big.Int)
+	if a.Cmp(mul(expA0, one18)) >= 0 {
+		a.Quo(a, expA0)
+		sum.Add(sum, expX0)
+	}
+	if a.Cmp(mul(expA1, one18)) >= 0 {
+		a.Quo(a, expA1)
+		sum.Add(sum, expX1)
+	}
+
+	// 20 decimals from here
+	sum.Mul(sum, constants.B100)
+	a.Mul(a, constants.B100)
+	for _, term := range lnTerms {
+		if a.Cmp(term.a) >= 0 {
+			a.Quo(mul(a, one20), term.a)
+			sum.Add(sum, term.x)
+		}
+	}
+
+	// ln(a) = 2 * atanh(z), z = (a - 1) / (a + 1), odd terms up to z^11
+	z := new(big.Int).Quo(mul(new(big.Int).Sub(a, one20), one20), add(a, one20))
+	zSquared := new(big.Int).Quo(mul(z, z), one20)
+	num := new(big.Int).Set(z)
+	seriesSum := new(big.Int).Set(num)
+	for i := int64(3); i <= 11; i += 2 {
+		num = new(big.Int).Quo(mul(num, zSquared), one20)
+		seriesSum.Add(seriesSum, new(big.Int).Quo(num, big.NewInt(i)))
+	}
+	seriesSum.Mul(seriesSum, constants.Two)
+	return sum.Add(sum, seriesSum).Quo(sum, constants.B100)
+}
+
+// ln36 returns the natural logarithm of x close to 1 with 36 decimals
+func ln36(x *big.Int) *big.Int {
+	x = mul(x, one18)
+	z := new(big.Int).Quo(mul(new(big.Int).Sub(x, one36), one36), add(x, one36))
+	zSquared := new(big.Int).Quo(mul(z, z), one36)
+	num := new(big.Int).Set(z)
+	seriesSum := new(big.Int).Set(num)
+	for i := int64(3); i <= 15; i += 2 {
+		num = new(big.Int).Quo(mul(num, zSquared), one36)
+		seriesSum.Add(seriesSum, new(big.Int).Quo(num, big.NewInt(i)))
+	}
+	return seriesSum.Mul(seriesSum, constants.Two)
+}
+
+// CalcOutGivenIn returns the output of a weighted pool for amountIn, balances and amounts are upscaled to 18
+// decimals, the swap fee is already deducted from amountIn.
+// out = balanceOut * (1 - (balanceIn / (balanceIn + amountIn))^(weightIn / weightOut))
+func CalcOutGivenIn(balanceIn, weightIn, balanceOut, weightOut, amountIn *big.Int) (*big.Int, error) {
+	if amountIn.Cmp(MulDown(balanceIn, WeightedMaxInRatio)) > 0 {
+		return nil, ErrMaxInRatio
+	}
+	base := DivUp(balanceIn, add(balanceIn, amountIn))
+	exponent := DivDown(weightIn, weightOut)
+	power, err := PowUp(base, exponent)
+	if err != nil {
+		return nil, err
+	}
+	return MulDown(balanceOut, Complement(power)), nil
+}
+
+// CalcInGivenOut returns the input of a weighted pool for amountOut, balances and amounts are upscaled to 18
+// decimals, the swap fee is not added to the result.
+// in = balanceIn * ((balanceOut / (balanceOut - amountOut))^(weightOut / weightIn) - 1)
+func CalcInGivenOut(balanceIn, weightIn, balanceOut, weightOut, amountOut *big.Int) (*big.Int, error) {
+	if amountOut.Cmp(MulDown(balanceOut, WeightedMaxOutRatio)) > 0 {
+		return nil, ErrMaxOutRatio
+	}
+	base := DivUp(balanceOut, new(big.Int).Sub(balanceOut, amountOut))
+	exponent := DivUp(weightOut, weightIn)
+	power, err := PowUp(base, exponent)
+	if err != nil {
+		return nil, err
+	}
+	return MulUp(balanceIn, power.Sub(power, one18)), nil
+}
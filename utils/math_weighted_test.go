@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func toFloat(x *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(x), new(big.Float).SetInt(one18)).Float64()
+	return f
+}
+
+func fromFloat(f float64) *big.Int {
+	x, _ := new(big.Float).Mul(big.NewFloat(f), new(big.Float).SetInt(one18)).Int(nil)
+	return x
+}
+
+func TestWeightedMath(t *testing.T) {
+	// LogExpMath is accurate to 1e-14, float64 to about 1e-15
+	for _, c := range [][2]float64{{2, 0.5}, {0.5, 4.2}, {1.05, 0.25}, {0.99, 3}, {1e6, 1.5}, {0.001, 0.75}, {7, 0}} {
+		x, y := fromFloat(c[0]), fromFloat(c[1])
+		got, err := Pow(x, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := math.Pow(toFloat(x), toFloat(y))
+		if math.Abs(toFloat(got)-expect)/expect > 1e-12 {
+			t.Errorf("%v^%v: expect[%+v], but got[%+v]", c[0], c[1], expect, toFloat(got))
+		}
+		up, err := PowUp(x, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c[1] != 0 && up.Cmp(got) <= 0 {
+			t.Errorf("expect PowUp more than [%+v], but got[%+v]", got, up)
+		}
+	}
+	if _, err := Pow(fromFloat(1e30), fromFloat(10)); err != ErrInvalidExponent {
+		t.Errorf("expect[%+v], but got[%+v]", ErrInvalidExponent, err)
+	}
+
+	// 80/20 pool, out = balanceOut * (1 - (balanceIn / (balanceIn + in))^4)
+	balanceIn, balanceOut := fromFloat(1000), fromFloat(4000)
+	weightIn, weightOut := fromFloat(0.8), fromFloat(0.2)
+	out, err := CalcOutGivenIn(balanceIn, weightIn, balanceOut, weightOut, fromFloat(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := 4000 * (1 - math.Pow(1000.0/1010.0, 4))
+	if math.Abs(toFloat(out)-expect)/expect > 1e-12 {
+		t.Errorf("expect[%+v], but got[%+v]", expect, toFloat(out))
+	}
+	// and back, PowUp rounds up by 1e-14 relative on both ways
+	in, err := CalcInGivenOut(balanceIn, weightIn, balanceOut, weightOut, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Cmp(fromFloat(10)) < 0 || math.Abs(toFloat(in)-10)/10 > 1e-11 {
+		t.Errorf("expect about [%+v], but got[%+v]", 10, toFloat(in))
+	}
+
+	if _, err := CalcOutGivenIn(balanceIn, weightIn, balanceOut, weightOut, fromFloat(301)); err != ErrMaxInRatio {
+		t.Errorf("expect[%+v], but got[%+v]", ErrMaxInRatio, err)
+	}
+	if _, err := CalcInGivenOut(balanceIn, weightIn, balanceOut, weightOut, fromFloat(1201)); err != ErrMaxOutRatio {
+		t.Errorf("expect[%+v], but got[%+v]", ErrMaxOutRatio, err)
+	}
+}